@@ -18,27 +18,35 @@ type Alumni struct {
 	NoTelepon  string             `bson:"no_telepon" json:"no_telepon"`
 	Alamat     *string            `bson:"alamat" json:"alamat"`
 	IsDelete   bool               `bson:"is_delete" json:"is_delete"`
+	Version    int64              `bson:"version" json:"version"`
 	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
 }
 
 type CreateAlumniRequest struct {
-	NIM        string `json:"nim"`
-	Nama       string `json:"nama"`
-	Jurusan    string `json:"jurusan"`
-	Angkatan   int    `json:"angkatan"`
-	TahunLulus int    `json:"tahun_lulus"`
-	Email      string `json:"email"`
-	NoTelepon  string `json:"no_telepon"`
-	Alamat     string `json:"alamat"`
+	NIM        string `json:"nim" validate:"required,nim"`
+	Nama       string `json:"nama" validate:"required,max=100"`
+	Jurusan    string `json:"jurusan" validate:"required,max=100"`
+	Angkatan   int    `json:"angkatan" validate:"required,min=1950,max=2100"`
+	TahunLulus int    `json:"tahun_lulus" validate:"required,min=1950,max=2100,gtefield=Angkatan"`
+	Email      string `json:"email" validate:"required,email,max=254"`
+	NoTelepon  string `json:"no_telepon" validate:"omitempty,phone"`
+	Alamat     string `json:"alamat" validate:"max=500"`
 }
 
 type UpdateAlumniRequest struct {
-	Nama       string `json:"nama"`
-	Jurusan    string `json:"jurusan"`
-	Angkatan   int    `json:"angkatan"`
-	TahunLulus int    `json:"tahun_lulus"`
-	Email      string `json:"email"`
-	NoTelepon  string `json:"no_telepon"`
-	Alamat     string `json:"alamat"`
+	Nama       string `json:"nama" validate:"required,max=100"`
+	Jurusan    string `json:"jurusan" validate:"required,max=100"`
+	Angkatan   int    `json:"angkatan" validate:"required,min=1950,max=2100"`
+	TahunLulus int    `json:"tahun_lulus" validate:"required,min=1950,max=2100,gtefield=Angkatan"`
+	Email      string `json:"email" validate:"required,email,max=254"`
+	NoTelepon  string `json:"no_telepon" validate:"omitempty,phone"`
+	Alamat     string `json:"alamat" validate:"max=500"`
+}
+
+// UpdateMyAlumniRequest adalah data alumni yang boleh diubah sendiri lewat /api/me/alumni.
+// Identitas (NIM, nama, jurusan, angkatan, tahun lulus, email) hanya diubah admin.
+type UpdateMyAlumniRequest struct {
+	NoTelepon string `json:"no_telepon" validate:"omitempty,phone"`
+	Alamat    string `json:"alamat" validate:"max=500"`
 }
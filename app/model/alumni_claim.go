@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Status klaim profil alumni
+const (
+	ClaimStatusPendingCode   = "pending_code"   // menunggu kode yang dikirim ke email alumni
+	ClaimStatusPendingReview = "pending_review" // menunggu keputusan admin (sengketa / tanpa email)
+	ClaimStatusApproved      = "approved"
+	ClaimStatusRejected      = "rejected"
+	ClaimStatusExpired       = "expired"
+)
+
+// AlumniClaim adalah permintaan user untuk menautkan akunnya ke data alumni.
+type AlumniClaim struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID        primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	AlumniID      primitive.ObjectID  `bson:"alumni_id" json:"alumni_id"`
+	NIM           string              `bson:"nim" json:"nim"`
+	Status        string              `bson:"status" json:"status"`
+	Note          string              `bson:"note,omitempty" json:"note,omitempty"`
+	DisputeReason string              `bson:"dispute_reason,omitempty" json:"dispute_reason,omitempty"`
+	CodeHash      string              `bson:"code_hash,omitempty" json:"-"`
+	CodeExpiresAt *time.Time          `bson:"code_expires_at,omitempty" json:"code_expires_at,omitempty"`
+	Attempts      int                 `bson:"attempts" json:"-"`
+	ReviewedBy    *primitive.ObjectID `bson:"reviewed_by,omitempty" json:"reviewed_by,omitempty"`
+	ReviewedAt    *time.Time          `bson:"reviewed_at,omitempty" json:"reviewed_at,omitempty"`
+	ReviewNote    string              `bson:"review_note,omitempty" json:"review_note,omitempty"`
+	CreatedAt     time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time           `bson:"updated_at" json:"updated_at"`
+}
+
+type CreateAlumniClaimRequest struct {
+	NIM        string `json:"nim"`
+	TahunLulus int    `json:"tahun_lulus"`
+	// RequestReview langsung mengirim klaim ke admin, mis. jika email alumni sudah tidak bisa diakses
+	RequestReview bool   `json:"request_review"`
+	Note          string `json:"note"`
+}
+
+type VerifyAlumniClaimRequest struct {
+	Code string `json:"code"`
+}
+
+type ReviewAlumniClaimRequest struct {
+	Note string `json:"note"`
+}
@@ -0,0 +1,53 @@
+package model
+
+// Mode import alumni
+const (
+	ImportModeDryRun  = "dry_run"
+	ImportModeExecute = "execute"
+)
+
+// Perlakuan untuk NIM yang sudah ada di database
+const (
+	ImportOnDuplicateUpdate = "update"
+	ImportOnDuplicateSkip   = "skip"
+)
+
+// Hasil per baris import
+const (
+	ImportActionCreate  = "create"
+	ImportActionUpdate  = "update"
+	ImportActionSkip    = "skip"
+	ImportActionInvalid = "invalid"
+	ImportActionFailed  = "failed"
+)
+
+// ImportFields adalah field alumni yang bisa diisi dari kolom file import.
+var ImportFields = []string{"nim", "nama", "jurusan", "angkatan", "tahun_lulus", "email", "no_telepon", "alamat"}
+
+// ImportRowResult adalah hasil satu baris data. Row mengikuti nomor baris di file (header = baris 1).
+// Duplicate bernilai "file" jika NIM sudah muncul di baris sebelumnya, atau "database" jika
+// NIM sudah terdaftar sebagai alumni aktif.
+type ImportRowResult struct {
+	Row       int          `json:"row"`
+	NIM       string       `json:"nim"`
+	Action    string       `json:"action"`
+	Duplicate string       `json:"duplicate,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// ImportReport adalah ringkasan import. Rows hanya berisi baris yang tidak valid, duplikat
+// atau gagal ditulis; baris baru yang valid cukup dihitung di Created.
+type ImportReport struct {
+	Mode        string            `json:"mode"`
+	Format      string            `json:"format"`
+	OnDuplicate string            `json:"on_duplicate"`
+	Columns     map[string]string `json:"columns"`
+	TotalRows   int               `json:"total_rows"`
+	Valid       int               `json:"valid"`
+	Invalid     int               `json:"invalid"`
+	Created     int               `json:"created"`
+	Updated     int               `json:"updated"`
+	Skipped     int               `json:"skipped"`
+	Failed      int               `json:"failed"`
+	Rows        []ImportRowResult `json:"rows"`
+}
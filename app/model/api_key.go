@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// APIKeyPrefix adalah awalan semua API key, memudahkan secret scanning.
+// Format lengkap: gfm_<prefix>_<secret>. Hanya prefix dan hash secret yang disimpan.
+const APIKeyPrefix = "gfm"
+
+type APIKey struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Name        string             `bson:"name" json:"name"`
+	Prefix      string             `bson:"prefix" json:"prefix"`
+	KeyHash     string             `bson:"key_hash" json:"-"`
+	Permissions []string           `bson:"permissions" json:"permissions"`
+	CreatedBy   primitive.ObjectID `bson:"created_by" json:"created_by"`
+	ExpiresAt   *time.Time         `bson:"expires_at" json:"expires_at"`
+	LastUsedAt  *time.Time         `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	LastUsedIP  string             `bson:"last_used_ip,omitempty" json:"last_used_ip,omitempty"`
+	RevokedAt   *time.Time         `bson:"revoked_at" json:"revoked_at"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name"`
+	Permissions   []string `json:"permissions"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+// CreateAPIKeyResponse berisi key lengkap yang hanya ditampilkan sekali saat dibuat.
+type CreateAPIKeyResponse struct {
+	APIKey APIKey `json:"api_key"`
+	Key    string `json:"key"`
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Aksi yang dicatat di audit log
+const (
+	AuditActionImpersonationStart   = "impersonation.start"
+	AuditActionImpersonationRequest = "impersonation.request"
+)
+
+// AuditLog mencatat tindakan admin atas nama user lain. Untuk impersonation, ActorID adalah
+// admin yang bertindak dan UserID adalah user yang di-impersonate.
+type AuditLog struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Action        string             `bson:"action" json:"action"`
+	ActorID       primitive.ObjectID `bson:"actor_id" json:"actor_id"`
+	ActorUsername string             `bson:"actor_username" json:"actor_username"`
+	UserID        primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Method        string             `bson:"method" json:"method"`
+	Path          string             `bson:"path" json:"path"`
+	Status        int                `bson:"status" json:"status"`
+	Blocked       bool               `bson:"blocked" json:"blocked"`
+	Reason        string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	IP            string             `bson:"ip" json:"ip"`
+	UserAgent     string             `bson:"user_agent" json:"user_agent"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type ImpersonateRequest struct {
+	Reason string `json:"reason"`
+}
+
+type ImpersonateResponse struct {
+	Token     string `json:"token"`
+	ExpiresIn int64  `json:"expires_in"`
+	User      User   `json:"user"`
+}
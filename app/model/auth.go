@@ -8,13 +8,35 @@ import (
 )
 
 type User struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	Username  string             `bson:"username" json:"username"`
-	Email     string             `bson:"email" json:"email"`
-	Password  string             `bson:"password_hash" json:"-"`
-	Role      string             `bson:"role" json:"role"`
-	IsDelete  bool               `bson:"is_delete" json:"is_delete"`
-	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Username        string             `bson:"username" json:"username"`
+	Email           string             `bson:"email" json:"email"`
+	EmailVerified   bool               `bson:"email_verified" json:"email_verified"`
+	EmailVerifiedAt *time.Time         `bson:"email_verified_at,omitempty" json:"email_verified_at,omitempty"`
+	Password        string             `bson:"password_hash" json:"-"`
+	Role            string             `bson:"role" json:"role"`
+	IsDelete        bool               `bson:"is_delete" json:"is_delete"`
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+
+	FailedLoginAttempts int        `bson:"failed_login_attempts" json:"failed_login_attempts"`
+	LastFailedLoginAt   *time.Time `bson:"last_failed_login_at,omitempty" json:"last_failed_login_at,omitempty"`
+	LockedUntil         *time.Time `bson:"locked_until,omitempty" json:"locked_until,omitempty"`
+
+	TwoFactorEnabled  bool     `bson:"two_factor_enabled" json:"two_factor_enabled"`
+	TOTPSecret        string   `bson:"totp_secret,omitempty" json:"-"`
+	TOTPPendingSecret string   `bson:"totp_pending_secret,omitempty" json:"-"`
+	TOTPLastStep      int64    `bson:"totp_last_step,omitempty" json:"-"`
+	RecoveryCodes     []string `bson:"recovery_codes,omitempty" json:"-"`
+
+	// Identitas SSO (OIDC) yang ditautkan; subject unik per issuer
+	OIDCIssuer  string `bson:"oidc_issuer,omitempty" json:"-"`
+	OIDCSubject string `bson:"oidc_subject,omitempty" json:"-"`
+}
+
+type RegisterRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
 }
 
 type LoginRequest struct {
@@ -23,13 +45,151 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	User  User   `json:"user"`
-	Token string `json:"token"`
+	User                   User   `json:"user"`
+	Token                  string `json:"token"`
+	RefreshToken           string `json:"refresh_token"`
+	ExpiresIn              int64  `json:"expires_in"`
+	TwoFactorSetupRequired bool   `json:"two_factor_setup_required,omitempty"`
+}
+
+// TwoFactorChallengeResponse dikembalikan /login jika user mengaktifkan 2FA.
+// ChallengeToken ditukar di /login/2fa bersama kode TOTP atau recovery code.
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int64  `json:"expires_in"`
+}
+
+type LoginTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password     string `json:"password"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
 }
 
 type JWTClaims struct {
-	UserID   primitive.ObjectID `json:"user_id"`
-	Username string             `json:"username"`
-	Role     string             `json:"role"`
+	UserID    primitive.ObjectID `json:"user_id"`
+	Username  string             `json:"username"`
+	Role      string             `json:"role"`
+	SessionID string             `json:"sid,omitempty"`
+	Act       *ActorClaim        `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
+
+// ActorClaim (claim "act") diisi pada token impersonation: admin yang bertindak sebagai user
+// di UserID. SessionID adalah session login admin; jika dicabut, token impersonation ikut mati.
+type ActorClaim struct {
+	UserID    primitive.ObjectID `json:"user_id"`
+	Username  string             `json:"username"`
+	SessionID string             `json:"sid,omitempty"`
+}
+
+// RefreshToken disimpan dalam bentuk hash; token asli hanya dikirim sekali ke client.
+// Semua token hasil rotasi dari satu login berbagi FamilyID yang sama.
+type RefreshToken struct {
+	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID     primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	TokenHash  string              `bson:"token_hash" json:"-"`
+	FamilyID   string              `bson:"family_id" json:"family_id"`
+	ExpiresAt  time.Time           `bson:"expires_at" json:"expires_at"`
+	CreatedAt  time.Time           `bson:"created_at" json:"created_at"`
+	RevokedAt  *time.Time          `bson:"revoked_at" json:"revoked_at"`
+	ReplacedBy *primitive.ObjectID `bson:"replaced_by,omitempty" json:"replaced_by,omitempty"`
+}
+
+// RevokedToken mencatat access token (berdasarkan jti) yang dicabut sebelum expired.
+type RevokedToken struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	JTI       string             `bson:"jti" json:"jti"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"`
+	RevokedAt time.Time          `bson:"revoked_at" json:"revoked_at"`
+}
+
+// Tujuan OneTimeToken
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposeTwoFactorLogin    = "two_factor_login"
+)
+
+// OneTimeToken adalah token sekali pakai yang disimpan dalam bentuk hash (reset password, dsb).
+type OneTimeToken struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Purpose   string             `bson:"purpose" json:"purpose"`
+	TokenHash string             `bson:"token_hash" json:"-"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"`
+	UsedAt    *time.Time         `bson:"used_at" json:"used_at"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// UpdateUserRoleRequest dipakai admin untuk mengganti role user.
+type UpdateUserRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// AdminResetPasswordRequest dipakai admin untuk mengganti password user secara langsung.
+type AdminResetPasswordRequest struct {
+	Password string `json:"password"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
+
+// OIDCState menyimpan login SSO yang sedang berjalan: hash parameter state,
+// code_verifier PKCE dan nonce untuk dicocokkan saat callback.
+type OIDCState struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	StateHash    string             `bson:"state_hash" json:"-"`
+	CodeVerifier string             `bson:"code_verifier" json:"-"`
+	Nonce        string             `bson:"nonce" json:"-"`
+	// InvitationHash adalah hash token undangan jika login SSO dimulai dari link undangan
+	InvitationHash string    `bson:"invitation_hash,omitempty" json:"-"`
+	ExpiresAt      time.Time `bson:"expires_at" json:"expires_at"`
+	CreatedAt      time.Time `bson:"created_at" json:"created_at"`
+}
+
+// LoginThrottle menghitung percobaan login gagal per kunci (mis. "ip:10.0.0.1").
+// Count di-reset jika tidak ada kegagalan baru selama satu window. ExpiresAt adalah akhir
+// window atau lock (mana yang lebih lama); setelahnya dokumen dihapus TTL index.
+type LoginThrottle struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Key         string             `bson:"key" json:"key"`
+	Count       int                `bson:"count" json:"count"`
+	LastAt      time.Time          `bson:"last_at" json:"last_at"`
+	LockedUntil *time.Time         `bson:"locked_until,omitempty" json:"locked_until,omitempty"`
+	ExpiresAt   time.Time          `bson:"expires_at" json:"expires_at"`
+}
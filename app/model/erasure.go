@@ -0,0 +1,68 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Status permintaan penghapusan data pribadi
+const (
+	ErasureStatusPending   = "pending"
+	ErasureStatusRunning   = "running"
+	ErasureStatusCompleted = "completed"
+	ErasureStatusFailed    = "failed"
+	ErasureStatusRejected  = "rejected"
+	ErasureStatusCancelled = "cancelled"
+)
+
+// Mode penghapusan. Anonymize mempertahankan data pekerjaan (tanpa identitas) untuk statistik;
+// delete menghapus permanen semuanya. Foto dan sertifikat selalu dihapus beserta file-nya.
+const (
+	ErasureModeAnonymize = "anonymize"
+	ErasureModeDelete    = "delete"
+)
+
+// ErasureRequest adalah permintaan user untuk menghapus data pribadinya (UU PDP).
+// Penghapusan baru dijalankan setelah dikonfirmasi admin.
+type ErasureRequest struct {
+	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID     primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	Username   string              `bson:"username" json:"username"`
+	Reason     string              `bson:"reason,omitempty" json:"reason,omitempty"`
+	Status     string              `bson:"status" json:"status"`
+	Mode       string              `bson:"mode,omitempty" json:"mode,omitempty"`
+	ReviewedBy *primitive.ObjectID `bson:"reviewed_by,omitempty" json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time          `bson:"reviewed_at,omitempty" json:"reviewed_at,omitempty"`
+	ReviewNote string              `bson:"review_note,omitempty" json:"review_note,omitempty"`
+	Summary    *ErasureSummary     `bson:"summary,omitempty" json:"summary,omitempty"`
+	Error      string              `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt  time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time           `bson:"updated_at" json:"updated_at"`
+}
+
+// ErasureSummary mencatat jumlah data yang dihapus / dianonimkan.
+type ErasureSummary struct {
+	User         string `bson:"user" json:"user"`
+	Alumni       string `bson:"alumni" json:"alumni"`
+	Pekerjaan    int64  `bson:"pekerjaan" json:"pekerjaan"`
+	Photos       int64  `bson:"photos" json:"photos"`
+	Certificates int64  `bson:"certificates" json:"certificates"`
+	Files        int    `bson:"files" json:"files"`
+}
+
+type CreateErasureRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ApproveErasureRequest mengonfirmasi penghapusan. ConfirmUsername harus sama dengan
+// username pemilik data, sebagai pengaman terhadap salah klik.
+type ApproveErasureRequest struct {
+	Mode            string `json:"mode"`
+	ConfirmUsername string `json:"confirm_username"`
+	Note            string `json:"note"`
+}
+
+type RejectErasureRequest struct {
+	Note string `json:"note"`
+}
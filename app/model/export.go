@@ -0,0 +1,15 @@
+package model
+
+// Format ekspor yang didukung
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatXLSX   = "xlsx"
+	ExportFormatNDJSON = "ndjson"
+)
+
+// AlumniExport adalah satu dokumen ekspor alumni. PekerjaanSaatIni hanya terisi jika ekspor
+// meminta join pekerjaan dan alumni memiliki pekerjaan aktif.
+type AlumniExport struct {
+	Alumni           `bson:",inline"`
+	PekerjaanSaatIni *PekerjaanAlumni `bson:"pekerjaan_saat_ini,omitempty" json:"pekerjaan_saat_ini,omitempty"`
+}
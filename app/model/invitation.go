@@ -0,0 +1,55 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Status undangan. Undangan pending yang melewati ExpiresAt dianggap expired.
+const (
+	InvitationStatusPending  = "pending"
+	InvitationStatusAccepted = "accepted"
+	InvitationStatusRevoked  = "revoked"
+	InvitationStatusExpired  = "expired"
+)
+
+// Invitation adalah undangan admin kepada alumni untuk membuat akun. Token di link undangan
+// hanya disimpan dalam bentuk hash; akun yang dibuat langsung ditautkan ke AlumniID.
+type Invitation struct {
+	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	AlumniID   primitive.ObjectID  `bson:"alumni_id" json:"alumni_id"`
+	NIM        string              `bson:"nim" json:"nim"`
+	Email      string              `bson:"email" json:"email"`
+	TokenHash  string              `bson:"token_hash" json:"-"`
+	Status     string              `bson:"status" json:"status"`
+	InvitedBy  primitive.ObjectID  `bson:"invited_by" json:"invited_by"`
+	ExpiresAt  time.Time           `bson:"expires_at" json:"expires_at"`
+	AcceptedBy *primitive.ObjectID `bson:"accepted_by,omitempty" json:"accepted_by,omitempty"`
+	AcceptedAt *time.Time          `bson:"accepted_at,omitempty" json:"accepted_at,omitempty"`
+	RevokedAt  *time.Time          `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	CreatedAt  time.Time           `bson:"created_at" json:"created_at"`
+}
+
+type CreateInvitationRequest struct {
+	AlumniID string `json:"alumni_id"`
+}
+
+// BulkInvitationRequest mengundang semua alumni yang belum punya akun pada angkatan dan/atau jurusan.
+type BulkInvitationRequest struct {
+	Angkatan int    `json:"angkatan"`
+	Jurusan  string `json:"jurusan"`
+}
+
+// InvitationSkip menjelaskan alumni yang tidak diundang pada undangan massal.
+type InvitationSkip struct {
+	AlumniID primitive.ObjectID `json:"alumni_id"`
+	NIM      string             `json:"nim"`
+	Reason   string             `json:"reason"`
+}
+
+type AcceptInvitationRequest struct {
+	Token    string `json:"token"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
@@ -19,33 +19,41 @@ type PekerjaanAlumni struct {
 	StatusPekerjaan     string             `bson:"status_pekerjaan" json:"status_pekerjaan"`
 	DeskripsiPekerjaan  string             `bson:"deskripsi_pekerjaan" json:"deskripsi_pekerjaan"`
 	IsDelete            bool               `bson:"is_delete" json:"is_delete"`
+	Version             int64              `bson:"version" json:"version"`
 	CreatedAt           time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt           time.Time          `bson:"updated_at" json:"updated_at"`
 }
 
+// Status pekerjaan yang diterima
+const (
+	StatusPekerjaanAktif    = "aktif"
+	StatusPekerjaanSelesai  = "selesai"
+	StatusPekerjaanResigned = "resigned"
+)
+
 type CreatePekerjaanRequest struct {
-	AlumniID            string `json:"alumni_id"`
-	NamaPerusahaan      string `json:"nama_perusahaan"`
-	PosisiJabatan       string `json:"posisi_jabatan"`
-	BidangIndustri      string `json:"bidang_industri"`
-	LokasiKerja         string `json:"lokasi_kerja"`
-	GajiRange           string `json:"gaji_range"`
-	TanggalMulaiKerja   string `json:"tanggal_mulai_kerja"`
-	TanggalSelesaiKerja *string `json:"tanggal_selesai_kerja"`
-	StatusPekerjaan     string `json:"status_pekerjaan"`
-	DeskripsiPekerjaan  string `json:"deskripsi_pekerjaan"`
+	AlumniID            string  `json:"alumni_id" validate:"required,mongodb"`
+	NamaPerusahaan      string  `json:"nama_perusahaan" validate:"required,max=200"`
+	PosisiJabatan       string  `json:"posisi_jabatan" validate:"required,max=100"`
+	BidangIndustri      string  `json:"bidang_industri" validate:"required,max=100"`
+	LokasiKerja         string  `json:"lokasi_kerja" validate:"required,max=100"`
+	GajiRange           string  `json:"gaji_range" validate:"max=50"`
+	TanggalMulaiKerja   string  `json:"tanggal_mulai_kerja" validate:"required,date"`
+	TanggalSelesaiKerja *string `json:"tanggal_selesai_kerja" validate:"omitempty,date,date_gtefield=TanggalMulaiKerja"`
+	StatusPekerjaan     string  `json:"status_pekerjaan" validate:"required,oneof=aktif selesai resigned"`
+	DeskripsiPekerjaan  string  `json:"deskripsi_pekerjaan" validate:"max=2000"`
 }
 
 type UpdatePekerjaanRequest struct {
-	NamaPerusahaan      string  `json:"nama_perusahaan"`
-	PosisiJabatan       string  `json:"posisi_jabatan"`
-	BidangIndustri      string  `json:"bidang_industri"`
-	LokasiKerja         string  `json:"lokasi_kerja"`
-	GajiRange           string  `json:"gaji_range"`
-	TanggalMulaiKerja   string  `json:"tanggal_mulai_kerja"`
-	TanggalSelesaiKerja *string `json:"tanggal_selesai_kerja"`
-	StatusPekerjaan     string  `json:"status_pekerjaan"`
-	DeskripsiPekerjaan  string  `json:"deskripsi_pekerjaan"`
+	NamaPerusahaan      string  `json:"nama_perusahaan" validate:"required,max=200"`
+	PosisiJabatan       string  `json:"posisi_jabatan" validate:"required,max=100"`
+	BidangIndustri      string  `json:"bidang_industri" validate:"required,max=100"`
+	LokasiKerja         string  `json:"lokasi_kerja" validate:"required,max=100"`
+	GajiRange           string  `json:"gaji_range" validate:"max=50"`
+	TanggalMulaiKerja   string  `json:"tanggal_mulai_kerja" validate:"required,date"`
+	TanggalSelesaiKerja *string `json:"tanggal_selesai_kerja" validate:"omitempty,date,date_gtefield=TanggalMulaiKerja"`
+	StatusPekerjaan     string  `json:"status_pekerjaan" validate:"required,oneof=aktif selesai resigned"`
+	DeskripsiPekerjaan  string  `json:"deskripsi_pekerjaan" validate:"max=2000"`
 }
 
 type PekerjaanTrashResponse struct {
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Daftar permission yang dikenal aplikasi. Role menyimpan subset dari daftar ini.
+const (
+	PermAlumniRead       = "alumni:read"
+	PermAlumniWrite      = "alumni:write"
+	PermAlumniDelete     = "alumni:delete"
+	PermAlumniHardDelete = "alumni:hard_delete"
+	PermAlumniClaims     = "alumni:claims"
+	PermAlumniImport     = "alumni:import"
+	PermAlumniExport     = "alumni:export"
+
+	PermPekerjaanRead       = "pekerjaan:read"
+	PermPekerjaanReadAny    = "pekerjaan:read_any"
+	PermPekerjaanWrite      = "pekerjaan:write"
+	PermPekerjaanDelete     = "pekerjaan:delete"
+	PermPekerjaanRestore    = "pekerjaan:restore"
+	PermPekerjaanHardDelete = "pekerjaan:hard_delete"
+
+	PermFilesUploadAny = "files:upload_any"
+	PermFilesDeleteAny = "files:delete_any"
+
+	PermUsersRead        = "users:read"
+	PermUsersWrite       = "users:write"
+	PermUsersDelete      = "users:delete"
+	PermUsersImpersonate = "users:impersonate"
+
+	PermRolesManage       = "roles:manage"
+	PermAPIKeysManage     = "api_keys:manage"
+	PermAuditRead         = "audit:read"
+	PermInvitationsManage = "invitations:manage"
+	PermPrivacyManage     = "privacy:manage"
+)
+
+// AllPermissions dipakai untuk validasi input dan untuk role admin bawaan.
+var AllPermissions = []string{
+	PermAlumniRead, PermAlumniWrite, PermAlumniDelete, PermAlumniHardDelete, PermAlumniClaims, PermAlumniImport, PermAlumniExport,
+	PermPekerjaanRead, PermPekerjaanReadAny, PermPekerjaanWrite, PermPekerjaanDelete, PermPekerjaanRestore, PermPekerjaanHardDelete,
+	PermFilesUploadAny, PermFilesDeleteAny,
+	PermUsersRead, PermUsersWrite, PermUsersDelete, PermUsersImpersonate,
+	PermRolesManage, PermAPIKeysManage, PermAuditRead, PermInvitationsManage, PermPrivacyManage,
+}
+
+// Role bawaan yang dibuat saat startup
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// DefaultUserPermissions adalah permission awal role "user".
+var DefaultUserPermissions = []string{PermAlumniRead, PermPekerjaanRead}
+
+func IsValidPermission(perm string) bool {
+	for _, p := range AllPermissions {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+type Role struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Name        string             `bson:"name" json:"name"`
+	Description string             `bson:"description" json:"description"`
+	Permissions []string           `bson:"permissions" json:"permissions"`
+	IsSystem    bool               `bson:"is_system" json:"is_system"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateRoleRequest struct {
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Jenis security event
+const (
+	SecurityEventLogin                = "login"
+	SecurityEventLoginTwoFactor       = "login.2fa"
+	SecurityEventLoginOIDC            = "login.oidc"
+	SecurityEventLogout               = "logout"
+	SecurityEventTokenReuse           = "token.reuse"
+	SecurityEventPasswordChange       = "password.change"
+	SecurityEventPasswordResetRequest = "password.reset_request"
+	SecurityEventPasswordReset        = "password.reset"
+	SecurityEventPasswordAdminReset   = "password.admin_reset"
+	SecurityEventTwoFactorEnable      = "2fa.enable"
+	SecurityEventTwoFactorDisable     = "2fa.disable"
+	SecurityEventRecoveryCodes        = "2fa.recovery_codes"
+	SecurityEventSessionRevoke        = "session.revoke"
+	SecurityEventRoleChange           = "role.change"
+	SecurityEventEmailVerify          = "user.email_verify"
+	SecurityEventUserUnlock           = "user.unlock"
+	SecurityEventUserDelete           = "user.delete"
+	SecurityEventUserRestore          = "user.restore"
+	SecurityEventUserPurge            = "user.purge"
+	SecurityEventImpersonation        = "impersonation.start"
+)
+
+// Hasil security event
+const (
+	SecurityOutcomeSuccess = "success"
+	SecurityOutcomeFailure = "failure"
+	SecurityOutcomeBlocked = "blocked"
+)
+
+// SecurityEvent mencatat kejadian terkait keamanan akun (login, ganti password, perubahan role, ...).
+// UserID adalah akun yang terdampak; ActorID diisi jika aksi dilakukan admin atas akun tersebut.
+// Login gagal dengan username yang tidak dikenal disimpan tanpa UserID.
+type SecurityEvent struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	Type          string              `bson:"type" json:"type"`
+	Outcome       string              `bson:"outcome" json:"outcome"`
+	UserID        *primitive.ObjectID `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	Username      string              `bson:"username,omitempty" json:"username,omitempty"`
+	ActorID       *primitive.ObjectID `bson:"actor_id,omitempty" json:"actor_id,omitempty"`
+	ActorUsername string              `bson:"actor_username,omitempty" json:"actor_username,omitempty"`
+	Reason        string              `bson:"reason,omitempty" json:"reason,omitempty"`
+	Metadata      map[string]string   `bson:"metadata,omitempty" json:"metadata,omitempty"`
+	IP            string              `bson:"ip" json:"ip"`
+	UserAgent     string              `bson:"user_agent" json:"user_agent"`
+	CreatedAt     time.Time           `bson:"created_at" json:"created_at"`
+}
+
+// SecurityEventFilter berisi filter opsional untuk daftar security event (nilai kosong diabaikan).
+type SecurityEventFilter struct {
+	UserID  primitive.ObjectID
+	ActorID primitive.ObjectID
+	Type    string
+	Outcome string
+	IP      string
+	From    *time.Time
+	To      *time.Time
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session mewakili satu login yang berhasil. ID session sama dengan FamilyID refresh token
+// dan dibawa di access token sebagai claim "sid", sehingga mencabut session langsung
+// mematikan access token dan refresh token dari login tersebut.
+type Session struct {
+	ID         string             `bson:"_id" json:"id"`
+	UserID     primitive.ObjectID `bson:"user_id" json:"user_id"`
+	UserAgent  string             `bson:"user_agent" json:"user_agent"`
+	IP         string             `bson:"ip" json:"ip"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	LastSeenAt time.Time          `bson:"last_seen_at" json:"last_seen_at"`
+	ExpiresAt  time.Time          `bson:"expires_at" json:"expires_at"`
+	RevokedAt  *time.Time         `bson:"revoked_at" json:"revoked_at,omitempty"`
+	Current    bool               `bson:"-" json:"current"`
+}
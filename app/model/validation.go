@@ -0,0 +1,15 @@
+package model
+
+// FieldError adalah satu kesalahan validasi pada field request.
+// Code stabil untuk dipakai client, Message untuk ditampilkan ke user.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationErrorResponse adalah body respons 422 Unprocessable Entity.
+type ValidationErrorResponse struct {
+	Error  string       `json:"error"`
+	Errors []FieldError `json:"errors"`
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"gofiber-mongo/app/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AlumniClaimRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAlumniClaimRepository(db *mongo.Database) *AlumniClaimRepository {
+	return &AlumniClaimRepository{
+		collection: db.Collection("alumni_claims"),
+	}
+}
+
+func (r *AlumniClaimRepository) Create(ctx context.Context, claim *model.AlumniClaim) error {
+	if claim.ID.IsZero() {
+		claim.ID = primitive.NewObjectID()
+	}
+	claim.CreatedAt = time.Now()
+	claim.UpdatedAt = claim.CreatedAt
+
+	_, err := r.collection.InsertOne(ctx, claim)
+	return err
+}
+
+func (r *AlumniClaimRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.AlumniClaim, error) {
+	var claim model.AlumniClaim
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&claim)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &claim, nil
+}
+
+func (r *AlumniClaimRepository) FindByUser(ctx context.Context, userID primitive.ObjectID) ([]model.AlumniClaim, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var list []model.AlumniClaim
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// FindByStatus mengambil klaim dengan status tertentu (semua status jika kosong), terlama lebih dulu.
+func (r *AlumniClaimRepository) FindByStatus(ctx context.Context, status string) ([]model.AlumniClaim, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var list []model.AlumniClaim
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ExpirePendingForUser membatalkan kode klaim user yang masih aktif, supaya hanya ada satu kode aktif.
+func (r *AlumniClaimRepository) ExpirePendingForUser(ctx context.Context, userID primitive.ObjectID) error {
+	return r.expireCodes(ctx, bson.M{"user_id": userID})
+}
+
+// ExpireCode membatalkan kode satu klaim, mis. karena expired atau terlalu banyak percobaan.
+func (r *AlumniClaimRepository) ExpireCode(ctx context.Context, id primitive.ObjectID) error {
+	return r.expireCodes(ctx, bson.M{"_id": id})
+}
+
+// expireCodes menghapus kode klaim yang cocok dengan filter. Klaim pending_code menjadi expired,
+// sedangkan klaim pending_review tetap menunggu admin dan hanya kehilangan kode samarannya.
+func (r *AlumniClaimRepository) expireCodes(ctx context.Context, filter bson.M) error {
+	now := time.Now()
+
+	filter["status"] = model.ClaimStatusPendingCode
+	if _, err := r.collection.UpdateMany(ctx, filter, bson.M{
+		"$set":   bson.M{"status": model.ClaimStatusExpired, "updated_at": now},
+		"$unset": bson.M{"code_hash": ""},
+	}); err != nil {
+		return err
+	}
+
+	filter["status"] = model.ClaimStatusPendingReview
+	filter["code_hash"] = bson.M{"$exists": true}
+	_, err := r.collection.UpdateMany(ctx, filter, bson.M{
+		"$set":   bson.M{"updated_at": now},
+		"$unset": bson.M{"code_hash": ""},
+	})
+	return err
+}
+
+// IncrementAttempts menambah counter percobaan kode secara atomik dan mengembalikan nilai barunya.
+func (r *AlumniClaimRepository) IncrementAttempts(ctx context.Context, id primitive.ObjectID) (int, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var claim model.AlumniClaim
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, bson.M{
+		"$inc": bson.M{"attempts": 1},
+		"$set": bson.M{"updated_at": time.Now()},
+	}, opts).Decode(&claim)
+	if err != nil {
+		return 0, err
+	}
+	return claim.Attempts, nil
+}
+
+// UpdateStatus memindahkan klaim dari salah satu status fromStatuses ke status baru.
+// Mengembalikan false jika status klaim sudah berubah lebih dulu.
+func (r *AlumniClaimRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, fromStatuses []string, set bson.M) (bool, error) {
+	set["updated_at"] = time.Now()
+	result, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id":    id,
+		"status": bson.M{"$in": fromStatuses},
+	}, bson.M{
+		"$set":   set,
+		"$unset": bson.M{"code_hash": ""},
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// DeleteByUser menghapus semua klaim milik user (dipakai saat penghapusan data pribadi).
+func (r *AlumniClaimRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
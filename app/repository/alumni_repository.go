@@ -2,11 +2,13 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"gofiber-mongo/app/model"
+	"regexp"
 	"time"
 )
 
@@ -26,19 +28,25 @@ func NewAlumniRepository(db *mongo.Database) *AlumniRepository {
 	}
 }
 
-func (r *AlumniRepository) SoftDelete(ctx context.Context, alumniID primitive.ObjectID) error {
+// SoftDelete menandai alumni terhapus; version nil berarti tanpa syarat versi.
+func (r *AlumniRepository) SoftDelete(ctx context.Context, alumniID primitive.ObjectID, version *int64) error {
 	// Soft delete alumni
-	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": alumniID}, bson.M{
+	result, err := r.collection.UpdateOne(ctx, withVersion(bson.M{"_id": alumniID}, version), bson.M{
 		"$set": bson.M{"is_delete": true},
+		"$inc": incVersion,
 	})
 	if err != nil {
 		return err
 	}
+	if err := versionChecked(version, result.MatchedCount); err != nil {
+		return err
+	}
 
 	// Also soft delete all related pekerjaan
 	pekerjaanColl := r.collection.Database().Collection("pekerjaan_alumni")
 	_, err = pekerjaanColl.UpdateMany(ctx, bson.M{"alumni_id": alumniID}, bson.M{
 		"$set": bson.M{"is_delete": true},
+		"$inc": incVersion,
 	})
 	return err
 }
@@ -70,6 +78,19 @@ func (r *AlumniRepository) GetByID(ctx context.Context, id primitive.ObjectID) (
 	return &alumni, nil
 }
 
+// GetByUserID mengambil alumni yang ditautkan ke akun user.
+func (r *AlumniRepository) GetByUserID(ctx context.Context, userID primitive.ObjectID) (*model.Alumni, error) {
+	var alumni model.Alumni
+	err := r.collection.FindOne(ctx, bson.M{"user_id": userID, "is_delete": false}).Decode(&alumni)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &alumni, nil
+}
+
 func (r *AlumniRepository) Create(ctx context.Context, req model.CreateAlumniRequest) (*model.Alumni, error) {
 	alumni := model.Alumni{
 		ID:         primitive.NewObjectID(),
@@ -82,6 +103,7 @@ func (r *AlumniRepository) Create(ctx context.Context, req model.CreateAlumniReq
 		NoTelepon:  req.NoTelepon,
 		Alamat:     &req.Alamat,
 		IsDelete:   false,
+		Version:    1,
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
 	}
@@ -94,31 +116,86 @@ func (r *AlumniRepository) Create(ctx context.Context, req model.CreateAlumniReq
 	return &alumni, nil
 }
 
-func (r *AlumniRepository) Update(ctx context.Context, id primitive.ObjectID, req model.UpdateAlumniRequest) (*model.Alumni, error) {
-	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+// alumniUpdateFields memetakan request update ke field dokumen alumni.
+func alumniUpdateFields(req model.UpdateAlumniRequest) bson.M {
+	return bson.M{
+		"nama":        req.Nama,
+		"jurusan":     req.Jurusan,
+		"angkatan":    req.Angkatan,
+		"tahun_lulus": req.TahunLulus,
+		"email":       req.Email,
+		"no_telepon":  req.NoTelepon,
+		"alamat":      req.Alamat,
+	}
+}
+
+// Update menimpa semua field alumni; version nil berarti tanpa syarat versi.
+func (r *AlumniRepository) Update(ctx context.Context, id primitive.ObjectID, version *int64, req model.UpdateAlumniRequest) (*model.Alumni, error) {
+	set := alumniUpdateFields(req)
+	set["updated_at"] = time.Now()
+
+	result, err := r.collection.UpdateOne(ctx, withVersion(bson.M{"_id": id}, version), bson.M{"$set": set, "$inc": incVersion})
+	if err != nil {
+		return nil, err
+	}
+	if err := versionChecked(version, result.MatchedCount); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// Patch hanya menulis field yang berbeda antara current (data tersimpan) dan patched beserta
+// updated_at. Tanpa perubahan, dokumen tidak ditulis sama sekali. version adalah versi saat
+// current dibaca, sehingga perubahan oleh pihak lain di antaranya menghasilkan ErrVersionMismatch.
+func (r *AlumniRepository) Patch(ctx context.Context, id primitive.ObjectID, version int64, current, patched model.UpdateAlumniRequest) (*model.Alumni, error) {
+	set := changedFields(alumniUpdateFields(current), alumniUpdateFields(patched))
+	if len(set) == 0 {
+		return r.GetByID(ctx, id)
+	}
+	set["updated_at"] = time.Now()
+
+	filter := withVersion(bson.M{"_id": id, "is_delete": false}, &version)
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": set, "$inc": incVersion})
+	if err != nil {
+		return nil, err
+	}
+	if err := versionChecked(&version, result.MatchedCount); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// UpdateContact memperbarui data kontak alumni yang boleh diubah sendiri oleh pemiliknya;
+// version nil berarti tanpa syarat versi.
+func (r *AlumniRepository) UpdateContact(ctx context.Context, id primitive.ObjectID, version *int64, req model.UpdateMyAlumniRequest) (*model.Alumni, error) {
+	result, err := r.collection.UpdateOne(ctx, withVersion(bson.M{"_id": id}, version), bson.M{
 		"$set": bson.M{
-			"nama":        req.Nama,
-			"jurusan":     req.Jurusan,
-			"angkatan":    req.Angkatan,
-			"tahun_lulus": req.TahunLulus,
-			"email":       req.Email,
-			"no_telepon":  req.NoTelepon,
-			"alamat":      req.Alamat,
+			"no_telepon": req.NoTelepon,
+			"alamat":     req.Alamat,
+			"updated_at": time.Now(),
 		},
+		"$inc": incVersion,
 	})
 	if err != nil {
 		return nil, err
 	}
+	if err := versionChecked(version, result.MatchedCount); err != nil {
+		return nil, err
+	}
 	return r.GetByID(ctx, id)
 }
 
-func (r *AlumniRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
-	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
-	return err
+// Delete menghapus alumni permanen; version nil berarti tanpa syarat versi.
+func (r *AlumniRepository) Delete(ctx context.Context, id primitive.ObjectID, version *int64) error {
+	result, err := r.collection.DeleteOne(ctx, withVersion(bson.M{"_id": id}, version))
+	if err != nil {
+		return err
+	}
+	return versionChecked(version, result.DeletedCount)
 }
 
-func (r *AlumniRepository) GetAllWithFilter(ctx context.Context, search, sortBy, order string, limit, offset int) ([]model.Alumni, error) {
-	// Build search filter
+// alumniSearchFilter adalah filter pencarian yang dipakai daftar, hitung total dan ekspor alumni.
+func alumniSearchFilter(search string) bson.M {
 	filter := bson.M{"is_delete": false}
 	if search != "" {
 		filter = bson.M{
@@ -131,6 +208,12 @@ func (r *AlumniRepository) GetAllWithFilter(ctx context.Context, search, sortBy,
 			},
 		}
 	}
+	return filter
+}
+
+func (r *AlumniRepository) GetAllWithFilter(ctx context.Context, search, sortBy, order string, limit, offset int) ([]model.Alumni, error) {
+	// Build search filter
+	filter := alumniSearchFilter(search)
 
 	// Build sort
 	sortOrder := int32(-1)
@@ -156,19 +239,38 @@ func (r *AlumniRepository) GetAllWithFilter(ctx context.Context, search, sortBy,
 }
 
 func (r *AlumniRepository) CountWithSearch(ctx context.Context, search string) (int64, error) {
-	filter := bson.M{"is_delete": false}
-	if search != "" {
-		filter = bson.M{
-			"is_delete": false,
-			"$or": []bson.M{
-				{"nim": bson.M{"$regex": search, "$options": "i"}},
-				{"nama": bson.M{"$regex": search, "$options": "i"}},
-				{"jurusan": bson.M{"$regex": search, "$options": "i"}},
-				{"email": bson.M{"$regex": search, "$options": "i"}},
+	return r.collection.CountDocuments(ctx, alumniSearchFilter(search))
+}
+
+// ExportCursor membuka cursor seluruh alumni yang cocok dengan pencarian, tanpa pagination.
+// Jika withPekerjaan true, tiap dokumen diberi field pekerjaan_saat_ini berisi pekerjaan
+// berstatus aktif dengan tanggal mulai terbaru (kosong jika tidak ada).
+func (r *AlumniRepository) ExportCursor(ctx context.Context, search, sortBy, order string, withPekerjaan bool) (*mongo.Cursor, error) {
+	sort := exportSort(sortBy, order)
+	if !withPekerjaan {
+		return r.collection.Find(ctx, alumniSearchFilter(search), options.Find().SetSort(sort))
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: alumniSearchFilter(search)}},
+		{{Key: "$sort", Value: sort}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": "pekerjaan_alumni",
+			"let":  bson.M{"alumni_id": "$_id"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{
+					"$expr":            bson.M{"$eq": bson.A{"$alumni_id", "$$alumni_id"}},
+					"is_delete":        false,
+					"status_pekerjaan": model.StatusPekerjaanAktif,
+				}},
+				bson.M{"$sort": bson.D{{Key: "tanggal_mulai_kerja", Value: -1}}},
+				bson.M{"$limit": 1},
 			},
-		}
+			"as": "pekerjaan_saat_ini",
+		}}},
+		{{Key: "$set", Value: bson.M{"pekerjaan_saat_ini": bson.M{"$arrayElemAt": bson.A{"$pekerjaan_saat_ini", 0}}}}},
 	}
-	return r.collection.CountDocuments(ctx, filter)
+	return r.collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
 }
 
 func (r *AlumniRepository) GetWithoutPekerjaan(ctx context.Context) ([]model.Alumni, error) {
@@ -216,3 +318,207 @@ func (r *AlumniRepository) GetWithoutPekerjaan(ctx context.Context) ([]model.Alu
 	}
 	return result, nil
 }
+
+func (r *AlumniRepository) GetByNIM(ctx context.Context, nim string) (*model.Alumni, error) {
+	var alumni model.Alumni
+	err := r.collection.FindOne(ctx, bson.M{"nim": nim, "is_delete": false}).Decode(&alumni)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &alumni, nil
+}
+
+// GetUnlinkedByCohort mengambil alumni yang belum ditautkan ke akun pada angkatan dan/atau
+// jurusan tertentu (angkatan 0 atau jurusan kosong berarti tidak difilter).
+func (r *AlumniRepository) GetUnlinkedByCohort(ctx context.Context, angkatan int, jurusan string) ([]model.Alumni, error) {
+	filter := bson.M{
+		"is_delete": false,
+		"user_id":   bson.M{"$in": []interface{}{nil, primitive.NilObjectID}},
+	}
+	if angkatan != 0 {
+		filter["angkatan"] = angkatan
+	}
+	if jurusan != "" {
+		filter["jurusan"] = bson.M{"$regex": "^" + regexp.QuoteMeta(jurusan) + "$", "$options": "i"}
+	}
+
+	opts := options.Find().SetSort(bson.M{"nim": 1})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var list []model.Alumni
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// LinkUser menautkan alumni ke user hanya jika alumni belum ditautkan ke siapa pun.
+// Mengembalikan false jika alumni sudah dimiliki user lain.
+func (r *AlumniRepository) LinkUser(ctx context.Context, alumniID, userID primitive.ObjectID) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id":     alumniID,
+		"user_id": bson.M{"$in": []interface{}{nil, primitive.NilObjectID}},
+	}, bson.M{
+		"$set": bson.M{"user_id": userID, "updated_at": time.Now()},
+		"$inc": incVersion,
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// SetUser menautkan alumni ke user tanpa syarat (keputusan admin atas klaim yang disengketakan).
+func (r *AlumniRepository) SetUser(ctx context.Context, alumniID, userID primitive.ObjectID) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": alumniID}, bson.M{
+		"$set": bson.M{"user_id": userID, "updated_at": time.Now()},
+		"$inc": incVersion,
+	})
+	return err
+}
+
+func (r *AlumniRepository) UnlinkUser(ctx context.Context, alumniID primitive.ObjectID) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": alumniID}, bson.M{
+		"$set": bson.M{"user_id": primitive.NilObjectID, "updated_at": time.Now()},
+		"$inc": incVersion,
+	})
+	return err
+}
+
+// GetAllByUserID mengambil semua alumni yang ditautkan ke user, termasuk yang sudah di-soft delete.
+func (r *AlumniRepository) GetAllByUserID(ctx context.Context, userID primitive.ObjectID) ([]model.Alumni, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var list []model.Alumni
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Anonymize menghapus data identitas alumni dan menandainya terhapus. Jurusan, angkatan dan
+// tahun lulus dipertahankan untuk statistik.
+func (r *AlumniRepository) Anonymize(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{
+			"user_id":    primitive.NilObjectID,
+			"nim":        "",
+			"nama":       "[dihapus]",
+			"email":      "",
+			"no_telepon": "",
+			"alamat":     nil,
+			"is_delete":  true,
+			"updated_at": time.Now(),
+		},
+		"$inc": incVersion,
+	})
+	return err
+}
+
+// GetActiveByNIMs mengambil alumni aktif yang NIM-nya ada di daftar (dipakai import untuk deteksi duplikat).
+func (r *AlumniRepository) GetActiveByNIMs(ctx context.Context, nims []string) ([]model.Alumni, error) {
+	return r.findActiveIn(ctx, "nim", nims)
+}
+
+// GetActiveByEmails mengambil alumni aktif yang email-nya ada di daftar.
+func (r *AlumniRepository) GetActiveByEmails(ctx context.Context, emails []string) ([]model.Alumni, error) {
+	return r.findActiveIn(ctx, "email", emails)
+}
+
+func (r *AlumniRepository) findActiveIn(ctx context.Context, field string, values []string) ([]model.Alumni, error) {
+	if len(values) == 0 {
+		return []model.Alumni{}, nil
+	}
+	opts := options.Find().SetProjection(bson.M{"_id": 1, "nim": 1, "email": 1})
+	cursor, err := r.collection.Find(ctx, bson.M{field: bson.M{"$in": values}, "is_delete": false}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var list []model.Alumni
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// AlumniUpsertResult adalah hasil UpsertByNIM. Failed berisi error per index baris.
+type AlumniUpsertResult struct {
+	Created int
+	Updated int
+	Failed  map[int]error
+}
+
+// UpsertByNIM menulis data import dalam satu bulk write tidak berurutan: alumni aktif dengan NIM
+// yang sama diperbarui, sisanya dibuat baru. Hanya field di fields yang ditimpa saat update;
+// field opsional yang tidak ada di file tetap diisi kosong untuk data baru.
+func (r *AlumniRepository) UpsertByNIM(ctx context.Context, rows []model.CreateAlumniRequest, fields []string) (*AlumniUpsertResult, error) {
+	result := &AlumniUpsertResult{Failed: map[int]error{}}
+	if len(rows) == 0 {
+		return result, nil
+	}
+
+	mapped := map[string]bool{}
+	for _, f := range fields {
+		mapped[f] = true
+	}
+
+	now := time.Now()
+	models := make([]mongo.WriteModel, 0, len(rows))
+	for _, req := range rows {
+		values := bson.M{
+			"nama":        req.Nama,
+			"jurusan":     req.Jurusan,
+			"angkatan":    req.Angkatan,
+			"tahun_lulus": req.TahunLulus,
+			"email":       req.Email,
+			"no_telepon":  req.NoTelepon,
+			"alamat":      req.Alamat,
+		}
+		set := bson.M{"updated_at": now}
+		setOnInsert := bson.M{
+			"user_id":    primitive.NilObjectID,
+			"created_at": now,
+		}
+		for field, value := range values {
+			if mapped[field] {
+				set[field] = value
+			} else {
+				setOnInsert[field] = value
+			}
+		}
+
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"nim": req.NIM, "is_delete": false}).
+			SetUpdate(bson.M{"$set": set, "$setOnInsert": setOnInsert, "$inc": incVersion}).
+			SetUpsert(true))
+	}
+
+	res, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if res != nil {
+		result.Created = int(res.UpsertedCount)
+		result.Updated = int(res.MatchedCount)
+	}
+	if err != nil {
+		var bulkErr mongo.BulkWriteException
+		if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil || len(bulkErr.WriteErrors) == 0 {
+			return result, err
+		}
+		for _, we := range bulkErr.WriteErrors {
+			result.Failed[we.Index] = we
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"gofiber-mongo/app/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type APIKeyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAPIKeyRepository(db *mongo.Database) *APIKeyRepository {
+	return &APIKeyRepository{
+		collection: db.Collection("api_keys"),
+	}
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	if key.ID.IsZero() {
+		key.ID = primitive.NewObjectID()
+	}
+	key.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, key)
+	return err
+}
+
+func (r *APIKeyRepository) GetAll(ctx context.Context) ([]model.APIKey, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var list []model.APIKey
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *APIKeyRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&key)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *APIKeyRepository) FindByPrefix(ctx context.Context, prefix string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.collection.FindOne(ctx, bson.M{"prefix": prefix}).Decode(&key)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "revoked_at": nil}, bson.M{
+		"$set": bson.M{"revoked_at": time.Now()},
+	})
+	return err
+}
+
+// TouchLastUsed mencatat pemakaian terakhir, paling sering sekali per menit per key
+// supaya tidak ada write ke database di setiap request.
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id primitive.ObjectID, ip string) error {
+	now := time.Now()
+	_, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id": id,
+		"$or": []bson.M{
+			{"last_used_at": bson.M{"$exists": false}},
+			{"last_used_at": bson.M{"$lt": now.Add(-time.Minute)}},
+		},
+	}, bson.M{
+		"$set": bson.M{"last_used_at": now, "last_used_ip": ip},
+	})
+	return err
+}
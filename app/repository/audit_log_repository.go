@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"gofiber-mongo/app/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AuditLogRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAuditLogRepository(db *mongo.Database) *AuditLogRepository {
+	return &AuditLogRepository{
+		collection: db.Collection("audit_logs"),
+	}
+}
+
+func (r *AuditLogRepository) Create(ctx context.Context, entry *model.AuditLog) error {
+	entry.ID = primitive.NewObjectID()
+	entry.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, entry)
+	return err
+}
+
+// SetStatus melengkapi entry dengan status respons setelah request selesai diproses.
+func (r *AuditLogRepository) SetStatus(ctx context.Context, id primitive.ObjectID, status int) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"status": status},
+	})
+	return err
+}
+
+// buildAuditFilter membuat filter dari parameter opsional (ObjectID kosong / string kosong diabaikan).
+func buildAuditFilter(actorID, userID primitive.ObjectID, action string) bson.M {
+	filter := bson.M{}
+	if !actorID.IsZero() {
+		filter["actor_id"] = actorID
+	}
+	if !userID.IsZero() {
+		filter["user_id"] = userID
+	}
+	if action != "" {
+		filter["action"] = action
+	}
+	return filter
+}
+
+func (r *AuditLogRepository) GetAllWithFilter(ctx context.Context, actorID, userID primitive.ObjectID, action string, limit, offset int) ([]model.AuditLog, error) {
+	opts := options.Find().
+		SetSort(bson.M{"created_at": -1}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, buildAuditFilter(actorID, userID, action), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var list []model.AuditLog
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *AuditLogRepository) CountWithFilter(ctx context.Context, actorID, userID primitive.ObjectID, action string) (int64, error) {
+	return r.collection.CountDocuments(ctx, buildAuditFilter(actorID, userID, action))
+}
+
+// AnonymizeUser menghapus entry di mana user adalah pihak yang di-impersonate, dan menghapus
+// identitas (username, IP, user agent) pada entry di mana user adalah admin yang bertindak.
+// actor_id tetap disimpan agar jejak tindakan admin tidak hilang.
+func (r *AuditLogRepository) AnonymizeUser(ctx context.Context, userID primitive.ObjectID) error {
+	if _, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+		return err
+	}
+	_, err := r.collection.UpdateMany(ctx, bson.M{"actor_id": userID}, bson.M{
+		"$set": bson.M{
+			"actor_username": "deleted-" + userID.Hex(),
+			"ip":             "",
+			"user_agent":     "",
+		},
+	})
+	return err
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"gofiber-mongo/app/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ErasureRepository struct {
+	collection *mongo.Collection
+}
+
+func NewErasureRepository(db *mongo.Database) *ErasureRepository {
+	return &ErasureRepository{
+		collection: db.Collection("erasure_requests"),
+	}
+}
+
+func (r *ErasureRepository) Create(ctx context.Context, req *model.ErasureRequest) error {
+	now := time.Now()
+	req.ID = primitive.NewObjectID()
+	req.Status = model.ErasureStatusPending
+	req.CreatedAt = now
+	req.UpdatedAt = now
+
+	_, err := r.collection.InsertOne(ctx, req)
+	return err
+}
+
+func (r *ErasureRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.ErasureRequest, error) {
+	var req model.ErasureRequest
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&req)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &req, nil
+}
+
+// FindOpenByUser mengambil permintaan user yang belum selesai (pending, running atau failed).
+func (r *ErasureRepository) FindOpenByUser(ctx context.Context, userID primitive.ObjectID) (*model.ErasureRequest, error) {
+	var req model.ErasureRequest
+	err := r.collection.FindOne(ctx, bson.M{
+		"user_id": userID,
+		"status": bson.M{"$in": []string{
+			model.ErasureStatusPending, model.ErasureStatusRunning, model.ErasureStatusFailed,
+		}},
+	}).Decode(&req)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (r *ErasureRepository) FindByUser(ctx context.Context, userID primitive.ObjectID) ([]model.ErasureRequest, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var list []model.ErasureRequest
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// FindByStatus mengambil permintaan dengan status tertentu (semua jika kosong), terlama lebih dulu.
+func (r *ErasureRepository) FindByStatus(ctx context.Context, status string) ([]model.ErasureRequest, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var list []model.ErasureRequest
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// UpdateStatus memindahkan permintaan dari salah satu status fromStatuses ke status baru.
+// Mengembalikan false jika status sudah berubah lebih dulu.
+func (r *ErasureRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, fromStatuses []string, set bson.M) (bool, error) {
+	set["updated_at"] = time.Now()
+	result, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id":    id,
+		"status": bson.M{"$in": fromStatuses},
+	}, bson.M{"$set": set})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
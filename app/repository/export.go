@@ -0,0 +1,17 @@
+package repository
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// exportSort mengikuti sortBy / order daftar biasa, ditambah _id agar urutan dokumen dengan
+// nilai sort yang sama tetap stabil sepanjang cursor.
+func exportSort(sortBy, order string) bson.D {
+	sortOrder := int32(-1)
+	if order == "asc" {
+		sortOrder = 1
+	}
+	sort := bson.D{{Key: sortBy, Value: sortOrder}}
+	if sortBy != "_id" {
+		sort = append(sort, bson.E{Key: "_id", Value: 1})
+	}
+	return sort
+}
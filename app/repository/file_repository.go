@@ -24,8 +24,14 @@ type IFileRepository interface {
 	FindCertificateByAlumniID(ctx context.Context, alumniID string) (*model.Certificate, error)
 	DeleteCertificate(ctx context.Context, id string) error
 
+	// Semua file alumni (termasuk yang sudah di-soft delete), untuk ekspor dan penghapusan data pribadi
+	FindPhotosByAlumniID(ctx context.Context, alumniID primitive.ObjectID) ([]model.Photo, error)
+	FindCertificatesByAlumniID(ctx context.Context, alumniID primitive.ObjectID) ([]model.Certificate, error)
+	DeleteFilesByAlumniID(ctx context.Context, alumniID primitive.ObjectID) (photos int64, certificates int64, err error)
+
 	// Alumni ownership check
 	CheckAlumniOwnership(ctx context.Context, alumniID string, userID primitive.ObjectID) (bool, error)
+	FindAlumniIDByUserID(ctx context.Context, userID primitive.ObjectID) (string, error)
 }
 
 // FileRepository implements IFileRepository
@@ -70,6 +76,27 @@ func (r *FileRepository) CheckAlumniOwnership(ctx context.Context, alumniID stri
 	return alumni.UserID == userID, nil
 }
 
+// FindAlumniIDByUserID returns the ID of the alumni linked to a user, or "" if none
+func (r *FileRepository) FindAlumniIDByUserID(ctx context.Context, userID primitive.ObjectID) (string, error) {
+	var alumni struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+
+	err := r.alumniCollection.FindOne(ctx, bson.M{
+		"user_id":   userID,
+		"is_delete": false,
+	}).Decode(&alumni)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return alumni.ID.Hex(), nil
+}
+
 // CreatePhoto saves a new photo to database
 func (r *FileRepository) CreatePhoto(ctx context.Context, photo *model.Photo) error {
 	photo.UploadedAt = time.Now()
@@ -194,4 +221,51 @@ func (r *FileRepository) DeleteCertificate(ctx context.Context, id string) error
 
 	_, err = r.certificateCollection.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": bson.M{"is_delete": true}})
 	return err
-}   
\ No newline at end of file
+}   
+
+// FindPhotosByAlumniID retrieves all photo records of an alumni, including soft deleted ones
+func (r *FileRepository) FindPhotosByAlumniID(ctx context.Context, alumniID primitive.ObjectID) ([]model.Photo, error) {
+	cursor, err := r.photoCollection.Find(ctx, bson.M{"alumni_id": alumniID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var photos []model.Photo
+	if err := cursor.All(ctx, &photos); err != nil {
+		return nil, err
+	}
+
+	return photos, nil
+}
+
+// FindCertificatesByAlumniID retrieves all certificate records of an alumni, including soft deleted ones
+func (r *FileRepository) FindCertificatesByAlumniID(ctx context.Context, alumniID primitive.ObjectID) ([]model.Certificate, error) {
+	cursor, err := r.certificateCollection.Find(ctx, bson.M{"alumni_id": alumniID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var certs []model.Certificate
+	if err := cursor.All(ctx, &certs); err != nil {
+		return nil, err
+	}
+
+	return certs, nil
+}
+
+// DeleteFilesByAlumniID permanently deletes all photo and certificate records of an alumni
+func (r *FileRepository) DeleteFilesByAlumniID(ctx context.Context, alumniID primitive.ObjectID) (int64, int64, error) {
+	photos, err := r.photoCollection.DeleteMany(ctx, bson.M{"alumni_id": alumniID})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	certs, err := r.certificateCollection.DeleteMany(ctx, bson.M{"alumni_id": alumniID})
+	if err != nil {
+		return photos.DeletedCount, 0, err
+	}
+
+	return photos.DeletedCount, certs.DeletedCount, nil
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Nama unique index dipakai untuk memetakan error duplicate key ke field yang bentrok.
+const (
+	indexAlumniNIM    = "nim_unique_active"
+	indexAlumniEmail  = "email_unique_active"
+	indexUserUsername = "username_unique"
+	indexUserEmail    = "email_unique"
+	indexUserOIDC     = "oidc_subject_unique"
+	indexAPIKeyPrefix = "prefix_unique"
+)
+
+// activeOnly membatasi unique index pada data yang belum di-soft delete, sehingga NIM / email
+// alumni yang sudah dihapus boleh dipakai lagi.
+var activeOnly = bson.M{"is_delete": false}
+
+// expireAtField membuat TTL index: MongoDB menghapus dokumen setelah waktu di field tersebut lewat.
+func expireAtField(field string) mongo.IndexModel {
+	return mongo.IndexModel{
+		Keys:    bson.D{{Key: field, Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+}
+
+// uniqueField membuat unique index pada satu field (mis. hash token yang dicari secara langsung).
+func uniqueField(field string) mongo.IndexModel {
+	return mongo.IndexModel{
+		Keys:    bson.D{{Key: field, Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+}
+
+var collectionIndexes = map[string][]mongo.IndexModel{
+	"alumni": {
+		{
+			Keys:    bson.D{{Key: "nim", Value: 1}},
+			Options: options.Index().SetName(indexAlumniNIM).SetUnique(true).SetPartialFilterExpression(activeOnly),
+		},
+		{
+			// Alumni tanpa email (email "") tidak ikut dicek
+			Keys: bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetName(indexAlumniEmail).SetUnique(true).
+				SetPartialFilterExpression(bson.M{"is_delete": false, "email": bson.M{"$gt": ""}}),
+		},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{Keys: bson.D{{Key: "is_delete", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "is_delete", Value: 1}, {Key: "angkatan", Value: 1}, {Key: "jurusan", Value: 1}}},
+	},
+	"users": {
+		{
+			Keys:    bson.D{{Key: "username", Value: 1}},
+			Options: options.Index().SetName(indexUserUsername).SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetName(indexUserEmail).SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "oidc_issuer", Value: 1}, {Key: "oidc_subject", Value: 1}},
+			Options: options.Index().SetName(indexUserOIDC).SetUnique(true).
+				SetPartialFilterExpression(bson.M{"oidc_subject": bson.M{"$gt": ""}}),
+		},
+		{Keys: bson.D{{Key: "is_delete", Value: 1}, {Key: "role", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+	"pekerjaan_alumni": {
+		{Keys: bson.D{{Key: "alumni_id", Value: 1}, {Key: "is_delete", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "is_delete", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "is_delete", Value: 1}, {Key: "updated_at", Value: -1}}},
+	},
+	"photos": {
+		{Keys: bson.D{{Key: "alumni_id", Value: 1}, {Key: "is_delete", Value: 1}}},
+	},
+	"certificates": {
+		{Keys: bson.D{{Key: "alumni_id", Value: 1}, {Key: "is_delete", Value: 1}}},
+	},
+	"api_keys": {
+		{
+			Keys:    bson.D{{Key: "prefix", Value: 1}},
+			Options: options.Index().SetName(indexAPIKeyPrefix).SetUnique(true),
+		},
+	},
+	"refresh_tokens": {
+		uniqueField("token_hash"),
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "revoked_at", Value: 1}}},
+		{Keys: bson.D{{Key: "family_id", Value: 1}}},
+	},
+	"revoked_tokens": {
+		uniqueField("jti"),
+		expireAtField("expires_at"),
+	},
+	"one_time_tokens": {
+		uniqueField("token_hash"),
+	},
+	"invitations": {
+		uniqueField("token_hash"),
+	},
+	"oidc_states": {
+		uniqueField("state_hash"),
+		expireAtField("expires_at"),
+	},
+	"login_throttles": {
+		uniqueField("key"),
+		expireAtField("expires_at"),
+	},
+	"security_events": {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+	},
+}
+
+// EnsureIndexes membuat index yang dibutuhkan query repository. CreateMany idempotent untuk
+// index yang sudah ada. Kegagalan satu collection (mis. data lama berisi NIM ganda sehingga
+// unique index tidak bisa dibuat) tidak menghentikan collection lain; semua error digabung.
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	var errs []error
+	for name, indexes := range collectionIndexes {
+		if _, err := db.Collection(name).Indexes().CreateMany(ctx, indexes); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// duplicateKeyFields memetakan nama unique index ke field JSON yang bentrok.
+var duplicateKeyFields = map[string]string{
+	indexAlumniNIM:    "nim",
+	indexAlumniEmail:  "email",
+	indexUserUsername: "username",
+	indexUserEmail:    "email",
+	indexUserOIDC:     "oidc_subject",
+}
+
+// DuplicateKeyField mengecek apakah err adalah pelanggaran unique index dan mengembalikan
+// field yang bentrok (kosong jika index tidak dikenal).
+func DuplicateKeyField(err error) (string, bool) {
+	if err == nil || !mongo.IsDuplicateKeyError(err) {
+		return "", false
+	}
+	msg := err.Error()
+	for index, field := range duplicateKeyFields {
+		if strings.Contains(msg, "index: "+index+" ") {
+			return field, true
+		}
+	}
+	return "", true
+}
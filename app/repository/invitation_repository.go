@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"gofiber-mongo/app/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type InvitationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewInvitationRepository(db *mongo.Database) *InvitationRepository {
+	return &InvitationRepository{
+		collection: db.Collection("invitations"),
+	}
+}
+
+func (r *InvitationRepository) Create(ctx context.Context, invitation *model.Invitation) error {
+	if invitation.ID.IsZero() {
+		invitation.ID = primitive.NewObjectID()
+	}
+	invitation.Status = model.InvitationStatusPending
+	invitation.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, invitation)
+	return err
+}
+
+func (r *InvitationRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Invitation, error) {
+	var invitation model.Invitation
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&invitation)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// FindActiveByHash mengambil undangan pending yang belum expired berdasarkan hash token.
+func (r *InvitationRepository) FindActiveByHash(ctx context.Context, tokenHash string) (*model.Invitation, error) {
+	var invitation model.Invitation
+	err := r.collection.FindOne(ctx, bson.M{
+		"token_hash": tokenHash,
+		"status":     model.InvitationStatusPending,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}).Decode(&invitation)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// FindByStatus mengambil undangan dengan status tertentu (semua jika kosong), terbaru lebih dulu.
+// Status "pending" hanya yang belum expired; "expired" adalah pending yang sudah lewat waktunya.
+func (r *InvitationRepository) FindByStatus(ctx context.Context, status string) ([]model.Invitation, error) {
+	filter := bson.M{}
+	switch status {
+	case "":
+	case model.InvitationStatusPending:
+		filter["status"] = model.InvitationStatusPending
+		filter["expires_at"] = bson.M{"$gt": time.Now()}
+	case model.InvitationStatusExpired:
+		filter["status"] = model.InvitationStatusPending
+		filter["expires_at"] = bson.M{"$lte": time.Now()}
+	default:
+		filter["status"] = status
+	}
+
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var list []model.Invitation
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// RevokePendingForAlumni membatalkan undangan pending alumni, supaya hanya ada satu link aktif.
+func (r *InvitationRepository) RevokePendingForAlumni(ctx context.Context, alumniID primitive.ObjectID) error {
+	_, err := r.collection.UpdateMany(ctx, bson.M{
+		"alumni_id": alumniID,
+		"status":    model.InvitationStatusPending,
+	}, bson.M{
+		"$set": bson.M{"status": model.InvitationStatusRevoked, "revoked_at": time.Now()},
+	})
+	return err
+}
+
+// Revoke membatalkan undangan pending. Mengembalikan false jika undangan sudah tidak pending.
+func (r *InvitationRepository) Revoke(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id":    id,
+		"status": model.InvitationStatusPending,
+	}, bson.M{
+		"$set": bson.M{"status": model.InvitationStatusRevoked, "revoked_at": time.Now()},
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// MarkAccepted menandai undangan pending sebagai diterima oleh userID.
+func (r *InvitationRepository) MarkAccepted(ctx context.Context, id, userID primitive.ObjectID) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id":    id,
+		"status": model.InvitationStatusPending,
+	}, bson.M{
+		"$set": bson.M{
+			"status":      model.InvitationStatusAccepted,
+			"accepted_by": userID,
+			"accepted_at": time.Now(),
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// DeleteByAlumni menghapus semua undangan alumni (dipakai saat penghapusan data pribadi).
+func (r *InvitationRepository) DeleteByAlumni(ctx context.Context, alumniID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"alumni_id": alumniID})
+	return err
+}
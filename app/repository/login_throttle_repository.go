@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"gofiber-mongo/app/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LoginThrottleRepository struct {
+	collection *mongo.Collection
+}
+
+func NewLoginThrottleRepository(db *mongo.Database) *LoginThrottleRepository {
+	return &LoginThrottleRepository{
+		collection: db.Collection("login_throttles"),
+	}
+}
+
+func (r *LoginThrottleRepository) Get(ctx context.Context, key string) (*model.LoginThrottle, error) {
+	var throttle model.LoginThrottle
+	err := r.collection.FindOne(ctx, bson.M{"key": key}).Decode(&throttle)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+// RecordFailure menambah counter secara atomik. Jika kegagalan terakhir lebih lama dari
+// window, counter dimulai lagi dari 1. expires_at digeser ke akhir window, tetapi tidak
+// pernah lebih awal dari lock yang sedang berlaku.
+func (r *LoginThrottleRepository) RecordFailure(ctx context.Context, key string, window time.Duration) (*model.LoginThrottle, error) {
+	now := time.Now()
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"key": key,
+			"count": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$last_at", now.Add(-window)}},
+				bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$count", 0}}, 1}},
+				1,
+			}},
+			"last_at": now,
+			"expires_at": bson.M{"$max": bson.A{
+				now.Add(window),
+				bson.M{"$ifNull": bson.A{"$locked_until", now}},
+			}},
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var throttle model.LoginThrottle
+	if err := r.collection.FindOneAndUpdate(ctx, bson.M{"key": key}, update, opts).Decode(&throttle); err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+func (r *LoginThrottleRepository) LockUntil(ctx context.Context, key string, until time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"key": key}, bson.M{
+		"$set": bson.M{"locked_until": until},
+		"$max": bson.M{"expires_at": until},
+	})
+	return err
+}
+
+// DeleteByUser menghapus counter yang dikunci per user, yaitu key berakhiran "user:<id>"
+// (mis. "claim:user:<id>"). Counter per IP tidak terkait user dan dibiarkan.
+func (r *LoginThrottleRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{
+		"key": bson.M{"$regex": "(^|:)user:" + userID.Hex() + "$"},
+	})
+	return err
+}
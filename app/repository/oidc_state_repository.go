@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"gofiber-mongo/app/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type OIDCStateRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOIDCStateRepository(db *mongo.Database) *OIDCStateRepository {
+	return &OIDCStateRepository{
+		collection: db.Collection("oidc_states"),
+	}
+}
+
+func (r *OIDCStateRepository) Create(ctx context.Context, state *model.OIDCState) error {
+	if state.ID.IsZero() {
+		state.ID = primitive.NewObjectID()
+	}
+	state.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, state)
+	return err
+}
+
+// Consume mengambil dan menghapus state secara atomik sehingga callback tidak bisa di-replay.
+// Mengembalikan nil jika state tidak ditemukan atau sudah expired.
+func (r *OIDCStateRepository) Consume(ctx context.Context, stateHash string) (*model.OIDCState, error) {
+	var state model.OIDCState
+	err := r.collection.FindOneAndDelete(ctx, bson.M{
+		"state_hash": stateHash,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}).Decode(&state)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &state, nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"gofiber-mongo/app/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type OneTimeTokenRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOneTimeTokenRepository(db *mongo.Database) *OneTimeTokenRepository {
+	return &OneTimeTokenRepository{
+		collection: db.Collection("one_time_tokens"),
+	}
+}
+
+func (r *OneTimeTokenRepository) Create(ctx context.Context, token *model.OneTimeToken) error {
+	if token.ID.IsZero() {
+		token.ID = primitive.NewObjectID()
+	}
+	token.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, token)
+	return err
+}
+
+// Consume menandai token sebagai terpakai secara atomik. Mengembalikan nil jika token
+// tidak ditemukan, sudah dipakai, atau sudah expired.
+func (r *OneTimeTokenRepository) Consume(ctx context.Context, purpose, hash string) (*model.OneTimeToken, error) {
+	now := time.Now()
+	filter := bson.M{
+		"purpose":    purpose,
+		"token_hash": hash,
+		"used_at":    nil,
+		"expires_at": bson.M{"$gt": now},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var token model.OneTimeToken
+	err := r.collection.FindOneAndUpdate(ctx, filter, bson.M{"$set": bson.M{"used_at": now}}, opts).Decode(&token)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// InvalidateForUser menandai semua token aktif milik user untuk tujuan tertentu sebagai terpakai.
+func (r *OneTimeTokenRepository) InvalidateForUser(ctx context.Context, userID primitive.ObjectID, purpose string) error {
+	_, err := r.collection.UpdateMany(ctx, bson.M{"user_id": userID, "purpose": purpose, "used_at": nil}, bson.M{
+		"$set": bson.M{"used_at": time.Now()},
+	})
+	return err
+}
+
+// FindActive mencari token yang belum dipakai dan belum expired tanpa menandainya terpakai.
+func (r *OneTimeTokenRepository) FindActive(ctx context.Context, purpose, hash string) (*model.OneTimeToken, error) {
+	var token model.OneTimeToken
+	err := r.collection.FindOne(ctx, bson.M{
+		"purpose":    purpose,
+		"token_hash": hash,
+		"used_at":    nil,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}).Decode(&token)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
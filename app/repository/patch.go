@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// changedFields mengembalikan field di after yang nilainya berbeda dari before.
+func changedFields(before, after bson.M) bson.M {
+	set := bson.M{}
+	for field, value := range after {
+		if !reflect.DeepEqual(before[field], value) {
+			set[field] = value
+		}
+	}
+	return set
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestChangedFields(t *testing.T) {
+	mulai := time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		before, after bson.M
+		want          bson.M
+	}{
+		{"tidak ada perubahan", bson.M{"nama": "Budi", "angkatan": 2018}, bson.M{"nama": "Budi", "angkatan": 2018}, bson.M{}},
+		{"satu field berubah", bson.M{"nama": "Budi", "alamat": "Surabaya"}, bson.M{"nama": "Budi", "alamat": "Malang"}, bson.M{"alamat": "Malang"}},
+		{"field baru", bson.M{"nama": "Budi"}, bson.M{"nama": "Budi", "no_telepon": "+62 812"}, bson.M{"no_telepon": "+62 812"}},
+		{"waktu sama", bson.M{"mulai": mulai}, bson.M{"mulai": mulai}, bson.M{}},
+		{"pointer nil ke nilai", bson.M{"selesai": (*time.Time)(nil)}, bson.M{"selesai": &mulai}, bson.M{"selesai": &mulai}},
+		{"tipe berbeda dianggap berubah", bson.M{"angkatan": int32(2018)}, bson.M{"angkatan": 2018}, bson.M{"angkatan": 2018}},
+		{"field hanya di before diabaikan", bson.M{"nama": "Budi", "alamat": "Surabaya"}, bson.M{"nama": "Budi"}, bson.M{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := changedFields(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("changedFields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -23,6 +23,7 @@ func NewPekerjaanRepository(db *mongo.Database) *PekerjaanRepository {
 func (r *PekerjaanRepository) RestoreByID(ctx context.Context, id primitive.ObjectID) error {
 	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
 		"$set": bson.M{"is_delete": false},
+		"$inc": incVersion,
 	})
 	return err
 }
@@ -30,6 +31,7 @@ func (r *PekerjaanRepository) RestoreByID(ctx context.Context, id primitive.Obje
 func (r *PekerjaanRepository) RestoreByIDAndAlumni(ctx context.Context, id primitive.ObjectID, alumniID primitive.ObjectID) error {
 	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "alumni_id": alumniID}, bson.M{
 		"$set": bson.M{"is_delete": false},
+		"$inc": incVersion,
 	})
 	return err
 }
@@ -74,14 +76,20 @@ func (r *PekerjaanRepository) GetTrashedByAlumni(ctx context.Context, alumniID p
 	return list, nil
 }
 
-func (r *PekerjaanRepository) SoftDelete(ctx context.Context, id primitive.ObjectID) error {
-	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+// SoftDelete menandai pekerjaan terhapus; version nil berarti tanpa syarat versi.
+func (r *PekerjaanRepository) SoftDelete(ctx context.Context, id primitive.ObjectID, version *int64) error {
+	result, err := r.collection.UpdateOne(ctx, withVersion(bson.M{"_id": id}, version), bson.M{
 		"$set": bson.M{"is_delete": true},
+		"$inc": incVersion,
 	})
-	return err
+	if err != nil {
+		return err
+	}
+	return versionChecked(version, result.MatchedCount)
 }
 
-func (r *PekerjaanRepository) GetAllWithFilter(ctx context.Context, search, sortBy, order string, limit, offset int) ([]model.PekerjaanAlumni, error) {
+// pekerjaanSearchFilter adalah filter pencarian yang dipakai daftar, hitung total dan ekspor pekerjaan.
+func pekerjaanSearchFilter(search string) bson.M {
 	filter := bson.M{"is_delete": false}
 	if search != "" {
 		filter = bson.M{
@@ -94,6 +102,11 @@ func (r *PekerjaanRepository) GetAllWithFilter(ctx context.Context, search, sort
 			},
 		}
 	}
+	return filter
+}
+
+func (r *PekerjaanRepository) GetAllWithFilter(ctx context.Context, search, sortBy, order string, limit, offset int) ([]model.PekerjaanAlumni, error) {
+	filter := pekerjaanSearchFilter(search)
 
 	sortOrder := int32(-1)
 	if order == "asc" {
@@ -118,19 +131,12 @@ func (r *PekerjaanRepository) GetAllWithFilter(ctx context.Context, search, sort
 }
 
 func (r *PekerjaanRepository) CountWithSearch(ctx context.Context, search string) (int64, error) {
-	filter := bson.M{"is_delete": false}
-	if search != "" {
-		filter = bson.M{
-			"is_delete": false,
-			"$or": []bson.M{
-				{"nama_perusahaan": bson.M{"$regex": search, "$options": "i"}},
-				{"posisi_jabatan": bson.M{"$regex": search, "$options": "i"}},
-				{"bidang_industri": bson.M{"$regex": search, "$options": "i"}},
-				{"lokasi_kerja": bson.M{"$regex": search, "$options": "i"}},
-			},
-		}
-	}
-	return r.collection.CountDocuments(ctx, filter)
+	return r.collection.CountDocuments(ctx, pekerjaanSearchFilter(search))
+}
+
+// ExportCursor membuka cursor seluruh pekerjaan yang cocok dengan pencarian, tanpa pagination.
+func (r *PekerjaanRepository) ExportCursor(ctx context.Context, search, sortBy, order string) (*mongo.Cursor, error) {
+	return r.collection.Find(ctx, pekerjaanSearchFilter(search), options.Find().SetSort(exportSort(sortBy, order)))
 }
 
 func (r *PekerjaanRepository) GetAll(ctx context.Context) ([]model.PekerjaanAlumni, error) {
@@ -160,6 +166,19 @@ func (r *PekerjaanRepository) GetByID(ctx context.Context, id primitive.ObjectID
 	return &pekerjaan, nil
 }
 
+// GetTrashedByID mengambil pekerjaan yang sudah di-soft delete (untuk restore / hapus permanen).
+func (r *PekerjaanRepository) GetTrashedByID(ctx context.Context, id primitive.ObjectID) (*model.PekerjaanAlumni, error) {
+	var pekerjaan model.PekerjaanAlumni
+	err := r.collection.FindOne(ctx, bson.M{"_id": id, "is_delete": true}).Decode(&pekerjaan)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &pekerjaan, nil
+}
+
 func (r *PekerjaanRepository) GetByAlumniID(ctx context.Context, alumniID primitive.ObjectID) ([]model.PekerjaanAlumni, error) {
 	opts := options.Find().SetSort(bson.M{"created_at": -1})
 	cursor, err := r.collection.Find(ctx, bson.M{"alumni_id": alumniID, "is_delete": false}, opts)
@@ -209,6 +228,7 @@ func (r *PekerjaanRepository) Create(ctx context.Context, req model.CreatePekerj
 		StatusPekerjaan:     req.StatusPekerjaan,
 		DeskripsiPekerjaan:  req.DeskripsiPekerjaan,
 		IsDelete:            false,
+		Version:             1,
 		CreatedAt:           now,
 		UpdatedAt:           now,
 	}
@@ -221,7 +241,8 @@ func (r *PekerjaanRepository) Create(ctx context.Context, req model.CreatePekerj
 	return &pekerjaan, nil
 }
 
-func (r *PekerjaanRepository) Update(ctx context.Context, id primitive.ObjectID, req model.UpdatePekerjaanRequest) (*model.PekerjaanAlumni, error) {
+// pekerjaanUpdateFields memetakan request update ke field dokumen pekerjaan (tanggal di-parse).
+func pekerjaanUpdateFields(req model.UpdatePekerjaanRequest) (bson.M, error) {
 	tanggalMulai, err := time.Parse("2006-01-02", req.TanggalMulaiKerja)
 	if err != nil {
 		return nil, err
@@ -236,27 +257,96 @@ func (r *PekerjaanRepository) Update(ctx context.Context, id primitive.ObjectID,
 		tanggalSelesai = &t
 	}
 
-	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
-		"$set": bson.M{
-			"nama_perusahaan":       req.NamaPerusahaan,
-			"posisi_jabatan":        req.PosisiJabatan,
-			"bidang_industri":       req.BidangIndustri,
-			"lokasi_kerja":          req.LokasiKerja,
-			"gaji_range":            req.GajiRange,
-			"tanggal_mulai_kerja":   tanggalMulai,
-			"tanggal_selesai_kerja": tanggalSelesai,
-			"status_pekerjaan":      req.StatusPekerjaan,
-			"deskripsi_pekerjaan":   req.DeskripsiPekerjaan,
-			"updated_at":            time.Now(),
-		},
-	})
+	return bson.M{
+		"nama_perusahaan":       req.NamaPerusahaan,
+		"posisi_jabatan":        req.PosisiJabatan,
+		"bidang_industri":       req.BidangIndustri,
+		"lokasi_kerja":          req.LokasiKerja,
+		"gaji_range":            req.GajiRange,
+		"tanggal_mulai_kerja":   tanggalMulai,
+		"tanggal_selesai_kerja": tanggalSelesai,
+		"status_pekerjaan":      req.StatusPekerjaan,
+		"deskripsi_pekerjaan":   req.DeskripsiPekerjaan,
+	}, nil
+}
+
+// Update menimpa semua field pekerjaan; version nil berarti tanpa syarat versi.
+func (r *PekerjaanRepository) Update(ctx context.Context, id primitive.ObjectID, version *int64, req model.UpdatePekerjaanRequest) (*model.PekerjaanAlumni, error) {
+	set, err := pekerjaanUpdateFields(req)
+	if err != nil {
+		return nil, err
+	}
+	set["updated_at"] = time.Now()
+
+	result, err := r.collection.UpdateOne(ctx, withVersion(bson.M{"_id": id}, version), bson.M{"$set": set, "$inc": incVersion})
 	if err != nil {
 		return nil, err
 	}
+	if err := versionChecked(version, result.MatchedCount); err != nil {
+		return nil, err
+	}
 	return r.GetByID(ctx, id)
 }
 
-func (r *PekerjaanRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
-	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
-	return err
+// Patch hanya menulis field yang berbeda antara current (data tersimpan) dan patched beserta
+// updated_at. Tanpa perubahan, dokumen tidak ditulis sama sekali. version adalah versi saat
+// current dibaca, sehingga perubahan oleh pihak lain di antaranya menghasilkan ErrVersionMismatch.
+func (r *PekerjaanRepository) Patch(ctx context.Context, id primitive.ObjectID, version int64, current, patched model.UpdatePekerjaanRequest) (*model.PekerjaanAlumni, error) {
+	before, err := pekerjaanUpdateFields(current)
+	if err != nil {
+		return nil, err
+	}
+	after, err := pekerjaanUpdateFields(patched)
+	if err != nil {
+		return nil, err
+	}
+	set := changedFields(before, after)
+	if len(set) == 0 {
+		return r.GetByID(ctx, id)
+	}
+	set["updated_at"] = time.Now()
+
+	filter := withVersion(bson.M{"_id": id, "is_delete": false}, &version)
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": set, "$inc": incVersion})
+	if err != nil {
+		return nil, err
+	}
+	if err := versionChecked(&version, result.MatchedCount); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// Delete menghapus pekerjaan permanen; version nil berarti tanpa syarat versi.
+func (r *PekerjaanRepository) Delete(ctx context.Context, id primitive.ObjectID, version *int64) error {
+	result, err := r.collection.DeleteOne(ctx, withVersion(bson.M{"_id": id}, version))
+	if err != nil {
+		return err
+	}
+	return versionChecked(version, result.DeletedCount)
+}
+
+// GetAllByAlumniID mengambil semua pekerjaan alumni, termasuk yang sudah di-soft delete.
+func (r *PekerjaanRepository) GetAllByAlumniID(ctx context.Context, alumniID primitive.ObjectID) ([]model.PekerjaanAlumni, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	cursor, err := r.collection.Find(ctx, bson.M{"alumni_id": alumniID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var list []model.PekerjaanAlumni
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// DeleteByAlumniID menghapus permanen semua pekerjaan alumni.
+func (r *PekerjaanRepository) DeleteByAlumniID(ctx context.Context, alumniID primitive.ObjectID) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"alumni_id": alumniID})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
 }
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"gofiber-mongo/app/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RoleRepository struct {
+	collection *mongo.Collection
+	userColl   *mongo.Collection
+}
+
+func NewRoleRepository(db *mongo.Database) *RoleRepository {
+	return &RoleRepository{
+		collection: db.Collection("roles"),
+		userColl:   db.Collection("users"),
+	}
+}
+
+// EnsureDefaultRoles membuat role bawaan. Role admin selalu disinkronkan dengan semua
+// permission yang dikenal; role user hanya dibuat jika belum ada agar perubahan admin tidak tertimpa.
+func (r *RoleRepository) EnsureDefaultRoles(ctx context.Context) error {
+	now := time.Now()
+	upsert := options.Update().SetUpsert(true)
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"name": model.RoleAdmin}, bson.M{
+		"$set": bson.M{
+			"permissions": model.AllPermissions,
+			"is_system":   true,
+			"updated_at":  now,
+		},
+		"$setOnInsert": bson.M{
+			"description": "Administrator dengan semua permission",
+			"created_at":  now,
+		},
+	}, upsert)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.collection.UpdateOne(ctx, bson.M{"name": model.RoleUser}, bson.M{
+		"$set": bson.M{"is_system": true},
+		"$setOnInsert": bson.M{
+			"description": "User alumni biasa",
+			"permissions": model.DefaultUserPermissions,
+			"created_at":  now,
+			"updated_at":  now,
+		},
+	}, upsert)
+	return err
+}
+
+func (r *RoleRepository) GetAll(ctx context.Context) ([]model.Role, error) {
+	opts := options.Find().SetSort(bson.M{"name": 1})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var list []model.Role
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *RoleRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Role, error) {
+	var role model.Role
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&role)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *RoleRepository) FindByName(ctx context.Context, name string) (*model.Role, error) {
+	var role model.Role
+	err := r.collection.FindOne(ctx, bson.M{"name": name}).Decode(&role)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *RoleRepository) Create(ctx context.Context, req model.CreateRoleRequest) (*model.Role, error) {
+	now := time.Now()
+	role := model.Role{
+		ID:          primitive.NewObjectID(),
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+		IsSystem:    false,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if _, err := r.collection.InsertOne(ctx, role); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *RoleRepository) Update(ctx context.Context, id primitive.ObjectID, req model.UpdateRoleRequest) (*model.Role, error) {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{
+			"description": req.Description,
+			"permissions": req.Permissions,
+			"updated_at":  time.Now(),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+func (r *RoleRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// CountUsers menghitung user aktif yang memakai role tertentu.
+func (r *RoleRepository) CountUsers(ctx context.Context, name string) (int64, error) {
+	return r.userColl.CountDocuments(ctx, bson.M{"role": name, "is_delete": false})
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"gofiber-mongo/app/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SecurityEventRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSecurityEventRepository(db *mongo.Database) *SecurityEventRepository {
+	return &SecurityEventRepository{
+		collection: db.Collection("security_events"),
+	}
+}
+
+func (r *SecurityEventRepository) Create(ctx context.Context, event *model.SecurityEvent) error {
+	event.ID = primitive.NewObjectID()
+	event.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, event)
+	return err
+}
+
+func buildSecurityEventFilter(f model.SecurityEventFilter) bson.M {
+	filter := bson.M{}
+	if !f.UserID.IsZero() {
+		filter["user_id"] = f.UserID
+	}
+	if !f.ActorID.IsZero() {
+		filter["actor_id"] = f.ActorID
+	}
+	if f.Type != "" {
+		filter["type"] = f.Type
+	}
+	if f.Outcome != "" {
+		filter["outcome"] = f.Outcome
+	}
+	if f.IP != "" {
+		filter["ip"] = f.IP
+	}
+	if f.From != nil || f.To != nil {
+		createdAt := bson.M{}
+		if f.From != nil {
+			createdAt["$gte"] = *f.From
+		}
+		if f.To != nil {
+			createdAt["$lt"] = *f.To
+		}
+		filter["created_at"] = createdAt
+	}
+	return filter
+}
+
+func (r *SecurityEventRepository) GetAllWithFilter(ctx context.Context, f model.SecurityEventFilter, limit, offset int) ([]model.SecurityEvent, error) {
+	opts := options.Find().
+		SetSort(bson.M{"created_at": -1}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, buildSecurityEventFilter(f), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var list []model.SecurityEvent
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *SecurityEventRepository) CountWithFilter(ctx context.Context, f model.SecurityEventFilter) (int64, error) {
+	return r.collection.CountDocuments(ctx, buildSecurityEventFilter(f))
+}
+
+// FindByUser mengambil semua event milik user (dipakai untuk ekspor data pribadi).
+func (r *SecurityEventRepository) FindByUser(ctx context.Context, userID primitive.ObjectID) ([]model.SecurityEvent, error) {
+	return r.GetAllWithFilter(ctx, model.SecurityEventFilter{UserID: userID}, 0, 0)
+}
+
+// DeleteByUser menghapus semua event milik user (dipakai saat penghapusan data pribadi).
+// Event di mana user tersebut hanya sebagai admin yang bertindak tetap disimpan.
+func (r *SecurityEventRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
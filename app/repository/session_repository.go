@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"gofiber-mongo/app/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SessionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSessionRepository(db *mongo.Database) *SessionRepository {
+	return &SessionRepository{
+		collection: db.Collection("sessions"),
+	}
+}
+
+func (r *SessionRepository) Create(ctx context.Context, session *model.Session) error {
+	now := time.Now()
+	session.CreatedAt = now
+	session.LastSeenAt = now
+
+	_, err := r.collection.InsertOne(ctx, session)
+	return err
+}
+
+func (r *SessionRepository) FindByID(ctx context.Context, id string) (*model.Session, error) {
+	var session model.Session
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&session)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// FindActiveByUser mengambil session user yang belum dicabut dan belum expired.
+func (r *SessionRepository) FindActiveByUser(ctx context.Context, userID primitive.ObjectID) ([]model.Session, error) {
+	opts := options.Find().SetSort(bson.M{"last_seen_at": -1})
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"user_id":    userID,
+		"revoked_at": nil,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var list []model.Session
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Touch memperbarui waktu terakhir aktif, paling sering sekali per menit per session.
+func (r *SessionRepository) Touch(ctx context.Context, id, ip string) error {
+	now := time.Now()
+	_, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id":          id,
+		"last_seen_at": bson.M{"$lt": now.Add(-time.Minute)},
+	}, bson.M{
+		"$set": bson.M{"last_seen_at": now, "ip": ip},
+	})
+	return err
+}
+
+// Extend dipanggil saat refresh token dirotasi: session ikut diperpanjang.
+func (r *SessionRepository) Extend(ctx context.Context, id, ip string, expiresAt time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"last_seen_at": time.Now(), "ip": ip, "expires_at": expiresAt},
+	})
+	return err
+}
+
+func (r *SessionRepository) Revoke(ctx context.Context, id string) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "revoked_at": nil}, bson.M{
+		"$set": bson.M{"revoked_at": time.Now()},
+	})
+	return err
+}
+
+func (r *SessionRepository) RevokeAllForUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.UpdateMany(ctx, bson.M{"user_id": userID, "revoked_at": nil}, bson.M{
+		"$set": bson.M{"revoked_at": time.Now()},
+	})
+	return err
+}
+
+// DeleteByUser menghapus semua session user (dipakai saat penghapusan data pribadi).
+func (r *SessionRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
+
+// RevokeOthersForUser mengakhiri semua session user kecuali keepID (session yang sedang dipakai).
+func (r *SessionRepository) RevokeOthersForUser(ctx context.Context, userID primitive.ObjectID, keepID string) error {
+	_, err := r.collection.UpdateMany(ctx, bson.M{
+		"user_id":    userID,
+		"_id":        bson.M{"$ne": keepID},
+		"revoked_at": nil,
+	}, bson.M{
+		"$set": bson.M{"revoked_at": time.Now()},
+	})
+	return err
+}
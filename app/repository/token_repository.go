@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"gofiber-mongo/app/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type TokenRepository struct {
+	refreshColl *mongo.Collection
+	revokedColl *mongo.Collection
+}
+
+func NewTokenRepository(db *mongo.Database) *TokenRepository {
+	return &TokenRepository{
+		refreshColl: db.Collection("refresh_tokens"),
+		revokedColl: db.Collection("revoked_tokens"),
+	}
+}
+
+func (r *TokenRepository) CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error {
+	if token.ID.IsZero() {
+		token.ID = primitive.NewObjectID()
+	}
+	token.CreatedAt = time.Now()
+
+	_, err := r.refreshColl.InsertOne(ctx, token)
+	return err
+}
+
+func (r *TokenRepository) FindRefreshTokenByHash(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	err := r.refreshColl.FindOne(ctx, bson.M{"token_hash": hash}).Decode(&token)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RotateRefreshToken mencabut token lama dan mencatat penggantinya secara atomik.
+// Mengembalikan false jika token sudah dicabut sebelumnya (indikasi token dipakai ulang).
+func (r *TokenRepository) RotateRefreshToken(ctx context.Context, id, replacedBy primitive.ObjectID) (bool, error) {
+	result, err := r.refreshColl.UpdateOne(ctx, bson.M{"_id": id, "revoked_at": nil}, bson.M{
+		"$set": bson.M{"revoked_at": time.Now(), "replaced_by": replacedBy},
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *TokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	_, err := r.refreshColl.UpdateMany(ctx, bson.M{"family_id": familyID, "revoked_at": nil}, bson.M{
+		"$set": bson.M{"revoked_at": time.Now()},
+	})
+	return err
+}
+
+func (r *TokenRepository) RevokeAllForUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.refreshColl.UpdateMany(ctx, bson.M{"user_id": userID, "revoked_at": nil}, bson.M{
+		"$set": bson.M{"revoked_at": time.Now()},
+	})
+	return err
+}
+
+// RevokeOthersForUser mencabut semua refresh token user kecuali yang ada di family keepFamilyID.
+func (r *TokenRepository) RevokeOthersForUser(ctx context.Context, userID primitive.ObjectID, keepFamilyID string) error {
+	_, err := r.refreshColl.UpdateMany(ctx, bson.M{
+		"user_id":    userID,
+		"family_id":  bson.M{"$ne": keepFamilyID},
+		"revoked_at": nil,
+	}, bson.M{
+		"$set": bson.M{"revoked_at": time.Now()},
+	})
+	return err
+}
+
+// DeleteAllForUser menghapus refresh token dan catatan access token yang dicabut milik user
+// (dipakai saat penghapusan data pribadi, setelah semua session diputus).
+func (r *TokenRepository) DeleteAllForUser(ctx context.Context, userID primitive.ObjectID) error {
+	if _, err := r.refreshColl.DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+		return err
+	}
+	_, err := r.revokedColl.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
+
+func (r *TokenRepository) RevokeAccessToken(ctx context.Context, jti string, userID primitive.ObjectID, expiresAt time.Time) error {
+	_, err := r.revokedColl.InsertOne(ctx, model.RevokedToken{
+		ID:        primitive.NewObjectID(),
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+		RevokedAt: time.Now(),
+	})
+	// jti yang sudah dicabut sebelumnya (mis. logout dua kali) bukan error
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+func (r *TokenRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	count, err := r.revokedColl.CountDocuments(ctx, bson.M{
+		"jti":        jti,
+		"expires_at": bson.M{"$gt": time.Now()},
+	})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
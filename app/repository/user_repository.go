@@ -6,6 +6,9 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"regexp"
+	"time"
 )
 
 type UserRepository struct {
@@ -79,3 +82,252 @@ func (r *UserRepository) Update(ctx context.Context, id primitive.ObjectID, user
 	}
 	return r.FindByID(ctx, id)
 }
+
+func (r *UserRepository) UpdatePassword(ctx context.Context, id primitive.ObjectID, passwordHash string) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"password_hash": passwordHash},
+	})
+	return err
+}
+
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"email_verified": true, "email_verified_at": time.Now()},
+	})
+	return err
+}
+
+// MarkLegacyEmailsVerified menandai user yang dibuat sebelum ada verifikasi email
+// (belum punya field email_verified) sebagai terverifikasi, supaya tidak terkunci.
+func (r *UserRepository) MarkLegacyEmailsVerified(ctx context.Context) (int64, error) {
+	result, err := r.collection.UpdateMany(ctx, bson.M{"email_verified": bson.M{"$exists": false}}, bson.M{
+		"$set": bson.M{"email_verified": true},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// RecordFailedLogin menambah counter login gagal secara atomik dan mengembalikan nilai barunya.
+func (r *UserRepository) RecordFailedLogin(ctx context.Context, id primitive.ObjectID) (int, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user model.User
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, bson.M{
+		"$inc": bson.M{"failed_login_attempts": 1},
+		"$set": bson.M{"last_failed_login_at": time.Now()},
+	}, opts).Decode(&user)
+	if err != nil {
+		return 0, err
+	}
+	return user.FailedLoginAttempts, nil
+}
+
+func (r *UserRepository) LockUntil(ctx context.Context, id primitive.ObjectID, until time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"locked_until": until},
+	})
+	return err
+}
+
+// ResetFailedLogins dipakai setelah login berhasil dan oleh admin untuk membuka kunci akun.
+func (r *UserRepository) ResetFailedLogins(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set":   bson.M{"failed_login_attempts": 0},
+		"$unset": bson.M{"locked_until": "", "last_failed_login_at": ""},
+	})
+	return err
+}
+
+func (r *UserRepository) SetPendingTOTPSecret(ctx context.Context, id primitive.ObjectID, secret string) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"totp_pending_secret": secret},
+	})
+	return err
+}
+
+// EnableTwoFactor memindahkan secret pending menjadi aktif dan menyimpan hash recovery code.
+func (r *UserRepository) EnableTwoFactor(ctx context.Context, id primitive.ObjectID, secret string, step int64, recoveryHashes []string) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{
+			"two_factor_enabled": true,
+			"totp_secret":        secret,
+			"totp_last_step":     step,
+			"recovery_codes":     recoveryHashes,
+		},
+		"$unset": bson.M{"totp_pending_secret": ""},
+	})
+	return err
+}
+
+func (r *UserRepository) DisableTwoFactor(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set":   bson.M{"two_factor_enabled": false},
+		"$unset": bson.M{"totp_secret": "", "totp_pending_secret": "", "totp_last_step": "", "recovery_codes": ""},
+	})
+	return err
+}
+
+func (r *UserRepository) SetRecoveryCodes(ctx context.Context, id primitive.ObjectID, recoveryHashes []string) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"recovery_codes": recoveryHashes},
+	})
+	return err
+}
+
+// UseTOTPStep mencatat time step kode TOTP yang dipakai. Mengembalikan false jika step
+// tersebut (atau yang lebih baru) sudah pernah dipakai, sehingga kode tidak bisa di-replay.
+func (r *UserRepository) UseTOTPStep(ctx context.Context, id primitive.ObjectID, step int64) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id": id,
+		"$or": []bson.M{
+			{"totp_last_step": bson.M{"$exists": false}},
+			{"totp_last_step": bson.M{"$lt": step}},
+		},
+	}, bson.M{
+		"$set": bson.M{"totp_last_step": step},
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// UseRecoveryCode menghapus hash recovery code secara atomik. Mengembalikan false jika kode tidak ada.
+func (r *UserRepository) UseRecoveryCode(ctx context.Context, id primitive.ObjectID, hash string) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "recovery_codes": hash}, bson.M{
+		"$pull": bson.M{"recovery_codes": hash},
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// userSortFields membatasi field yang boleh dipakai untuk sorting daftar user.
+var userSortFields = map[string]bool{
+	"username":   true,
+	"email":      true,
+	"role":       true,
+	"created_at": true,
+}
+
+// buildUserFilter menyusun filter daftar user. status: "active" (default), "deleted" atau "all".
+func buildUserFilter(search, role, status string) bson.M {
+	filter := bson.M{}
+	switch status {
+	case "deleted":
+		filter["is_delete"] = true
+	case "all":
+	default:
+		filter["is_delete"] = bson.M{"$ne": true}
+	}
+	if role != "" {
+		filter["role"] = role
+	}
+	if search != "" {
+		pattern := regexp.QuoteMeta(search)
+		filter["$or"] = []bson.M{
+			{"username": bson.M{"$regex": pattern, "$options": "i"}},
+			{"email": bson.M{"$regex": pattern, "$options": "i"}},
+		}
+	}
+	return filter
+}
+
+func (r *UserRepository) GetAllWithFilter(ctx context.Context, search, role, status, sortBy, order string, limit, offset int) ([]model.User, error) {
+	filter := buildUserFilter(search, role, status)
+
+	if !userSortFields[sortBy] {
+		sortBy = "created_at"
+	}
+	sortOrder := int32(-1)
+	if order == "asc" {
+		sortOrder = 1
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: sortBy, Value: sortOrder}, {Key: "_id", Value: sortOrder}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var list []model.User
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *UserRepository) CountWithFilter(ctx context.Context, search, role, status string) (int64, error) {
+	return r.collection.CountDocuments(ctx, buildUserFilter(search, role, status))
+}
+
+func (r *UserRepository) UpdateRole(ctx context.Context, id primitive.ObjectID, role string) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"role": role},
+	})
+	return err
+}
+
+func (r *UserRepository) Restore(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "is_delete": true}, bson.M{
+		"$set": bson.M{"is_delete": false},
+	})
+	return err
+}
+
+func (r *UserRepository) HardDelete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *UserRepository) FindByOIDCSubject(ctx context.Context, issuer, subject string) (*model.User, error) {
+	var user model.User
+	err := r.collection.FindOne(ctx, bson.M{"oidc_issuer": issuer, "oidc_subject": subject}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// LinkOIDC menautkan identitas SSO ke user yang sudah ada.
+func (r *UserRepository) LinkOIDC(ctx context.Context, id primitive.ObjectID, issuer, subject string) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"oidc_issuer": issuer, "oidc_subject": subject},
+	})
+	return err
+}
+
+// Anonymize menghapus data identitas dan kredensial user, lalu menandainya terhapus.
+// Username dan email diganti nilai unik yang tidak bisa dipakai login.
+func (r *UserRepository) Anonymize(ctx context.Context, id primitive.ObjectID) error {
+	placeholder := "deleted-" + id.Hex()
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{
+			"username":           placeholder,
+			"email":              placeholder + "@invalid",
+			"email_verified":     false,
+			"password_hash":      "",
+			"two_factor_enabled": false,
+			"is_delete":          true,
+		},
+		"$unset": bson.M{
+			"email_verified_at":   "",
+			"totp_secret":         "",
+			"totp_pending_secret": "",
+			"recovery_codes":      "",
+			"oidc_issuer":         "",
+			"oidc_subject":        "",
+		},
+	})
+	return err
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ErrVersionMismatch dikembalikan write bersyarat jika dokumen sudah diubah (atau dihapus)
+// sejak versi yang diharapkan dibaca.
+var ErrVersionMismatch = errors.New("versi dokumen sudah berubah")
+
+// incVersion dipakai sebagai $inc di setiap write alumni / pekerjaan agar ETag ikut berubah.
+var incVersion = bson.M{"version": int64(1)}
+
+// withVersion menambahkan syarat versi ke filter; nil berarti tanpa syarat. Dokumen lama yang
+// belum punya field version dianggap versi 0.
+func withVersion(filter bson.M, version *int64) bson.M {
+	if version == nil {
+		return filter
+	}
+	if *version == 0 {
+		filter["version"] = bson.M{"$in": bson.A{int64(0), nil}}
+	} else {
+		filter["version"] = *version
+	}
+	return filter
+}
+
+// versionChecked mengubah write bersyarat yang tidak mengenai dokumen apa pun menjadi ErrVersionMismatch.
+func versionChecked(version *int64, affected int64) error {
+	if version != nil && affected == 0 {
+		return ErrVersionMismatch
+	}
+	return nil
+}
@@ -0,0 +1,583 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"gofiber-mongo/app/model"
+	"gofiber-mongo/app/repository"
+	"gofiber-mongo/mailer"
+	"gofiber-mongo/utils"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxClaimCodeAttempts adalah batas percobaan kode sebelum klaim dibatalkan.
+const maxClaimCodeAttempts = 5
+
+// AlumniClaimService menautkan akun user ke data alumni (Alumni.UserID).
+// User membuktikan identitas dengan NIM + tahun lulus + kode yang dikirim ke email alumni;
+// klaim atas alumni yang sudah dimiliki user lain (atau tanpa email) diputuskan admin.
+type AlumniClaimService struct {
+	Repo         *repository.AlumniClaimRepository
+	AlumniRepo   *repository.AlumniRepository
+	UserRepo     *repository.UserRepository
+	ThrottleRepo *repository.LoginThrottleRepository
+	Mailer       mailer.Mailer
+}
+
+func NewAlumniClaimService(repo *repository.AlumniClaimRepository, alumniRepo *repository.AlumniRepository,
+	userRepo *repository.UserRepository, throttleRepo *repository.LoginThrottleRepository, m mailer.Mailer) *AlumniClaimService {
+	return &AlumniClaimService{
+		Repo:         repo,
+		AlumniRepo:   alumniRepo,
+		UserRepo:     userRepo,
+		ThrottleRepo: throttleRepo,
+		Mailer:       m,
+	}
+}
+
+// claimRateLimited mencatat satu pengajuan klaim per user dan per IP, dan mengembalikan waktu
+// berakhirnya kunci jika salah satunya sudah melewati ALUMNI_CLAIM_MAX_ATTEMPTS /
+// ALUMNI_CLAIM_IP_MAX_ATTEMPTS dalam ALUMNI_CLAIM_WINDOW. Setiap pengajuan dihitung, cocok atau
+// tidak, karena hasil pencocokan NIM tidak diberitahukan ke pemanggil.
+func (s *AlumniClaimService) claimRateLimited(ctx context.Context, c *fiber.Ctx, userID primitive.ObjectID) (*time.Time, error) {
+	window := utils.DurationFromEnv("ALUMNI_CLAIM_WINDOW", time.Hour)
+	limits := []struct {
+		key string
+		max int
+	}{
+		{"claim:user:" + userID.Hex(), utils.IntFromEnv("ALUMNI_CLAIM_MAX_ATTEMPTS", 5)},
+		{"claim:ip:" + c.IP(), utils.IntFromEnv("ALUMNI_CLAIM_IP_MAX_ATTEMPTS", 20)},
+	}
+
+	for _, l := range limits {
+		throttle, err := s.ThrottleRepo.Get(ctx, l.key)
+		if err != nil {
+			return nil, err
+		}
+		if throttle != nil && throttle.LockedUntil != nil && time.Now().Before(*throttle.LockedUntil) {
+			return throttle.LockedUntil, nil
+		}
+	}
+	for _, l := range limits {
+		throttle, err := s.ThrottleRepo.RecordFailure(ctx, l.key, window)
+		if err != nil {
+			return nil, err
+		}
+		if throttle.Count >= l.max {
+			if err := s.ThrottleRepo.LockUntil(ctx, l.key, throttle.LastAt.Add(window)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nil, nil
+}
+
+// unsentCode membuat hash kode acak yang tidak pernah dikirim ke siapa pun, untuk klaim yang
+// NIM-nya tidak cocok atau yang masuk antrean admin. Bagi user, klaim tersebut terlihat sama
+// dengan klaim yang menunggu kode, dan verifikasinya selalu gagal dengan cara yang sama.
+func unsentCode() (string, *time.Time, error) {
+	_, hash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+	expiresAt := time.Now().Add(utils.DurationFromEnv("ALUMNI_CLAIM_CODE_TTL", 15*time.Minute))
+	return hash, &expiresAt, nil
+}
+
+// awaitsCode menandakan klaim yang bagi user terlihat menunggu kode: pending_code, atau
+// pending_review yang kode samarannya belum dibatalkan.
+func awaitsCode(claim *model.AlumniClaim) bool {
+	return claim.Status == model.ClaimStatusPendingCode ||
+		(claim.Status == model.ClaimStatusPendingReview && claim.CodeHash != "")
+}
+
+// claimSubmitted adalah respons pengajuan klaim. Isinya sama untuk NIM yang cocok maupun tidak,
+// dan untuk klaim yang menunggu kode maupun review admin, supaya data alumni tidak bisa ditebak.
+func claimSubmitted(c *fiber.Ctx, claim *model.AlumniClaim) error {
+	return c.Status(202).JSON(fiber.Map{
+		"success": true,
+		"message": "Jika NIM dan tahun lulus cocok, kode verifikasi dikirim ke email alumni yang tercatat " +
+			"atau klaim diteruskan ke admin untuk ditinjau",
+		"data": fiber.Map{"id": claim.ID},
+	})
+}
+
+// maskEmail menyamarkan email untuk ditampilkan, mis. "b***@example.com".
+func maskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return "***"
+	}
+	return local[:1] + "***@" + domain
+}
+
+// HandleCreate godoc
+// @Summary Klaim profil alumni
+// @Description Memulai klaim data alumni dengan NIM dan tahun lulus. Kode verifikasi dikirim ke email alumni yang tercatat.
+// @Description Jika alumni sudah ditautkan ke akun lain, tidak punya email, atau request_review=true, klaim masuk antrean admin.
+// @Description Respons selalu sama, termasuk jika NIM dan tahun lulus tidak cocok (kode tidak pernah dikirim). Pengajuan dibatasi per user dan per IP.
+// @Tags Alumni Claims
+// @Accept json
+// @Produce json
+// @Param body body model.CreateAlumniClaimRequest true "NIM dan tahun lulus"
+// @Success 202 {object} map[string]interface{} "id klaim"
+// @Failure 400 {object} map[string]interface{} "Request tidak valid"
+// @Failure 409 {object} map[string]interface{} "Akun sudah ditautkan"
+// @Failure 429 {object} map[string]interface{} "Terlalu banyak pengajuan klaim"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/alumni/claim [post]
+// @Security BearerAuth
+func (s *AlumniClaimService) Create(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(primitive.ObjectID)
+
+	var req model.CreateAlumniClaimRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
+	}
+	req.NIM = strings.TrimSpace(req.NIM)
+	if req.NIM == "" || req.TahunLulus == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "nim dan tahun_lulus harus diisi"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	linked, err := s.AlumniRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if linked != nil {
+		return c.Status(409).JSON(fiber.Map{"error": "Akun sudah ditautkan ke data alumni"})
+	}
+
+	until, err := s.claimRateLimited(ctx, c, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if until != nil {
+		return retryLater(c, *until, "Terlalu banyak pengajuan klaim. Coba lagi nanti")
+	}
+
+	alumni, err := s.AlumniRepo.GetByNIM(ctx, req.NIM)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := s.Repo.ExpirePendingForUser(ctx, userID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// NIM tidak ada atau tahun lulus salah: simpan klaim yang kodenya tidak pernah dikirim, supaya
+	// respons dan alur verifikasi sama persis dengan klaim yang cocok
+	if alumni == nil || alumni.TahunLulus != req.TahunLulus {
+		hash, expiresAt, err := unsentCode()
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Gagal generate kode"})
+		}
+		claim := &model.AlumniClaim{
+			UserID:        userID,
+			NIM:           req.NIM,
+			Note:          strings.TrimSpace(req.Note),
+			Status:        model.ClaimStatusPendingCode,
+			CodeHash:      hash,
+			CodeExpiresAt: expiresAt,
+		}
+		if err := s.Repo.Create(ctx, claim); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		return claimSubmitted(c, claim)
+	}
+
+	claim := &model.AlumniClaim{
+		UserID:   userID,
+		AlumniID: alumni.ID,
+		NIM:      alumni.NIM,
+		Note:     strings.TrimSpace(req.Note),
+	}
+
+	switch {
+	case !alumni.UserID.IsZero():
+		claim.DisputeReason = "already_linked"
+	case alumni.Email == "":
+		claim.DisputeReason = "no_email"
+	case req.RequestReview:
+		claim.DisputeReason = "requested_by_user"
+	}
+
+	if claim.DisputeReason != "" {
+		hash, expiresAt, err := unsentCode()
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Gagal generate kode"})
+		}
+		claim.Status = model.ClaimStatusPendingReview
+		claim.CodeHash = hash
+		claim.CodeExpiresAt = expiresAt
+		if err := s.Repo.Create(ctx, claim); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		return claimSubmitted(c, claim)
+	}
+
+	code, err := utils.GenerateNumericCode(6)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal generate kode"})
+	}
+	ttl := utils.DurationFromEnv("ALUMNI_CLAIM_CODE_TTL", 15*time.Minute)
+	expiresAt := time.Now().Add(ttl)
+	claim.Status = model.ClaimStatusPendingCode
+	claim.CodeHash = utils.HashToken(code)
+	claim.CodeExpiresAt = &expiresAt
+
+	if err := s.Repo.Create(ctx, claim); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	sendMailAsync(s.Mailer, mailer.Message{
+		To:      alumni.Email,
+		Subject: "Kode klaim profil alumni",
+		Body: "Halo " + alumni.Nama + ",\n\n" +
+			"Seseorang meminta untuk menautkan akun ke data alumni dengan NIM " + alumni.NIM + ". Kode verifikasi Anda:\n\n" +
+			code + "\n\n" +
+			"Kode berlaku selama " + ttl.String() + ". Abaikan email ini jika Anda tidak memintanya.\n",
+	})
+
+	return claimSubmitted(c, claim)
+}
+
+// HandleVerify godoc
+// @Summary Verifikasi kode klaim alumni
+// @Description Menyelesaikan klaim dengan kode dari email alumni. Jika berhasil, akun ditautkan ke data alumni.
+// @Tags Alumni Claims
+// @Accept json
+// @Produce json
+// @Param id path string true "Claim ID"
+// @Param body body model.VerifyAlumniClaimRequest true "Kode verifikasi"
+// @Success 200 {object} map[string]interface{} "klaim disetujui"
+// @Failure 400 {object} map[string]interface{} "Kode salah atau expired"
+// @Failure 404 {object} map[string]interface{} "Klaim tidak ditemukan"
+// @Failure 409 {object} map[string]interface{} "Alumni sudah dimiliki akun lain"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/alumni/claim/{id}/verify [post]
+// @Security BearerAuth
+func (s *AlumniClaimService) Verify(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(primitive.ObjectID)
+
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
+	}
+	var req model.VerifyAlumniClaimRequest
+	if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.Code) == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Kode harus diisi"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	claim, err := s.Repo.FindByID(ctx, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	// Klaim pending_review yang masih punya kode samaran diperlakukan sama dengan pending_code,
+	// supaya user tidak bisa membedakan NIM yang cocok dari yang tidak
+	if claim == nil || claim.UserID != userID || !awaitsCode(claim) {
+		return c.Status(404).JSON(fiber.Map{"error": "Klaim tidak ditemukan atau sudah tidak menunggu kode"})
+	}
+
+	expire := func(message string) error {
+		if err := s.Repo.ExpireCode(ctx, id); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(400).JSON(fiber.Map{"error": message})
+	}
+
+	if claim.CodeExpiresAt == nil || time.Now().After(*claim.CodeExpiresAt) {
+		return expire("Kode sudah expired, silakan ajukan klaim ulang")
+	}
+
+	attempts, err := s.Repo.IncrementAttempts(ctx, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if attempts > maxClaimCodeAttempts {
+		return expire("Terlalu banyak percobaan, silakan ajukan klaim ulang")
+	}
+	if claim.Status != model.ClaimStatusPendingCode ||
+		subtle.ConstantTimeCompare([]byte(utils.HashToken(strings.TrimSpace(req.Code))), []byte(claim.CodeHash)) != 1 {
+		return c.Status(400).JSON(fiber.Map{"error": "Kode tidak valid"})
+	}
+
+	linked, err := s.AlumniRepo.LinkUser(ctx, claim.AlumniID, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !linked {
+		// Alumni ditautkan ke akun lain sejak klaim dibuat: teruskan ke admin
+		if _, err := s.Repo.UpdateStatus(ctx, id, []string{model.ClaimStatusPendingCode}, bson.M{
+			"status":         model.ClaimStatusPendingReview,
+			"dispute_reason": "already_linked",
+		}); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(409).JSON(fiber.Map{"error": "Data alumni sudah ditautkan ke akun lain. Klaim diteruskan ke admin untuk ditinjau"})
+	}
+
+	if _, err := s.Repo.UpdateStatus(ctx, id, []string{model.ClaimStatusPendingCode}, bson.M{"status": model.ClaimStatusApproved}); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"success": true, "message": "Akun berhasil ditautkan ke data alumni"})
+}
+
+// HandleGetMine godoc
+// @Summary Daftar klaim alumni saya
+// @Description Mengambil riwayat klaim profil alumni milik user yang login. Klaim yang menunggu review admin ditampilkan
+// @Description sebagai pending_code sampai diputuskan.
+// @Tags Alumni Claims
+// @Produce json
+// @Success 200 {object} map[string]interface{} "claim list"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/alumni/claims [get]
+// @Security BearerAuth
+func (s *AlumniClaimService) GetMine(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	claims, err := s.Repo.FindByUser(ctx, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if claims == nil {
+		claims = []model.AlumniClaim{}
+	}
+	// Selama klaim belum diputuskan, user hanya melihat klaim yang menunggu kode atau yang kodenya
+	// sudah expired, tanpa alumni yang cocok maupun alasan dispute, supaya hasil pencocokan NIM
+	// tidak bisa dibaca dari sini
+	for i := range claims {
+		switch claims[i].Status {
+		case model.ClaimStatusPendingReview:
+			if awaitsCode(&claims[i]) {
+				claims[i].Status = model.ClaimStatusPendingCode
+			} else {
+				claims[i].Status = model.ClaimStatusExpired
+			}
+			fallthrough
+		case model.ClaimStatusPendingCode:
+			claims[i].AlumniID = primitive.NilObjectID
+			claims[i].DisputeReason = ""
+		}
+	}
+	return c.JSON(fiber.Map{"success": true, "data": claims})
+}
+
+// HandleUnlinkMine godoc
+// @Summary Lepas tautan alumni
+// @Description Melepas tautan akun user yang login dari data alumni
+// @Tags Alumni Claims
+// @Produce json
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 404 {object} map[string]interface{} "Akun belum ditautkan"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/alumni/link [delete]
+// @Security BearerAuth
+func (s *AlumniClaimService) UnlinkMine(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	alumni, err := s.AlumniRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if alumni == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Akun belum ditautkan ke data alumni"})
+	}
+
+	if err := s.AlumniRepo.UnlinkUser(ctx, alumni.ID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"success": true, "message": "Tautan akun ke data alumni berhasil dilepas"})
+}
+
+// HandleGetAll godoc
+// @Summary Antrean klaim alumni (admin)
+// @Description Mengambil klaim alumni berdasarkan status (default pending_review)
+// @Tags Alumni Claims
+// @Produce json
+// @Param status query string false "pending_code, pending_review, approved, rejected, expired, all" default(pending_review)
+// @Success 200 {object} map[string]interface{} "claim list"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /alumni-claims [get]
+// @Security BearerAuth
+func (s *AlumniClaimService) GetAll(c *fiber.Ctx) error {
+	status := c.Query("status", model.ClaimStatusPendingReview)
+	if status == "all" {
+		status = ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	claims, err := s.Repo.FindByStatus(ctx, status)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if claims == nil {
+		claims = []model.AlumniClaim{}
+	}
+	return c.JSON(fiber.Map{"success": true, "data": claims})
+}
+
+// HandleApprove godoc
+// @Summary Setujui klaim alumni (admin)
+// @Description Menautkan data alumni ke user pengklaim. Tautan ke akun lain (jika ada) diganti.
+// @Tags Alumni Claims
+// @Accept json
+// @Produce json
+// @Param id path string true "Claim ID"
+// @Param body body model.ReviewAlumniClaimRequest false "Catatan review"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 400 {object} map[string]interface{} "ID tidak valid"
+// @Failure 404 {object} map[string]interface{} "Klaim tidak ditemukan"
+// @Failure 409 {object} map[string]interface{} "User sudah ditautkan ke alumni lain"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /alumni-claims/{id}/approve [put]
+// @Security BearerAuth
+func (s *AlumniClaimService) Approve(c *fiber.Ctx) error {
+	reviewerID := c.Locals("user_id").(primitive.ObjectID)
+
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
+	}
+	var req model.ReviewAlumniClaimRequest
+	_ = c.BodyParser(&req)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	claim, err := s.Repo.FindByID(ctx, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if claim == nil || claim.Status != model.ClaimStatusPendingReview {
+		return c.Status(404).JSON(fiber.Map{"error": "Klaim tidak ditemukan atau tidak menunggu review"})
+	}
+
+	alumni, err := s.AlumniRepo.GetByID(ctx, claim.AlumniID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	user, err := s.UserRepo.FindByID(ctx, claim.UserID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if alumni == nil || user == nil || user.IsDelete {
+		return c.Status(404).JSON(fiber.Map{"error": "Data alumni atau user pada klaim sudah tidak ada"})
+	}
+
+	linked, err := s.AlumniRepo.GetByUserID(ctx, claim.UserID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if linked != nil && linked.ID != alumni.ID {
+		return c.Status(409).JSON(fiber.Map{"error": "User sudah ditautkan ke data alumni lain"})
+	}
+
+	if err := s.AlumniRepo.SetUser(ctx, alumni.ID, claim.UserID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if _, err := s.Repo.UpdateStatus(ctx, id, []string{model.ClaimStatusPendingReview}, bson.M{
+		"status":      model.ClaimStatusApproved,
+		"reviewed_by": reviewerID,
+		"reviewed_at": time.Now(),
+		"review_note": strings.TrimSpace(req.Note),
+	}); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"success": true, "message": "Klaim disetujui, akun ditautkan ke data alumni"})
+}
+
+// HandleReject godoc
+// @Summary Tolak klaim alumni (admin)
+// @Description Menolak klaim alumni yang menunggu review
+// @Tags Alumni Claims
+// @Accept json
+// @Produce json
+// @Param id path string true "Claim ID"
+// @Param body body model.ReviewAlumniClaimRequest false "Alasan penolakan"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 400 {object} map[string]interface{} "ID tidak valid"
+// @Failure 404 {object} map[string]interface{} "Klaim tidak ditemukan"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /alumni-claims/{id}/reject [put]
+// @Security BearerAuth
+func (s *AlumniClaimService) Reject(c *fiber.Ctx) error {
+	reviewerID := c.Locals("user_id").(primitive.ObjectID)
+
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
+	}
+	var req model.ReviewAlumniClaimRequest
+	_ = c.BodyParser(&req)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	updated, err := s.Repo.UpdateStatus(ctx, id, []string{model.ClaimStatusPendingReview}, bson.M{
+		"status":      model.ClaimStatusRejected,
+		"reviewed_by": reviewerID,
+		"reviewed_at": time.Now(),
+		"review_note": strings.TrimSpace(req.Note),
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !updated {
+		return c.Status(404).JSON(fiber.Map{"error": "Klaim tidak ditemukan atau tidak menunggu review"})
+	}
+	return c.JSON(fiber.Map{"success": true, "message": "Klaim ditolak"})
+}
+
+// HandleUnlink godoc
+// @Summary Lepas tautan user dari alumni (admin)
+// @Description Melepas tautan akun user dari data alumni tertentu
+// @Tags Alumni Claims
+// @Produce json
+// @Param id path string true "Alumni ID"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 400 {object} map[string]interface{} "ID tidak valid"
+// @Failure 404 {object} map[string]interface{} "Alumni tidak ditemukan"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /alumni/{id}/link [delete]
+// @Security BearerAuth
+func (s *AlumniClaimService) Unlink(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	alumni, err := s.AlumniRepo.GetByID(ctx, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if alumni == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Alumni tidak ditemukan"})
+	}
+
+	if err := s.AlumniRepo.UnlinkUser(ctx, id); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"success": true, "message": "Tautan user ke data alumni berhasil dilepas"})
+}
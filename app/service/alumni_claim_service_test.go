@@ -0,0 +1,184 @@
+package service
+
+import (
+	"encoding/json"
+	"gofiber-mongo/app/model"
+	"gofiber-mongo/app/repository"
+	"gofiber-mongo/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func newClaimTestApp(mt *mtest.T, userID primitive.ObjectID) *fiber.App {
+	svc := NewAlumniClaimService(repository.NewAlumniClaimRepository(mt.DB), repository.NewAlumniRepository(mt.DB),
+		repository.NewUserRepository(mt.DB), repository.NewLoginThrottleRepository(mt.DB), nil)
+	app := fiber.New()
+	api := app.Group("/api", func(c *fiber.Ctx) error {
+		c.Locals("user_id", userID)
+		return c.Next()
+	})
+	api.Post("/me/alumni/claim", svc.Create)
+	api.Post("/me/alumni/claim/:id/verify", svc.Verify)
+	api.Get("/me/alumni/claims", svc.GetMine)
+	return app
+}
+
+func postClaim(mt *mtest.T, app *fiber.App) *http.Response {
+	req := httptest.NewRequest(http.MethodPost, "/api/me/alumni/claim", strings.NewReader(`{"nim":"123456","tahun_lulus":2022}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		mt.Fatal(err)
+	}
+	return resp
+}
+
+func TestAlumniClaimCreate(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	userID := primitive.NewObjectID()
+
+	mt.Run("NIM tidak cocok tetap 202 tanpa email", func(mt *mtest.T) {
+		app := newClaimTestApp(mt, userID)
+
+		throttle := func(key string) bson.D {
+			return bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: toDoc(mt, model.LoginThrottle{Key: key, Count: 1, LastAt: time.Now()})}}
+		}
+		mt.AddMockResponses(
+			emptyCursor("db.alumni"),          // akun belum ditautkan
+			emptyCursor("db.login_throttles"), // user tidak dikunci
+			emptyCursor("db.login_throttles"), // IP tidak dikunci
+			throttle("claim:user:"+userID.Hex()),
+			throttle("claim:ip:0.0.0.0"),
+			emptyCursor("db.alumni"),      // NIM tidak ada
+			mtest.CreateSuccessResponse(), // kode klaim lama di-expire
+			mtest.CreateSuccessResponse(), // kode samaran klaim dispute dibatalkan
+			mtest.CreateSuccessResponse(), // klaim disimpan
+		)
+		resp := postClaim(mt, app)
+		if resp.StatusCode != http.StatusAccepted {
+			mt.Fatalf("status %d, want 202", resp.StatusCode)
+		}
+		var body map[string]any
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			mt.Fatal(err)
+		}
+		if data, _ := body["data"].(map[string]any); len(data) != 1 || data["id"] == nil {
+			mt.Fatalf("data = %v, want hanya id", body["data"])
+		}
+		if strings.Contains(body["message"].(string), "@") {
+			mt.Fatalf("message membocorkan email: %q", body["message"])
+		}
+	})
+
+	mt.Run("user dikunci 429", func(mt *mtest.T) {
+		app := newClaimTestApp(mt, userID)
+
+		until := time.Now().Add(time.Hour)
+		locked := toDoc(mt, model.LoginThrottle{Key: "claim:user:" + userID.Hex(), Count: 5, LastAt: time.Now(), LockedUntil: &until})
+		mt.AddMockResponses(
+			emptyCursor("db.alumni"),
+			mtest.CreateCursorResponse(0, "db.login_throttles", mtest.FirstBatch, locked),
+		)
+		resp := postClaim(mt, app)
+		if resp.StatusCode != http.StatusTooManyRequests {
+			mt.Fatalf("status %d, want 429", resp.StatusCode)
+		}
+		if resp.Header.Get(fiber.HeaderRetryAfter) == "" {
+			mt.Fatal("Retry-After kosong")
+		}
+	})
+}
+
+// Klaim yang cocok tetapi masuk antrean admin harus terlihat sama dengan klaim NIM yang tidak cocok.
+func TestAlumniClaimDisputeLooksPending(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	userID := primitive.NewObjectID()
+	expiresAt := time.Now().Add(15 * time.Minute)
+
+	unmatched := model.AlumniClaim{
+		ID:            primitive.NewObjectID(),
+		UserID:        userID,
+		NIM:           "999999",
+		Status:        model.ClaimStatusPendingCode,
+		CodeHash:      utils.HashToken("tidak-pernah-dikirim"),
+		CodeExpiresAt: &expiresAt,
+	}
+	disputed := model.AlumniClaim{
+		ID:            primitive.NewObjectID(),
+		UserID:        userID,
+		AlumniID:      primitive.NewObjectID(),
+		NIM:           "123456",
+		Status:        model.ClaimStatusPendingReview,
+		DisputeReason: "already_linked",
+		CodeHash:      utils.HashToken("tidak-pernah-dikirim"),
+		CodeExpiresAt: &expiresAt,
+	}
+
+	mt.Run("daftar klaim", func(mt *mtest.T) {
+		app := newClaimTestApp(mt, userID)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.alumni_claims", mtest.FirstBatch, toDoc(mt, disputed), toDoc(mt, unmatched)))
+
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/me/alumni/claims", nil), -1)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		var body struct {
+			Data []map[string]any `json:"data"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			mt.Fatal(err)
+		}
+		if len(body.Data) != 2 {
+			mt.Fatalf("data = %v", body.Data)
+		}
+		for _, field := range []string{"status", "alumni_id", "dispute_reason"} {
+			if body.Data[0][field] != body.Data[1][field] {
+				mt.Fatalf("%s berbeda: %v vs %v", field, body.Data[0][field], body.Data[1][field])
+			}
+		}
+		if body.Data[0]["status"] != model.ClaimStatusPendingCode || body.Data[0]["code_expires_at"] == nil {
+			mt.Fatalf("klaim dispute = %v, want pending_code dengan code_expires_at", body.Data[0])
+		}
+	})
+
+	verify := func(mt *mtest.T, claim model.AlumniClaim) (int, string) {
+		app := newClaimTestApp(mt, userID)
+		attempted := claim
+		attempted.Attempts = 1
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.alumni_claims", mtest.FirstBatch, toDoc(mt, claim)),
+			bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: toDoc(mt, attempted)}},
+		)
+		req := httptest.NewRequest(http.MethodPost, "/api/me/alumni/claim/"+claim.ID.Hex()+"/verify", strings.NewReader(`{"code":"123456"}`))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		var body map[string]any
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			mt.Fatal(err)
+		}
+		message, _ := body["error"].(string)
+		return resp.StatusCode, message
+	}
+
+	mt.Run("verifikasi NIM tidak cocok", func(mt *mtest.T) {
+		if status, message := verify(mt, unmatched); status != http.StatusBadRequest || message != "Kode tidak valid" {
+			mt.Fatalf("verify = %d %q", status, message)
+		}
+	})
+	mt.Run("verifikasi klaim dispute", func(mt *mtest.T) {
+		if status, message := verify(mt, disputed); status != http.StatusBadRequest || message != "Kode tidak valid" {
+			mt.Fatalf("verify = %d %q", status, message)
+		}
+	})
+}
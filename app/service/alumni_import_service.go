@@ -0,0 +1,526 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"gofiber-mongo/app/model"
+	"gofiber-mongo/app/repository"
+	"gofiber-mongo/utils"
+	"io"
+	"math"
+	"mime/multipart"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/xuri/excelize/v2"
+)
+
+const importMaxFileSize = 5 * 1024 * 1024 // 5MB
+
+// importAliases adalah nama kolom lain (setelah dinormalisasi) yang dikenali tanpa mapping.
+var importAliases = map[string]string{
+	"nama_lengkap":    "nama",
+	"name":            "nama",
+	"program_studi":   "jurusan",
+	"prodi":           "jurusan",
+	"tahun_masuk":     "angkatan",
+	"tahun_kelulusan": "tahun_lulus",
+	"lulus":           "tahun_lulus",
+	"e_mail":          "email",
+	"telepon":         "no_telepon",
+	"telp":            "no_telepon",
+	"no_telp":         "no_telepon",
+	"no_hp":           "no_telepon",
+	"hp":              "no_telepon",
+}
+
+// importRecord adalah satu baris file beserta nomor barisnya (header = baris 1).
+type importRecord struct {
+	Line  int
+	Cells []string
+}
+
+// normalizeHeader menyamakan penulisan nama kolom: "No. Telepon" -> "no_telepon".
+func normalizeHeader(h string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(strings.TrimSpace(h)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			underscore = false
+		} else if !underscore && b.Len() > 0 {
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
+
+// importFormat menentukan format file dari ekstensinya.
+func importFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return "csv"
+	case ".xlsx":
+		return "xlsx"
+	}
+	return ""
+}
+
+func readImportFile(fileHeader *multipart.FileHeader, format, sheet, delimiter string) ([]importRecord, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if format == "xlsx" {
+		return readImportXLSX(file, sheet)
+	}
+	return readImportCSV(file, delimiter)
+}
+
+// readImportCSV membaca CSV. Jika delimiter kosong, dipilih yang paling banyak muncul di
+// baris header antara koma, titik koma (default Excel berbahasa Indonesia) dan tab.
+func readImportCSV(r io.Reader, delimiter string) ([]importRecord, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	comma := ','
+	if delimiter == "" {
+		firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+		best := 0
+		for _, candidate := range []rune{',', ';', '\t'} {
+			if n := bytes.Count(firstLine, []byte(string(candidate))); n > best {
+				comma, best = candidate, n
+			}
+		}
+	} else if delimiter == "\\t" || delimiter == "tab" {
+		comma = '\t'
+	} else {
+		comma = []rune(delimiter)[0]
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var records []importRecord
+	for {
+		cells, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, importRecord{Line: line, Cells: cells})
+	}
+	return records, nil
+}
+
+// readImportXLSX membaca sheet yang diminta (default sheet pertama). Nilai sel diambil mentah
+// agar NIM dan tahun berformat angka tidak berubah menjadi notasi ilmiah atau berpemisah ribuan.
+func readImportXLSX(r io.Reader, sheet string) ([]importRecord, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if sheet == "" {
+		sheet = f.GetSheetName(0)
+	}
+	if idx, err := f.GetSheetIndex(sheet); err != nil || idx < 0 {
+		return nil, fmt.Errorf("sheet %q tidak ditemukan", sheet)
+	}
+
+	rows, err := f.GetRows(sheet, excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, err
+	}
+	records := make([]importRecord, 0, len(rows))
+	for i, cells := range rows {
+		records = append(records, importRecord{Line: i + 1, Cells: cells})
+	}
+	return records, nil
+}
+
+func isBlankRecord(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveImportColumns menentukan kolom untuk tiap field. Mapping dari request (field -> nama
+// kolom) diutamakan; field lain dicocokkan dengan nama kolom yang sama atau alias.
+func resolveImportColumns(header []string, mapping map[string]string) (map[string]int, []model.FieldError) {
+	index := map[string]int{}
+	for i, h := range header {
+		name := normalizeHeader(h)
+		if _, exists := index[name]; name != "" && !exists {
+			index[name] = i
+		}
+	}
+
+	known := map[string]bool{}
+	for _, field := range model.ImportFields {
+		known[field] = true
+	}
+
+	columns := map[string]int{}
+	errs := []model.FieldError{}
+	for field, column := range mapping {
+		if !known[field] {
+			errs = append(errs, model.FieldError{
+				Field:   "mapping." + field,
+				Code:    "unknown_field",
+				Message: "Field tidak dikenal, gunakan salah satu dari: " + strings.Join(model.ImportFields, ", "),
+			})
+			continue
+		}
+		i, ok := index[normalizeHeader(column)]
+		if !ok {
+			errs = append(errs, model.FieldError{
+				Field:   "mapping." + field,
+				Code:    "column_not_found",
+				Message: fmt.Sprintf("Kolom %q tidak ada di file", column),
+			})
+			continue
+		}
+		columns[field] = i
+	}
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+		return nil, errs
+	}
+
+	for _, field := range model.ImportFields {
+		if _, ok := columns[field]; ok {
+			continue
+		}
+		if i, ok := index[field]; ok {
+			columns[field] = i
+		}
+	}
+	// Alias dicocokkan urut kolom agar kolom paling kiri yang dipakai
+	for i, h := range header {
+		field, ok := importAliases[normalizeHeader(h)]
+		if _, mapped := columns[field]; ok && !mapped {
+			columns[field] = i
+		}
+	}
+	return columns, nil
+}
+
+// parseImportInt menerima angka bulat, termasuk nilai seperti "2020.0" dari spreadsheet.
+func parseImportInt(value string) (int, bool) {
+	if value == "" {
+		return 0, true
+	}
+	if n, err := strconv.Atoi(value); err == nil {
+		return n, true
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f != math.Trunc(f) {
+		return 0, false
+	}
+	return int(f), true
+}
+
+// importRequest menyusun dan memvalidasi satu baris dengan aturan yang sama seperti POST /api/alumni.
+func importRequest(cells []string, columns map[string]int) (model.CreateAlumniRequest, []model.FieldError) {
+	cell := func(field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(cells) {
+			return ""
+		}
+		return strings.TrimSpace(cells[i])
+	}
+
+	req := model.CreateAlumniRequest{
+		NIM:       cell("nim"),
+		Nama:      cell("nama"),
+		Jurusan:   cell("jurusan"),
+		Email:     cell("email"),
+		NoTelepon: cell("no_telepon"),
+		Alamat:    cell("alamat"),
+	}
+
+	parseErrs := map[string]model.FieldError{}
+	for field, target := range map[string]*int{"angkatan": &req.Angkatan, "tahun_lulus": &req.TahunLulus} {
+		n, ok := parseImportInt(cell(field))
+		if !ok {
+			parseErrs[field] = model.FieldError{Field: field, Code: "invalid_number", Message: field + " harus berupa angka"}
+			continue
+		}
+		*target = n
+	}
+
+	errs := []model.FieldError{}
+	for _, field := range []string{"angkatan", "tahun_lulus"} {
+		if fe, ok := parseErrs[field]; ok {
+			errs = append(errs, fe)
+		}
+	}
+	for _, fe := range utils.ValidateStruct(req) {
+		// Nilai yang gagal di-parse sudah dilaporkan di atas
+		if _, ok := parseErrs[fe.Field]; !ok {
+			errs = append(errs, fe)
+		}
+	}
+	return req, errs
+}
+
+// importCandidate adalah baris valid yang akan ditulis; result menunjuk ke hasil barisnya.
+type importCandidate struct {
+	req    model.CreateAlumniRequest
+	result *model.ImportRowResult
+}
+
+// HandleImport godoc
+// @Summary Import alumni dari CSV / XLSX
+// @Description Import data alumni secara massal. Mode dry_run (default) hanya memvalidasi setiap baris dan mendeteksi NIM ganda di file maupun di database tanpa menulis data. Mode execute menulis baris yang valid secara bertahap (upsert berdasarkan NIM) dan mengembalikan ringkasan. Kolom dicocokkan berdasarkan nama header; gunakan mapping untuk header yang berbeda.
+// @Tags Alumni
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "File CSV atau XLSX (maksimal 5MB), baris pertama berisi header"
+// @Param mode formData string false "dry_run atau execute" default(dry_run)
+// @Param on_duplicate formData string false "update atau skip untuk NIM yang sudah terdaftar" default(update)
+// @Param mapping formData string false "Objek JSON field -> nama kolom, mis. {\"nim\":\"No Induk\",\"nama\":\"Nama Lengkap\"}"
+// @Param sheet formData string false "Nama sheet XLSX (default sheet pertama)"
+// @Param delimiter formData string false "Pemisah kolom CSV (default dideteksi dari header)"
+// @Success 200 {object} model.ImportReport "import report"
+// @Failure 400 {object} map[string]interface{} "File atau parameter tidak valid"
+// @Failure 422 {object} model.ValidationErrorResponse "Mapping kolom tidak valid"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /alumni/import [post]
+// @Security BearerAuth
+func (s *AlumniService) Import(c *fiber.Ctx) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "File tidak ditemukan"})
+	}
+	if fileHeader.Size > importMaxFileSize {
+		return c.Status(400).JSON(fiber.Map{"error": "Ukuran file import tidak boleh lebih dari 5MB"})
+	}
+	format := importFormat(fileHeader.Filename)
+	if format == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Format file hanya boleh CSV atau XLSX"})
+	}
+
+	mode := c.FormValue("mode", model.ImportModeDryRun)
+	if mode != model.ImportModeDryRun && mode != model.ImportModeExecute {
+		return c.Status(400).JSON(fiber.Map{"error": "mode harus dry_run atau execute"})
+	}
+	onDuplicate := c.FormValue("on_duplicate", model.ImportOnDuplicateUpdate)
+	if onDuplicate != model.ImportOnDuplicateUpdate && onDuplicate != model.ImportOnDuplicateSkip {
+		return c.Status(400).JSON(fiber.Map{"error": "on_duplicate harus update atau skip"})
+	}
+	mapping := map[string]string{}
+	if v := c.FormValue("mapping"); v != "" {
+		if err := json.Unmarshal([]byte(v), &mapping); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "mapping harus berupa objek JSON field -> nama kolom"})
+		}
+	}
+
+	records, err := readImportFile(fileHeader, format, c.FormValue("sheet"), c.FormValue("delimiter"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "File tidak dapat dibaca: " + err.Error()})
+	}
+
+	var header []string
+	var rows []importRecord
+	for _, record := range records {
+		if isBlankRecord(record.Cells) {
+			continue
+		}
+		if header == nil {
+			header = record.Cells
+			continue
+		}
+		rows = append(rows, record)
+	}
+	if header == nil {
+		return c.Status(400).JSON(fiber.Map{"error": "File kosong"})
+	}
+	if maxRows := utils.IntFromEnv("IMPORT_MAX_ROWS", 5000); len(rows) > maxRows {
+		return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("Maksimal %d baris per import", maxRows)})
+	}
+
+	columns, errs := resolveImportColumns(header, mapping)
+	if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	report := model.ImportReport{
+		Mode:        mode,
+		Format:      format,
+		OnDuplicate: onDuplicate,
+		Columns:     map[string]string{},
+		TotalRows:   len(rows),
+	}
+	mappedFields := make([]string, 0, len(columns))
+	for _, field := range model.ImportFields {
+		if i, ok := columns[field]; ok {
+			report.Columns[field] = strings.TrimSpace(header[i])
+			mappedFields = append(mappedFields, field)
+		}
+	}
+
+	// Tahap 1: validasi per baris dan duplikat di dalam file
+	results := make([]model.ImportRowResult, len(rows))
+	var candidates []importCandidate
+	firstNIM := map[string]int{}
+	firstEmail := map[string]int{}
+	for i, record := range rows {
+		req, errs := importRequest(record.Cells, columns)
+		results[i] = model.ImportRowResult{Row: record.Line, NIM: req.NIM}
+
+		if line, dup := firstNIM[req.NIM]; req.NIM != "" && dup {
+			results[i].Duplicate = "file"
+			errs = append(errs, model.FieldError{Field: "nim", Code: "duplicate_in_file", Message: fmt.Sprintf("NIM sama dengan baris %d", line)})
+		} else if req.NIM != "" {
+			firstNIM[req.NIM] = record.Line
+		}
+		if line, dup := firstEmail[req.Email]; req.Email != "" && dup {
+			errs = append(errs, model.FieldError{Field: "email", Code: "duplicate_in_file", Message: fmt.Sprintf("Email sama dengan baris %d", line)})
+		} else if req.Email != "" {
+			firstEmail[req.Email] = record.Line
+		}
+
+		if len(errs) > 0 {
+			results[i].Action = model.ImportActionInvalid
+			results[i].Errors = errs
+			continue
+		}
+		candidates = append(candidates, importCandidate{req: req, result: &results[i]})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), utils.DurationFromEnv("IMPORT_TIMEOUT", 2*time.Minute))
+	defer cancel()
+
+	// Tahap 2: duplikat terhadap alumni aktif di database
+	nims := make([]string, 0, len(candidates))
+	emails := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		nims = append(nims, candidate.req.NIM)
+		emails = append(emails, candidate.req.Email)
+	}
+	existingByNIM, err := s.Repo.GetActiveByNIMs(ctx, nims)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	existingByEmail, err := s.Repo.GetActiveByEmails(ctx, emails)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	registered := map[string]bool{}
+	for _, alumni := range existingByNIM {
+		registered[alumni.NIM] = true
+	}
+	emailOwner := map[string]string{}
+	for _, alumni := range existingByEmail {
+		emailOwner[alumni.Email] = alumni.NIM
+	}
+
+	var toWrite []importCandidate
+	for _, candidate := range candidates {
+		result := candidate.result
+		if owner, ok := emailOwner[candidate.req.Email]; ok && owner != candidate.req.NIM {
+			result.Action = model.ImportActionInvalid
+			result.Errors = []model.FieldError{{Field: "email", Code: "duplicate", Message: "Email sudah terdaftar untuk NIM " + owner}}
+			continue
+		}
+
+		report.Valid++
+		switch {
+		case !registered[candidate.req.NIM]:
+			result.Action = model.ImportActionCreate
+			report.Created++
+		case onDuplicate == model.ImportOnDuplicateSkip:
+			result.Action = model.ImportActionSkip
+			result.Duplicate = "database"
+			report.Skipped++
+			continue
+		default:
+			result.Action = model.ImportActionUpdate
+			result.Duplicate = "database"
+			report.Updated++
+		}
+		toWrite = append(toWrite, candidate)
+	}
+	report.Invalid = report.TotalRows - report.Valid
+
+	// Tahap 3: tulis per batch. Pada dry_run, Created / Updated adalah perkiraan.
+	if mode == model.ImportModeExecute {
+		report.Created, report.Updated = 0, 0
+		batchSize := utils.IntFromEnv("IMPORT_BATCH_SIZE", 500)
+		if batchSize < 1 {
+			batchSize = 500
+		}
+		for start := 0; start < len(toWrite); start += batchSize {
+			batch := toWrite[start:min(start+batchSize, len(toWrite))]
+			reqs := make([]model.CreateAlumniRequest, len(batch))
+			for i, candidate := range batch {
+				reqs[i] = candidate.req
+			}
+
+			res, err := s.Repo.UpsertByNIM(ctx, reqs, mappedFields)
+			report.Created += res.Created
+			report.Updated += res.Updated
+			if err != nil {
+				// Batch sebelumnya sudah tersimpan; laporan dikirim agar import bisa dilanjutkan
+				report.Rows = importReportRows(results)
+				return c.Status(500).JSON(fiber.Map{"error": err.Error(), "data": report})
+			}
+			for i, writeErr := range res.Failed {
+				batch[i].result.Action = model.ImportActionFailed
+				batch[i].result.Errors = []model.FieldError{importWriteError(writeErr)}
+				report.Failed++
+			}
+		}
+	}
+
+	report.Rows = importReportRows(results)
+	return c.JSON(fiber.Map{"success": true, "data": report})
+}
+
+// importWriteError menerjemahkan error penulisan satu baris menjadi FieldError.
+func importWriteError(err error) model.FieldError {
+	if field, ok := repository.DuplicateKeyField(err); ok {
+		message, known := duplicateMessages[field]
+		if !known {
+			message = "Data sudah ada"
+		}
+		return model.FieldError{Field: field, Code: "duplicate", Message: message}
+	}
+	return model.FieldError{Code: "write_failed", Message: err.Error()}
+}
+
+// importReportRows menyaring hasil yang perlu ditinjau: baris tidak valid, duplikat dan gagal.
+func importReportRows(results []model.ImportRowResult) []model.ImportRowResult {
+	rows := []model.ImportRowResult{}
+	for _, result := range results {
+		if result.Action != model.ImportActionCreate || result.Duplicate != "" || len(result.Errors) > 0 {
+			rows = append(rows, result)
+		}
+	}
+	return rows
+}
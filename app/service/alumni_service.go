@@ -4,6 +4,7 @@ import (
 	"context"
 	"gofiber-mongo/app/model"
 	"gofiber-mongo/app/repository"
+	"gofiber-mongo/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"strconv"
 	"strings"
@@ -29,8 +30,12 @@ func NewAlumniService(repo *repository.AlumniRepository) *AlumniService {
 // @Accept json
 // @Produce json
 // @Param id path string true "Alumni ID"
+// @Param If-Match header string false "ETag dari GET /alumni/{id} (wajib jika REQUIRE_IF_MATCH=true)"
 // @Success 200 {object} map[string]interface{} "success response"
 // @Failure 400 {object} map[string]interface{} "ID tidak valid"
+// @Failure 404 {object} map[string]interface{} "Alumni tidak ditemukan"
+// @Failure 412 {object} map[string]interface{} "Data sudah diubah pihak lain"
+// @Failure 428 {object} map[string]interface{} "If-Match wajib diisi"
 // @Failure 500 {object} map[string]interface{} "error"
 // @Router /alumni/{id} [delete]
 // @Security BearerAuth
@@ -44,10 +49,21 @@ func (s *AlumniService) SoftDelete(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	err = s.Repo.SoftDelete(ctx, id)
+	alumni, err := s.Repo.GetByID(ctx, id)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
+	if alumni == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Alumni tidak ditemukan"})
+	}
+	if ok, err := checkIfMatch(c, alumni.Version); !ok {
+		return err
+	}
+
+	err = s.Repo.SoftDelete(ctx, id, &alumni.Version)
+	if err != nil {
+		return storeFailed(c, err)
+	}
 	return c.JSON(fiber.Map{"success": true, "message": "Alumni + riwayat pekerjaan berhasil dihapus (soft delete)"})
 }
 
@@ -123,7 +139,7 @@ func (s *AlumniService) GetAll(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param id path string true "Alumni ID"
-// @Success 200 {object} map[string]interface{} "alumni data"
+// @Success 200 {object} map[string]interface{} "alumni data, versi dikirim sebagai header ETag"
 // @Failure 400 {object} map[string]interface{} "ID tidak valid"
 // @Failure 404 {object} map[string]interface{} "Alumni tidak ditemukan"
 // @Failure 500 {object} map[string]interface{} "error"
@@ -146,6 +162,7 @@ func (s *AlumniService) GetByID(c *fiber.Ctx) error {
 	if alumni == nil {
 		return c.Status(404).JSON(fiber.Map{"error": "Alumni tidak ditemukan"})
 	}
+	setETag(c, alumni.Version)
 	return c.JSON(fiber.Map{"success": true, "data": alumni})
 }
 
@@ -158,6 +175,8 @@ func (s *AlumniService) GetByID(c *fiber.Ctx) error {
 // @Param body body model.CreateAlumniRequest true "Alumni data"
 // @Success 201 {object} map[string]interface{} "created alumni"
 // @Failure 400 {object} map[string]interface{} "Request tidak valid"
+// @Failure 409 {object} map[string]interface{} "NIM atau email sudah terdaftar"
+// @Failure 422 {object} model.ValidationErrorResponse "Validasi gagal"
 // @Failure 500 {object} map[string]interface{} "error"
 // @Router /alumni [post]
 // @Security BearerAuth
@@ -166,13 +185,16 @@ func (s *AlumniService) Create(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
 	}
+	if errs := utils.ValidateStruct(req); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	newAlumni, err := s.Repo.Create(ctx, req)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return storeFailed(c, err)
 	}
 	return c.Status(201).JSON(fiber.Map{"success": true, "data": newAlumni})
 }
@@ -184,9 +206,15 @@ func (s *AlumniService) Create(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param id path string true "Alumni ID"
+// @Param If-Match header string false "ETag dari GET /alumni/{id} (wajib jika REQUIRE_IF_MATCH=true)"
 // @Param body body model.UpdateAlumniRequest true "Alumni data"
 // @Success 200 {object} map[string]interface{} "updated alumni"
 // @Failure 400 {object} map[string]interface{} "ID tidak valid"
+// @Failure 404 {object} map[string]interface{} "Alumni tidak ditemukan"
+// @Failure 409 {object} map[string]interface{} "NIM atau email sudah terdaftar"
+// @Failure 412 {object} map[string]interface{} "Data sudah diubah pihak lain"
+// @Failure 422 {object} model.ValidationErrorResponse "Validasi gagal"
+// @Failure 428 {object} map[string]interface{} "If-Match wajib diisi"
 // @Failure 500 {object} map[string]interface{} "error"
 // @Router /alumni/{id} [put]
 // @Security BearerAuth
@@ -201,14 +229,84 @@ func (s *AlumniService) Update(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
 	}
+	if errs := utils.ValidateStruct(req); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	updated, err := s.Repo.Update(ctx, id, req)
+	current, err := s.Repo.GetByID(ctx, id)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
+	if current == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Alumni tidak ditemukan"})
+	}
+	if ok, err := checkIfMatch(c, current.Version); !ok {
+		return err
+	}
+
+	updated, err := s.Repo.Update(ctx, id, &current.Version, req)
+	if err != nil {
+		return storeFailed(c, err)
+	}
+	setETag(c, updated.Version)
+	return c.JSON(fiber.Map{"success": true, "data": updated})
+}
+
+// HandlePatch godoc
+// @Summary Patch alumni
+// @Description Memperbarui sebagian data alumni dengan JSON Merge Patch (RFC 7396). Field yang tidak dikirim tetap, null menghapus field (field wajib akan gagal validasi). Hasil gabungan divalidasi dengan aturan yang sama seperti PUT dan hanya field yang berubah yang ditulis.
+// @Tags Alumni
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path string true "Alumni ID"
+// @Param If-Match header string false "ETag dari GET /alumni/{id} (wajib jika REQUIRE_IF_MATCH=true)"
+// @Param body body model.UpdateAlumniRequest true "Field alumni yang diubah"
+// @Success 200 {object} map[string]interface{} "updated alumni"
+// @Failure 400 {object} map[string]interface{} "ID atau request tidak valid"
+// @Failure 404 {object} map[string]interface{} "Alumni tidak ditemukan"
+// @Failure 409 {object} map[string]interface{} "Email sudah terdaftar"
+// @Failure 412 {object} map[string]interface{} "Data sudah diubah pihak lain"
+// @Failure 415 {object} map[string]interface{} "Content-Type tidak didukung"
+// @Failure 422 {object} model.ValidationErrorResponse "Validasi gagal"
+// @Failure 428 {object} map[string]interface{} "If-Match wajib diisi"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /alumni/{id} [patch]
+// @Security BearerAuth
+func (s *AlumniService) Patch(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	alumni, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if alumni == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Alumni tidak ditemukan"})
+	}
+	if ok, err := checkIfMatch(c, alumni.Version); !ok {
+		return err
+	}
+
+	current := alumniUpdateRequest(alumni)
+	var req model.UpdateAlumniRequest
+	if ok, err := mergePatchRequest(c, current, &req); !ok {
+		return err
+	}
+
+	updated, err := s.Repo.Patch(ctx, id, alumni.Version, current, req)
+	if err != nil {
+		return storeFailed(c, err)
+	}
+	setETag(c, updated.Version)
 	return c.JSON(fiber.Map{"success": true, "data": updated})
 }
 
@@ -219,8 +317,12 @@ func (s *AlumniService) Update(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param id path string true "Alumni ID"
+// @Param If-Match header string false "ETag dari GET /alumni/{id} (wajib jika REQUIRE_IF_MATCH=true)"
 // @Success 200 {object} map[string]interface{} "success response"
 // @Failure 400 {object} map[string]interface{} "ID tidak valid"
+// @Failure 404 {object} map[string]interface{} "Alumni tidak ditemukan"
+// @Failure 412 {object} map[string]interface{} "Data sudah diubah pihak lain"
+// @Failure 428 {object} map[string]interface{} "If-Match wajib diisi"
 // @Failure 500 {object} map[string]interface{} "error"
 // @Router /alumni/{id} [delete]
 // @Security BearerAuth
@@ -234,9 +336,20 @@ func (s *AlumniService) Delete(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := s.Repo.Delete(ctx, id); err != nil {
+	alumni, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
+	if alumni == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Alumni tidak ditemukan"})
+	}
+	if ok, err := checkIfMatch(c, alumni.Version); !ok {
+		return err
+	}
+
+	if err := s.Repo.Delete(ctx, id, &alumni.Version); err != nil {
+		return storeFailed(c, err)
+	}
 	return c.JSON(fiber.Map{"success": true, "message": "Alumni berhasil dihapus"})
 }
 
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"gofiber-mongo/app/model"
+	"gofiber-mongo/app/repository"
+	"gofiber-mongo/middleware"
+	"gofiber-mongo/utils"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type APIKeyService struct {
+	Repo *repository.APIKeyRepository
+}
+
+func NewAPIKeyService(repo *repository.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{
+		Repo: repo,
+	}
+}
+
+// HandleCreate godoc
+// @Summary Create API key
+// @Description Membuat API key untuk akses mesin-ke-mesin. Key lengkap hanya ditampilkan sekali pada response ini.
+// @Description Permission key tidak boleh melebihi permission admin yang membuatnya.
+// @Tags API Keys
+// @Accept json
+// @Produce json
+// @Param body body model.CreateAPIKeyRequest true "API key data"
+// @Success 201 {object} model.CreateAPIKeyResponse
+// @Failure 400 {object} map[string]interface{} "Request tidak valid"
+// @Failure 403 {object} map[string]interface{} "Permission melebihi milik pembuat"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /api-keys [post]
+// @Security BearerAuth
+func (s *APIKeyService) Create(c *fiber.Ctx) error {
+	var req model.CreateAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "name tidak boleh kosong"})
+	}
+	if len(req.Permissions) == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "permissions tidak boleh kosong"})
+	}
+	if invalid := invalidPermissions(req.Permissions); len(invalid) > 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Permission tidak dikenal", "permissions": invalid})
+	}
+	for _, p := range req.Permissions {
+		if !middleware.HasPermission(c, p) {
+			return c.Status(403).JSON(fiber.Map{
+				"error":      "Tidak bisa memberikan permission yang tidak Anda miliki",
+				"permission": p,
+			})
+		}
+	}
+	if req.ExpiresInDays < 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "expires_in_days tidak boleh negatif"})
+	}
+
+	key, prefix, hash, err := utils.GenerateAPIKey(model.APIKeyPrefix)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	apiKey := model.APIKey{
+		Name:        req.Name,
+		Prefix:      prefix,
+		KeyHash:     hash,
+		Permissions: req.Permissions,
+		CreatedBy:   c.Locals("user_id").(primitive.ObjectID),
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		apiKey.ExpiresAt = &expiresAt
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.Repo.Create(ctx, &apiKey); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"data":    model.CreateAPIKeyResponse{APIKey: apiKey, Key: key},
+	})
+}
+
+// HandleGetAll godoc
+// @Summary Get all API keys
+// @Description Mengambil semua API key (tanpa secret) beserta waktu pemakaian terakhir
+// @Tags API Keys
+// @Produce json
+// @Success 200 {object} map[string]interface{} "API key list"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /api-keys [get]
+// @Security BearerAuth
+func (s *APIKeyService) GetAll(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	keys, err := s.Repo.GetAll(ctx)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if keys == nil {
+		keys = []model.APIKey{}
+	}
+	return c.JSON(fiber.Map{"success": true, "data": keys})
+}
+
+// HandleRevoke godoc
+// @Summary Revoke API key
+// @Description Mencabut API key sehingga tidak bisa dipakai lagi
+// @Tags API Keys
+// @Produce json
+// @Param id path string true "API key ID"
+// @Success 200 {object} map[string]interface{} "API key dicabut"
+// @Failure 400 {object} map[string]interface{} "ID tidak valid"
+// @Failure 404 {object} map[string]interface{} "API key tidak ditemukan"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /api-keys/{id} [delete]
+// @Security BearerAuth
+func (s *APIKeyService) Revoke(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	apiKey, err := s.Repo.FindByID(ctx, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if apiKey == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "API key tidak ditemukan"})
+	}
+
+	if err := s.Repo.Revoke(ctx, id); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"success": true, "message": "API key berhasil dicabut"})
+}
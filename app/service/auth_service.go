@@ -0,0 +1,1019 @@
+package service
+
+import (
+	"context"
+	"gofiber-mongo/app/model"
+	"gofiber-mongo/app/repository"
+	"gofiber-mongo/mailer"
+	"gofiber-mongo/utils"
+	"log"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type AuthService struct {
+	UserRepo         *repository.UserRepository
+	TokenRepo        *repository.TokenRepository
+	OneTimeTokenRepo *repository.OneTimeTokenRepository
+	ThrottleRepo     *repository.LoginThrottleRepository
+	SessionRepo      *repository.SessionRepository
+	AlumniRepo       *repository.AlumniRepository
+	EventRepo        *repository.SecurityEventRepository
+	Mailer           mailer.Mailer
+}
+
+func NewAuthService(db *mongo.Database, m mailer.Mailer) *AuthService {
+	return &AuthService{
+		UserRepo:         repository.NewUserRepository(db),
+		TokenRepo:        repository.NewTokenRepository(db),
+		OneTimeTokenRepo: repository.NewOneTimeTokenRepository(db),
+		ThrottleRepo:     repository.NewLoginThrottleRepository(db),
+		SessionRepo:      repository.NewSessionRepository(db),
+		AlumniRepo:       repository.NewAlumniRepository(db),
+		EventRepo:        repository.NewSecurityEventRepository(db),
+		Mailer:           m,
+	}
+}
+
+func (s *AuthService) recordEvent(c *fiber.Ctx, event *model.SecurityEvent) {
+	recordSecurityEvent(s.EventRepo, c, event)
+}
+
+// loginFailed mencatat login yang ditolak. user nil jika username tidak dikenal.
+func (s *AuthService) loginFailed(c *fiber.Ctx, eventType, outcome, reason, username string, user *model.User) {
+	if len(username) > 100 {
+		username = username[:100]
+	}
+	event := &model.SecurityEvent{Type: eventType, Outcome: outcome, Username: username, Reason: reason}
+	if user != nil {
+		event = userSecurityEvent(eventType, outcome, user)
+		event.Reason = reason
+	}
+	s.recordEvent(c, event)
+}
+
+// startSession mencatat login baru sebagai session lalu menerbitkan token untuk session tersebut.
+func (s *AuthService) startSession(ctx context.Context, c *fiber.Ctx, user *model.User) (*model.LoginResponse, error) {
+	session := &model.Session{
+		ID:        uuid.NewString(),
+		UserID:    user.ID,
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		IP:        c.IP(),
+		ExpiresAt: time.Now().Add(utils.RefreshTokenTTL()),
+	}
+	if err := s.SessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	resp, _, err := s.issueTokens(ctx, user, session.ID)
+	return resp, err
+}
+
+// issueTokens membuat access token baru dan refresh token dalam family yang diberikan.
+// FamilyID sekaligus menjadi ID session.
+func (s *AuthService) issueTokens(ctx context.Context, user *model.User, familyID string) (*model.LoginResponse, *model.RefreshToken, error) {
+	accessToken, err := utils.GenerateToken(user, familyID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plain, hash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refresh := &model.RefreshToken{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		TokenHash: hash,
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(utils.RefreshTokenTTL()),
+	}
+	if err := s.TokenRepo.CreateRefreshToken(ctx, refresh); err != nil {
+		return nil, nil, err
+	}
+
+	return &model.LoginResponse{
+		User:                   *user,
+		Token:                  accessToken,
+		RefreshToken:           plain,
+		ExpiresIn:              int64(utils.AccessTokenTTL().Seconds()),
+		TwoFactorSetupRequired: utils.RequiresTwoFactor(user.Role) && !user.TwoFactorEnabled,
+	}, refresh, nil
+}
+
+// HandleRegister godoc
+// @Summary Register user
+// @Description Mendaftarkan user baru dengan role user dan mengirim link verifikasi ke email
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.RegisterRequest true "Username, email dan password"
+// @Success 201 {object} map[string]interface{} "created user"
+// @Failure 400 {object} map[string]interface{} "Request tidak valid"
+// @Failure 403 {object} map[string]interface{} "Registrasi terbuka dinonaktifkan"
+// @Failure 422 {object} model.ValidationErrorResponse "Password tidak memenuhi kebijakan"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /register [post]
+func (s *AuthService) Register(c *fiber.Ctx) error {
+	// Jika dinonaktifkan, akun baru hanya bisa dibuat lewat undangan admin
+	if !utils.BoolFromEnv("OPEN_REGISTRATION", true) {
+		return c.Status(403).JSON(fiber.Map{"error": "Registrasi terbuka dinonaktifkan. Gunakan link undangan dari admin"})
+	}
+
+	var req model.RegisterRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
+	}
+
+	req.Email = strings.TrimSpace(req.Email)
+	if req.Username == "" || req.Email == "" || req.Password == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Username, email dan password harus diisi"})
+	}
+	if addr, err := mail.ParseAddress(req.Email); err != nil || addr.Address != req.Email {
+		return c.Status(400).JSON(fiber.Map{"error": "Format email tidak valid"})
+	}
+	if errs := utils.ValidatePassword("password", req.Password, req.Username, strings.SplitN(req.Email, "@", 2)[0]); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	existingUser, _ := s.UserRepo.FindByUsername(ctx, req.Username)
+	if existingUser != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Username sudah terdaftar"})
+	}
+
+	existingUser, _ = s.UserRepo.FindByEmail(ctx, req.Email)
+	if existingUser != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Email sudah terdaftar"})
+	}
+
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal hash password"})
+	}
+
+	newUser := &model.User{
+		Username:      req.Username,
+		Email:         req.Email,
+		EmailVerified: false,
+		Password:      hashedPassword,
+		Role:          "user",
+		IsDelete:      false,
+		CreatedAt:     time.Now(),
+	}
+
+	createdUser, err := s.UserRepo.Create(ctx, newUser)
+	if err != nil {
+		if _, dup := repository.DuplicateKeyField(err); dup {
+			return storeFailed(c, err)
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal membuat user"})
+	}
+
+	if err := s.sendVerificationEmail(ctx, createdUser); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "User berhasil dibuat. Silakan cek email untuk verifikasi",
+		"user": fiber.Map{
+			"id":             createdUser.ID,
+			"username":       createdUser.Username,
+			"email":          createdUser.Email,
+			"email_verified": createdUser.EmailVerified,
+			"role":           createdUser.Role,
+		},
+	})
+}
+
+// HandleLogin godoc
+// @Summary Login
+// @Description Login dengan username/email dan password, mengembalikan access token dan refresh token
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.LoginRequest true "Kredensial"
+// @Success 200 {object} model.LoginResponse "token, atau model.TwoFactorChallengeResponse jika 2FA aktif"
+// @Failure 400 {object} map[string]interface{} "Request tidak valid"
+// @Failure 401 {object} map[string]interface{} "Username atau password salah"
+// @Failure 403 {object} map[string]interface{} "Email belum diverifikasi"
+// @Failure 429 {object} map[string]interface{} "Terlalu banyak percobaan login gagal dari IP ini"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /login [post]
+func (s *AuthService) Login(c *fiber.Ctx) error {
+	var req model.LoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	policy := loadLockoutPolicy()
+	ipKey := "ip:" + c.IP()
+
+	ipThrottle, err := s.ThrottleRepo.Get(ctx, ipKey)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if ipThrottle != nil && ipThrottle.LockedUntil != nil && time.Now().Before(*ipThrottle.LockedUntil) {
+		s.loginFailed(c, model.SecurityEventLogin, model.SecurityOutcomeBlocked, "ip_locked", req.Username, nil)
+		return tooManyAttempts(c, *ipThrottle.LockedUntil)
+	}
+
+	user, err := s.UserRepo.FindByUsername(ctx, req.Username)
+	if err != nil || user == nil {
+		user, err = s.UserRepo.FindByEmail(ctx, req.Username)
+	}
+
+	// User tidak ada / sudah dihapus diperlakukan sama dengan password salah,
+	// termasuk biaya hash-nya, supaya keberadaan username tidak bisa ditebak.
+	if err != nil || user == nil || user.IsDelete {
+		utils.CheckPasswordDummy(req.Password)
+		if err := s.recordIPFailure(ctx, ipKey, policy); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		s.loginFailed(c, model.SecurityEventLogin, model.SecurityOutcomeFailure, "unknown_user", req.Username, nil)
+		return c.Status(401).JSON(fiber.Map{"error": "Username atau password salah"})
+	}
+
+	// Akun yang terkunci dijawab sama persis dengan password salah (kunci hanya dicatat di
+	// security event), agar 429 tidak membocorkan bahwa username tersebut terdaftar.
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		utils.CheckPasswordDummy(req.Password)
+		if err := s.recordIPFailure(ctx, ipKey, policy); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		s.loginFailed(c, model.SecurityEventLogin, model.SecurityOutcomeBlocked, "account_locked", req.Username, user)
+		return c.Status(401).JSON(fiber.Map{"error": "Username atau password salah"})
+	}
+
+	passwordOK, needsRehash := utils.VerifyPassword(req.Password, user.Password)
+	if !passwordOK {
+		if err := s.recordUserFailure(ctx, user, policy); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err := s.recordIPFailure(ctx, ipKey, policy); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		s.loginFailed(c, model.SecurityEventLogin, model.SecurityOutcomeFailure, "invalid_password", req.Username, user)
+		return c.Status(401).JSON(fiber.Map{"error": "Username atau password salah"})
+	}
+
+	if user.FailedLoginAttempts > 0 || user.LockedUntil != nil {
+		if err := s.UserRepo.ResetFailedLogins(ctx, user.ID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	// Hash lama (bcrypt / parameter argon2id usang) di-upgrade selagi password asli tersedia
+	if needsRehash {
+		if newHash, err := utils.HashPassword(req.Password); err != nil {
+			log.Printf("Gagal rehash password user %s: %v", user.ID.Hex(), err)
+		} else if err := s.UserRepo.UpdatePassword(ctx, user.ID, newHash); err != nil {
+			log.Printf("Gagal menyimpan rehash password user %s: %v", user.ID.Hex(), err)
+		}
+	}
+
+	if !user.EmailVerified && utils.BoolFromEnv("REQUIRE_EMAIL_VERIFICATION", true) {
+		s.loginFailed(c, model.SecurityEventLogin, model.SecurityOutcomeBlocked, "email_not_verified", req.Username, user)
+		return c.Status(403).JSON(fiber.Map{
+			"error": "Email belum diverifikasi. Silakan cek email atau minta link verifikasi baru",
+			"code":  "email_not_verified",
+		})
+	}
+
+	// Password benar tapi 2FA aktif: token baru diberikan setelah /login/2fa
+	if user.TwoFactorEnabled {
+		return s.twoFactorChallenge(ctx, c, user)
+	}
+
+	resp, err := s.startSession(ctx, c, user)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal generate token"})
+	}
+
+	s.recordEvent(c, userSecurityEvent(model.SecurityEventLogin, model.SecurityOutcomeSuccess, user))
+	return c.JSON(resp)
+}
+
+// twoFactorChallenge membuat challenge token untuk langkah kedua login (/login/2fa).
+func (s *AuthService) twoFactorChallenge(ctx context.Context, c *fiber.Ctx, user *model.User) error {
+	plain, hash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal generate token"})
+	}
+	ttl := utils.DurationFromEnv("TWO_FACTOR_CHALLENGE_TTL", 5*time.Minute)
+	err = s.OneTimeTokenRepo.Create(ctx, &model.OneTimeToken{
+		UserID:    user.ID,
+		Purpose:   model.TokenPurposeTwoFactorLogin,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(model.TwoFactorChallengeResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    plain,
+		ExpiresIn:         int64(ttl.Seconds()),
+	})
+}
+
+// HandleLoginTwoFactor godoc
+// @Summary Login langkah kedua (2FA)
+// @Description Menukar challenge token dari /login dengan kode TOTP atau recovery code untuk mendapatkan token
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.LoginTwoFactorRequest true "Challenge token dan kode"
+// @Success 200 {object} model.LoginResponse
+// @Failure 400 {object} map[string]interface{} "Request tidak valid"
+// @Failure 401 {object} map[string]interface{} "Kode atau challenge tidak valid"
+// @Failure 429 {object} map[string]interface{} "Terlalu banyak percobaan login gagal"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /login/2fa [post]
+func (s *AuthService) LoginTwoFactor(c *fiber.Ctx) error {
+	var req model.LoginTwoFactorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
+	}
+	if req.ChallengeToken == "" || (req.Code == "" && req.RecoveryCode == "") {
+		return c.Status(400).JSON(fiber.Map{"error": "Challenge token dan kode harus diisi"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	challengeHash := utils.HashToken(req.ChallengeToken)
+	challenge, err := s.OneTimeTokenRepo.FindActive(ctx, model.TokenPurposeTwoFactorLogin, challengeHash)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if challenge == nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Challenge tidak valid atau expired, silakan login ulang"})
+	}
+
+	user, err := s.UserRepo.FindByID(ctx, challenge.UserID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if user == nil || user.IsDelete {
+		return c.Status(401).JSON(fiber.Map{"error": "Challenge tidak valid atau expired, silakan login ulang"})
+	}
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		s.loginFailed(c, model.SecurityEventLoginTwoFactor, model.SecurityOutcomeBlocked, "account_locked", user.Username, user)
+		return tooManyAttempts(c, *user.LockedUntil)
+	}
+
+	ok, err := verifySecondFactor(ctx, s.UserRepo, user, req.Code, req.RecoveryCode)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !ok {
+		// Kode salah dihitung sebagai login gagal agar kode 6 digit tidak bisa di-brute force
+		if err := s.recordUserFailure(ctx, user, loadLockoutPolicy()); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		s.loginFailed(c, model.SecurityEventLoginTwoFactor, model.SecurityOutcomeFailure, "invalid_code", user.Username, user)
+		return c.Status(401).JSON(fiber.Map{"error": "Kode tidak valid"})
+	}
+
+	consumed, err := s.OneTimeTokenRepo.Consume(ctx, model.TokenPurposeTwoFactorLogin, challengeHash)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if consumed == nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Challenge tidak valid atau expired, silakan login ulang"})
+	}
+
+	if user.FailedLoginAttempts > 0 {
+		if err := s.UserRepo.ResetFailedLogins(ctx, user.ID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	resp, err := s.startSession(ctx, c, user)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal generate token"})
+	}
+
+	event := userSecurityEvent(model.SecurityEventLoginTwoFactor, model.SecurityOutcomeSuccess, user)
+	if req.RecoveryCode != "" && req.Code == "" {
+		event.Metadata = map[string]string{"method": "recovery_code"}
+	}
+	s.recordEvent(c, event)
+	return c.JSON(resp)
+}
+
+// lockoutPolicy mengatur kapan akun / IP dikunci setelah login gagal berulang.
+// Setelah MaxAttempts kegagalan, durasi kunci = Base * 2^(kegagalan-MaxAttempts), maksimal Max.
+type lockoutPolicy struct {
+	MaxAttempts   int
+	IPMaxAttempts int
+	Base          time.Duration
+	Max           time.Duration
+	Window        time.Duration
+}
+
+func loadLockoutPolicy() lockoutPolicy {
+	return lockoutPolicy{
+		MaxAttempts:   utils.IntFromEnv("LOGIN_MAX_ATTEMPTS", 5),
+		IPMaxAttempts: utils.IntFromEnv("LOGIN_IP_MAX_ATTEMPTS", 20),
+		Base:          utils.DurationFromEnv("LOGIN_LOCKOUT_BASE", time.Minute),
+		Max:           utils.DurationFromEnv("LOGIN_LOCKOUT_MAX", time.Hour),
+		Window:        utils.DurationFromEnv("LOGIN_ATTEMPT_WINDOW", 15*time.Minute),
+	}
+}
+
+func (p lockoutPolicy) lockDuration(failures, maxAttempts int) time.Duration {
+	if failures < maxAttempts {
+		return 0
+	}
+	d := p.Base
+	for i := maxAttempts; i < failures; i++ {
+		d *= 2
+		if d >= p.Max {
+			return p.Max
+		}
+	}
+	return d
+}
+
+func (s *AuthService) recordUserFailure(ctx context.Context, user *model.User, policy lockoutPolicy) error {
+	failures, err := s.UserRepo.RecordFailedLogin(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if d := policy.lockDuration(failures, policy.MaxAttempts); d > 0 {
+		return s.UserRepo.LockUntil(ctx, user.ID, time.Now().Add(d))
+	}
+	return nil
+}
+
+func (s *AuthService) recordIPFailure(ctx context.Context, key string, policy lockoutPolicy) error {
+	throttle, err := s.ThrottleRepo.RecordFailure(ctx, key, policy.Window)
+	if err != nil {
+		return err
+	}
+	if d := policy.lockDuration(throttle.Count, policy.IPMaxAttempts); d > 0 {
+		return s.ThrottleRepo.LockUntil(ctx, key, time.Now().Add(d))
+	}
+	return nil
+}
+
+func tooManyAttempts(c *fiber.Ctx, until time.Time) error {
+	return retryLater(c, until, "Terlalu banyak percobaan login gagal. Coba lagi nanti")
+}
+
+// retryLater mengirim 429 dengan header Retry-After sampai until.
+func retryLater(c *fiber.Ctx, until time.Time, message string) error {
+	retryAfter := int(time.Until(until).Seconds()) + 1
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+	return c.Status(429).JSON(fiber.Map{
+		"error":       message,
+		"retry_after": retryAfter,
+	})
+}
+
+// HandleRefresh godoc
+// @Summary Refresh access token
+// @Description Menukar refresh token dengan access token dan refresh token baru (rotasi). Refresh token lama tidak bisa dipakai lagi.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} model.LoginResponse
+// @Failure 400 {object} map[string]interface{} "Request tidak valid"
+// @Failure 401 {object} map[string]interface{} "Refresh token tidak valid"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /token/refresh [post]
+func (s *AuthService) Refresh(c *fiber.Ctx) error {
+	var req model.RefreshTokenRequest
+	if err := c.BodyParser(&req); err != nil || req.RefreshToken == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stored, err := s.TokenRepo.FindRefreshTokenByHash(ctx, utils.HashToken(req.RefreshToken))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if stored == nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Refresh token tidak valid"})
+	}
+
+	// Token yang sudah dirotasi dipakai lagi: anggap bocor, cabut seluruh family
+	if stored.RevokedAt != nil {
+		if err := s.TokenRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		s.tokenReused(c, stored)
+		return c.Status(401).JSON(fiber.Map{"error": "Refresh token sudah tidak berlaku"})
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return c.Status(401).JSON(fiber.Map{"error": "Refresh token sudah expired"})
+	}
+
+	user, err := s.UserRepo.FindByID(ctx, stored.UserID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if user == nil || user.IsDelete {
+		if err := s.TokenRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(401).JSON(fiber.Map{"error": "User tidak ditemukan atau telah dihapus"})
+	}
+
+	session, err := s.SessionRepo.FindByID(ctx, stored.FamilyID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if session == nil {
+		// Refresh token dari sebelum ada session: catat sebagai session baru
+		session = &model.Session{
+			ID:        stored.FamilyID,
+			UserID:    user.ID,
+			UserAgent: c.Get(fiber.HeaderUserAgent),
+			IP:        c.IP(),
+			ExpiresAt: stored.ExpiresAt,
+		}
+		if err := s.SessionRepo.Create(ctx, session); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+	if session.RevokedAt != nil {
+		if err := s.TokenRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(401).JSON(fiber.Map{"error": "Sesi sudah berakhir, silakan login ulang"})
+	}
+
+	resp, next, err := s.issueTokens(ctx, user, stored.FamilyID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal generate token"})
+	}
+
+	rotated, err := s.TokenRepo.RotateRefreshToken(ctx, stored.ID, next.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !rotated {
+		// Request lain sudah merotasi token ini lebih dulu
+		if err := s.TokenRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		s.tokenReused(c, stored)
+		return c.Status(401).JSON(fiber.Map{"error": "Refresh token sudah tidak berlaku"})
+	}
+
+	if err := s.SessionRepo.Extend(ctx, session.ID, c.IP(), next.ExpiresAt); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(resp)
+}
+
+// tokenReused mencatat refresh token yang dipakai ulang (kemungkinan bocor) beserta session-nya.
+func (s *AuthService) tokenReused(c *fiber.Ctx, stored *model.RefreshToken) {
+	s.recordEvent(c, &model.SecurityEvent{
+		Type:     model.SecurityEventTokenReuse,
+		Outcome:  model.SecurityOutcomeBlocked,
+		UserID:   &stored.UserID,
+		Metadata: map[string]string{"session_id": stored.FamilyID},
+	})
+}
+
+// HandleLogout godoc
+// @Summary Logout
+// @Description Mengakhiri session saat ini: access token, session dan refresh token-nya dicabut
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.RefreshTokenRequest false "Refresh token"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 403 {object} map[string]interface{} "Bukan login user"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /logout [post]
+// @Security BearerAuth
+func (s *AuthService) Logout(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(primitive.ObjectID)
+	claims, ok := c.Locals("claims").(*model.JWTClaims)
+	if !ok {
+		return c.Status(403).JSON(fiber.Map{"error": "Logout hanya untuk login user"})
+	}
+
+	var req model.RefreshTokenRequest
+	_ = c.BodyParser(&req)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if claims.ID != "" && claims.ExpiresAt != nil {
+		if err := s.TokenRepo.RevokeAccessToken(ctx, claims.ID, userID, claims.ExpiresAt.Time); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	if claims.SessionID != "" {
+		if err := s.SessionRepo.Revoke(ctx, claims.SessionID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err := s.TokenRepo.RevokeFamily(ctx, claims.SessionID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	// Token impersonation tidak punya refresh token; logout hanya mengakhiri impersonation dan
+	// tidak boleh menyentuh session milik user target
+	if req.RefreshToken != "" && claims.Act == nil {
+		stored, err := s.TokenRepo.FindRefreshTokenByHash(ctx, utils.HashToken(req.RefreshToken))
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		if stored != nil && stored.UserID == userID {
+			if err := s.TokenRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+			}
+		}
+	}
+
+	s.recordEvent(c, &model.SecurityEvent{
+		Type:     model.SecurityEventLogout,
+		Outcome:  model.SecurityOutcomeSuccess,
+		UserID:   &userID,
+		Username: claims.Username,
+	})
+	return c.JSON(fiber.Map{"success": true, "message": "Logout berhasil"})
+}
+
+// sendMail mengirim email di background agar waktu respons tidak bergantung pada SMTP
+// (dan tidak membocorkan apakah sebuah email terdaftar).
+func (s *AuthService) sendMail(msg mailer.Message) {
+	sendMailAsync(s.Mailer, msg)
+}
+
+func sendMailAsync(m mailer.Mailer, msg mailer.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := m.Send(ctx, msg); err != nil {
+			log.Printf("Gagal mengirim email ke %s: %v", msg.To, err)
+		}
+	}()
+}
+
+// sendVerificationEmail membuat token verifikasi baru (token lama tidak berlaku) dan mengirimkannya.
+func (s *AuthService) sendVerificationEmail(ctx context.Context, user *model.User) error {
+	if err := s.OneTimeTokenRepo.InvalidateForUser(ctx, user.ID, model.TokenPurposeEmailVerification); err != nil {
+		return err
+	}
+
+	plain, hash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	ttl := utils.DurationFromEnv("EMAIL_VERIFICATION_TTL", 24*time.Hour)
+	err = s.OneTimeTokenRepo.Create(ctx, &model.OneTimeToken{
+		UserID:    user.ID,
+		Purpose:   model.TokenPurposeEmailVerification,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return err
+	}
+
+	s.sendMail(mailer.Message{
+		To:      user.Email,
+		Subject: "Verifikasi email",
+		Body: "Halo " + user.Username + ",\n\n" +
+			"Terima kasih sudah mendaftar. Buka link berikut untuk memverifikasi email Anda:\n\n" +
+			utils.FrontendURL("/verify-email?token="+plain) + "\n\n" +
+			"Link berlaku selama " + ttl.String() + ".\n",
+	})
+	return nil
+}
+
+// HandleVerifyEmail godoc
+// @Summary Verifikasi email
+// @Description Memverifikasi email user menggunakan token dari email registrasi (dikirim oleh halaman FRONTEND_URL/verify-email)
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.VerifyEmailRequest true "Token verifikasi"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 400 {object} map[string]interface{} "Token tidak valid atau expired"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /verify-email [post]
+func (s *AuthService) VerifyEmail(c *fiber.Ctx) error {
+	var req model.VerifyEmailRequest
+	if err := c.BodyParser(&req); err != nil || req.Token == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Token harus diisi"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	token, err := s.OneTimeTokenRepo.Consume(ctx, model.TokenPurposeEmailVerification, utils.HashToken(req.Token))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if token == nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Token tidak valid atau expired"})
+	}
+
+	if err := s.UserRepo.MarkEmailVerified(ctx, token.UserID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "Email berhasil diverifikasi, silakan login"})
+}
+
+// HandleResendVerification godoc
+// @Summary Kirim ulang verifikasi email
+// @Description Mengirim ulang link verifikasi. Respons selalu sama, baik email terdaftar maupun tidak.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.ResendVerificationRequest true "Email"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 400 {object} map[string]interface{} "Request tidak valid"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /verify-email/resend [post]
+func (s *AuthService) ResendVerification(c *fiber.Ctx) error {
+	var req model.ResendVerificationRequest
+	if err := c.BodyParser(&req); err != nil || req.Email == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Email harus diisi"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	response := fiber.Map{
+		"success": true,
+		"message": "Jika email terdaftar dan belum diverifikasi, link verifikasi sudah dikirim",
+	}
+
+	user, err := s.UserRepo.FindByEmail(ctx, req.Email)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if user == nil || user.IsDelete || user.EmailVerified {
+		return c.JSON(response)
+	}
+
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(response)
+}
+
+// HandleForgotPassword godoc
+// @Summary Lupa password
+// @Description Mengirim link reset password ke email user. Respons selalu sama, baik email terdaftar maupun tidak.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.ForgotPasswordRequest true "Email"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 400 {object} map[string]interface{} "Request tidak valid"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /forgot-password [post]
+func (s *AuthService) ForgotPassword(c *fiber.Ctx) error {
+	var req model.ForgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil || req.Email == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Email harus diisi"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	response := fiber.Map{
+		"success": true,
+		"message": "Jika email terdaftar, link reset password sudah dikirim",
+	}
+
+	user, err := s.UserRepo.FindByEmail(ctx, req.Email)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if user == nil || user.IsDelete {
+		return c.JSON(response)
+	}
+
+	// Hanya link terakhir yang berlaku
+	if err := s.OneTimeTokenRepo.InvalidateForUser(ctx, user.ID, model.TokenPurposePasswordReset); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	plain, hash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal generate token"})
+	}
+
+	ttl := utils.DurationFromEnv("PASSWORD_RESET_TTL", time.Hour)
+	err = s.OneTimeTokenRepo.Create(ctx, &model.OneTimeToken{
+		UserID:    user.ID,
+		Purpose:   model.TokenPurposePasswordReset,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	s.sendMail(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset password",
+		Body: "Halo " + user.Username + ",\n\n" +
+			"Kami menerima permintaan reset password untuk akun Anda. Buka link berikut untuk membuat password baru:\n\n" +
+			utils.FrontendURL("/reset-password?token="+plain) + "\n\n" +
+			"Link berlaku selama " + ttl.String() + " dan hanya bisa dipakai sekali. Abaikan email ini jika Anda tidak memintanya.\n",
+	})
+
+	s.recordEvent(c, userSecurityEvent(model.SecurityEventPasswordResetRequest, model.SecurityOutcomeSuccess, user))
+	return c.JSON(response)
+}
+
+// HandleResetPassword godoc
+// @Summary Reset password
+// @Description Mengganti password menggunakan token dari email lupa password. Link di email membuka halaman FRONTEND_URL/reset-password yang mengirim token ke endpoint ini. Token hanya bisa dipakai sekali dan semua sesi login dicabut.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.ResetPasswordRequest true "Token dan password baru"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 400 {object} map[string]interface{} "Token tidak valid atau expired"
+// @Failure 422 {object} model.ValidationErrorResponse "Password tidak memenuhi kebijakan"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /reset-password [post]
+func (s *AuthService) ResetPassword(c *fiber.Ctx) error {
+	var req model.ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
+	}
+	if req.Token == "" || req.Password == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Token dan password harus diisi"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tokenHash := utils.HashToken(req.Token)
+	token, err := s.OneTimeTokenRepo.FindActive(ctx, model.TokenPurposePasswordReset, tokenHash)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if token == nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Token tidak valid atau expired"})
+	}
+
+	user, err := s.UserRepo.FindByID(ctx, token.UserID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if user == nil || user.IsDelete {
+		return c.Status(400).JSON(fiber.Map{"error": "Token tidak valid atau expired"})
+	}
+
+	// Validasi sebelum token dipakai, supaya user bisa mencoba password lain dengan link yang sama
+	personal, err := passwordPersonalInfo(ctx, s.AlumniRepo, user)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if errs := utils.ValidatePassword("password", req.Password, personal...); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	consumed, err := s.OneTimeTokenRepo.Consume(ctx, model.TokenPurposePasswordReset, tokenHash)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if consumed == nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Token tidak valid atau expired"})
+	}
+
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal hash password"})
+	}
+	if err := s.UserRepo.UpdatePassword(ctx, user.ID, hashedPassword); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Password berubah: semua session dan refresh token lama tidak boleh dipakai lagi
+	if err := revokeAllSessions(ctx, s.TokenRepo, s.SessionRepo, user.ID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	s.recordEvent(c, userSecurityEvent(model.SecurityEventPasswordReset, model.SecurityOutcomeSuccess, user))
+	return c.JSON(fiber.Map{"success": true, "message": "Password berhasil direset, silakan login kembali"})
+}
+
+// HandleChangePassword godoc
+// @Summary Ganti password
+// @Description Mengganti password user yang login. Session lain diakhiri, session saat ini tetap aktif.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.ChangePasswordRequest true "Password lama dan baru"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 400 {object} map[string]interface{} "Request tidak valid"
+// @Failure 401 {object} map[string]interface{} "Password lama salah"
+// @Failure 422 {object} model.ValidationErrorResponse "Password tidak memenuhi kebijakan"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/password [put]
+// @Security BearerAuth
+func (s *AuthService) ChangePassword(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(primitive.ObjectID)
+
+	var req model.ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
+	}
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Password lama dan password baru harus diisi"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := s.UserRepo.FindByID(ctx, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if user == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "User tidak ditemukan"})
+	}
+
+	if !utils.CheckPassword(req.CurrentPassword, user.Password) {
+		if err := s.recordUserFailure(ctx, user, loadLockoutPolicy()); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		s.loginFailed(c, model.SecurityEventPasswordChange, model.SecurityOutcomeFailure, "invalid_password", user.Username, user)
+		return c.Status(401).JSON(fiber.Map{"error": "Password lama salah"})
+	}
+
+	personal, err := passwordPersonalInfo(ctx, s.AlumniRepo, user)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	errs := utils.ValidatePassword("new_password", req.NewPassword, personal...)
+	if req.NewPassword == req.CurrentPassword {
+		errs = append(errs, model.FieldError{
+			Field:   "new_password",
+			Code:    "same_as_current",
+			Message: "Password baru harus berbeda dari password lama",
+		})
+	}
+	if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal hash password"})
+	}
+	if err := s.UserRepo.UpdatePassword(ctx, user.ID, hashedPassword); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := revokeOtherSessions(ctx, s.TokenRepo, s.SessionRepo, user.ID, currentSessionID(c)); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	s.recordEvent(c, userSecurityEvent(model.SecurityEventPasswordChange, model.SecurityOutcomeSuccess, user))
+	return c.JSON(fiber.Map{"success": true, "message": "Password berhasil diganti"})
+}
+
+// HandleJWKS godoc
+// @Summary JSON Web Key Set
+// @Description Public key (RS256/EdDSA) untuk memverifikasi token yang diterbitkan API ini. Kunci HS256 tidak dipublikasikan.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} utils.JWKSet
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /.well-known/jwks.json [get]
+func (s *AuthService) JWKS(c *fiber.Ctx) error {
+	km, err := utils.Keys()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(km.JWKS())
+}
@@ -0,0 +1,365 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"gofiber-mongo/app/model"
+	"gofiber-mongo/utils"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/xuri/excelize/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// exportFlushEvery menentukan seberapa sering buffer response dikirim ke client.
+const exportFlushEvery = 500
+
+var exportContentTypes = map[string]string{
+	model.ExportFormatCSV:    "text/csv; charset=utf-8",
+	model.ExportFormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	model.ExportFormatNDJSON: "application/x-ndjson",
+}
+
+// Kolom ekspor alumni memakai nama field import agar file hasil ekspor bisa di-import kembali.
+var (
+	alumniExportColumns = []string{
+		"id", "nim", "nama", "jurusan", "angkatan", "tahun_lulus", "email", "no_telepon", "alamat",
+		"created_at", "updated_at",
+	}
+	alumniPekerjaanExportColumns = []string{
+		"pekerjaan_nama_perusahaan", "pekerjaan_posisi_jabatan", "pekerjaan_bidang_industri",
+		"pekerjaan_lokasi_kerja", "pekerjaan_tanggal_mulai_kerja",
+	}
+	pekerjaanExportColumns = []string{
+		"id", "alumni_id", "nama_perusahaan", "posisi_jabatan", "bidang_industri", "lokasi_kerja",
+		"gaji_range", "tanggal_mulai_kerja", "tanggal_selesai_kerja", "status_pekerjaan",
+		"deskripsi_pekerjaan", "created_at", "updated_at",
+	}
+)
+
+// exportQuery membaca parameter yang sama dengan endpoint daftar (search, sortBy, order) dan format.
+func exportQuery(c *fiber.Ctx) (format, search, sortBy, order string, ok bool) {
+	format = strings.ToLower(c.Query("format", model.ExportFormatCSV))
+	if _, known := exportContentTypes[format]; !known {
+		return "", "", "", "", false
+	}
+	sortBy = c.Query("sortBy", "created_at")
+	order = strings.ToLower(c.Query("order", "desc"))
+	if order != "asc" {
+		order = "desc"
+	}
+	return format, c.Query("search", ""), sortBy, order, true
+}
+
+func exportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func exportDate(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func alumniExportRow(a *model.AlumniExport, withPekerjaan bool) []any {
+	alamat := ""
+	if a.Alamat != nil {
+		alamat = *a.Alamat
+	}
+	row := []any{
+		a.ID.Hex(), a.NIM, a.Nama, a.Jurusan, a.Angkatan, a.TahunLulus, a.Email, a.NoTelepon, alamat,
+		exportTime(a.CreatedAt), exportTime(a.UpdatedAt),
+	}
+	if !withPekerjaan {
+		return row
+	}
+	if p := a.PekerjaanSaatIni; p != nil {
+		return append(row, p.NamaPerusahaan, p.PosisiJabatan, p.BidangIndustri, p.LokasiKerja, exportDate(&p.TanggalMulaiKerja))
+	}
+	return append(row, "", "", "", "", "")
+}
+
+func pekerjaanExportRow(p *model.PekerjaanAlumni) []any {
+	return []any{
+		p.ID.Hex(), p.AlumniID.Hex(), p.NamaPerusahaan, p.PosisiJabatan, p.BidangIndustri, p.LokasiKerja,
+		p.GajiRange, exportDate(&p.TanggalMulaiKerja), exportDate(p.TanggalSelesaiKerja), p.StatusPekerjaan,
+		p.DeskripsiPekerjaan, exportTime(p.CreatedAt), exportTime(p.UpdatedAt),
+	}
+}
+
+// exportWriter menulis dokumen ekspor satu per satu. CSV dan XLSX memakai row, NDJSON memakai doc.
+type exportWriter interface {
+	WriteRow(doc any, row []any) error
+	Close() error
+}
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func newCSVExportWriter(out io.Writer, columns []string) (*csvExportWriter, error) {
+	// BOM agar Excel membaca CSV sebagai UTF-8
+	if _, err := io.WriteString(out, "\xef\xbb\xbf"); err != nil {
+		return nil, err
+	}
+	w := csv.NewWriter(out)
+	if err := w.Write(columns); err != nil {
+		return nil, err
+	}
+	return &csvExportWriter{w: w}, nil
+}
+
+// csvSignedNumber adalah nilai berawalan + / - yang aman (mis. nomor telepon "+62 812-...").
+var csvSignedNumber = regexp.MustCompile(`^[+-][0-9][0-9 .\-]*$`)
+
+// csvSafe mencegah formula injection saat CSV dibuka di aplikasi spreadsheet.
+func csvSafe(v string) string {
+	if v == "" {
+		return v
+	}
+	switch v[0] {
+	case '=', '@', '\t', '\r':
+		return "'" + v
+	case '+', '-':
+		if !csvSignedNumber.MatchString(v) {
+			return "'" + v
+		}
+	}
+	return v
+}
+
+func (e *csvExportWriter) WriteRow(_ any, row []any) error {
+	record := make([]string, len(row))
+	for i, v := range row {
+		record[i] = csvSafe(fmt.Sprint(v))
+	}
+	return e.w.Write(record)
+}
+
+func (e *csvExportWriter) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type ndjsonExportWriter struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonExportWriter) WriteRow(doc any, _ []any) error {
+	return e.enc.Encode(doc)
+}
+
+func (e *ndjsonExportWriter) Close() error {
+	return nil
+}
+
+// xlsxExportWriter memakai StreamWriter excelize yang menyimpan baris di file sementara,
+// sehingga memori tetap kecil; file baru bisa dikirim utuh saat Close.
+type xlsxExportWriter struct {
+	file *excelize.File
+	sw   *excelize.StreamWriter
+	out  io.Writer
+	line int
+}
+
+func newXLSXExportWriter(out io.Writer, sheet string, columns []string) (*xlsxExportWriter, error) {
+	f := excelize.NewFile()
+	if err := f.SetSheetName(f.GetSheetName(0), sheet); err != nil {
+		f.Close()
+		return nil, err
+	}
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	header := make([]any, len(columns))
+	for i, col := range columns {
+		header[i] = col
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &xlsxExportWriter{file: f, sw: sw, out: out, line: 1}, nil
+}
+
+func (e *xlsxExportWriter) WriteRow(_ any, row []any) error {
+	e.line++
+	cell, err := excelize.CoordinatesToCellName(1, e.line)
+	if err != nil {
+		return err
+	}
+	return e.sw.SetRow(cell, row)
+}
+
+func (e *xlsxExportWriter) Close() error {
+	defer e.file.Close()
+	if err := e.sw.Flush(); err != nil {
+		return err
+	}
+	return e.file.Write(e.out)
+}
+
+func newExportWriter(out io.Writer, format, name string, columns []string) (exportWriter, error) {
+	switch format {
+	case model.ExportFormatXLSX:
+		return newXLSXExportWriter(out, name, columns)
+	case model.ExportFormatNDJSON:
+		return &ndjsonExportWriter{enc: json.NewEncoder(out)}, nil
+	}
+	return newCSVExportWriter(out, columns)
+}
+
+// streamExport mengirim isi cursor sebagai file unduhan. Response dikirim bertahap, sehingga
+// error setelah baris pertama tidak bisa lagi mengubah status; error tersebut hanya dicatat ke log.
+func streamExport(ctx context.Context, cancel context.CancelFunc, c *fiber.Ctx, cursor *mongo.Cursor,
+	name, format string, columns []string, next func(*mongo.Cursor) (doc any, row []any, err error)) error {
+	c.Attachment(fmt.Sprintf("%s-%s.%s", name, time.Now().Format("20060102-150405"), format))
+	c.Set(fiber.HeaderContentType, exportContentTypes[format])
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		defer cursor.Close(context.Background())
+
+		if err := writeExport(ctx, w, cursor, name, format, columns, next); err != nil {
+			log.Printf("Ekspor %s (%s) terhenti: %v", name, format, err)
+		}
+	})
+	return nil
+}
+
+func writeExport(ctx context.Context, w *bufio.Writer, cursor *mongo.Cursor,
+	name, format string, columns []string, next func(*mongo.Cursor) (any, []any, error)) error {
+	ew, err := newExportWriter(w, format, name, columns)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	for cursor.Next(ctx) {
+		doc, row, err := next(cursor)
+		if err != nil {
+			return err
+		}
+		if err := ew.WriteRow(doc, row); err != nil {
+			return err
+		}
+		count++
+		if count%exportFlushEvery == 0 && format != model.ExportFormatXLSX {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	if err := ew.Close(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func exportContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), utils.DurationFromEnv("EXPORT_TIMEOUT", 10*time.Minute))
+}
+
+// HandleExport godoc
+// @Summary Ekspor alumni
+// @Description Mengunduh seluruh alumni yang cocok dengan filter daftar (search, sortBy, order) sebagai CSV, XLSX atau NDJSON. Data dikirim bertahap dari cursor database. with_pekerjaan=true menambahkan pekerjaan aktif terbaru tiap alumni. Butuh permission alumni:export (tidak termasuk role user bawaan).
+// @Tags Alumni
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/x-ndjson
+// @Param format query string false "csv, xlsx atau ndjson" default(csv)
+// @Param sortBy query string false "Sort field" default(created_at)
+// @Param order query string false "Sort order (asc/desc)" default(desc)
+// @Param search query string false "Search by nama or nim"
+// @Param with_pekerjaan query bool false "Sertakan pekerjaan saat ini" default(false)
+// @Success 200 {file} file "file ekspor"
+// @Failure 400 {object} map[string]interface{} "Format tidak valid"
+// @Failure 403 {object} map[string]interface{} "Butuh permission alumni:export"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /alumni/export [get]
+// @Security BearerAuth
+func (s *AlumniService) Export(c *fiber.Ctx) error {
+	format, search, sortBy, order, ok := exportQuery(c)
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "format harus csv, xlsx atau ndjson"})
+	}
+	withPekerjaan := c.QueryBool("with_pekerjaan", false)
+
+	columns := alumniExportColumns
+	if withPekerjaan {
+		columns = append(append([]string{}, alumniExportColumns...), alumniPekerjaanExportColumns...)
+	}
+
+	ctx, cancel := exportContext()
+	cursor, err := s.Repo.ExportCursor(ctx, search, sortBy, order, withPekerjaan)
+	if err != nil {
+		cancel()
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return streamExport(ctx, cancel, c, cursor, "alumni", format, columns, func(cur *mongo.Cursor) (any, []any, error) {
+		var a model.AlumniExport
+		if err := cur.Decode(&a); err != nil {
+			return nil, nil, err
+		}
+		return a, alumniExportRow(&a, withPekerjaan), nil
+	})
+}
+
+// HandleExport godoc
+// @Summary Ekspor pekerjaan
+// @Description Mengunduh seluruh pekerjaan yang cocok dengan filter daftar (search, sortBy, order) sebagai CSV, XLSX atau NDJSON. Data dikirim bertahap dari cursor database. Butuh permission alumni:export (tidak termasuk role user bawaan).
+// @Tags Pekerjaan
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/x-ndjson
+// @Param format query string false "csv, xlsx atau ndjson" default(csv)
+// @Param sortBy query string false "Sort field" default(created_at)
+// @Param order query string false "Sort order (asc/desc)" default(desc)
+// @Param search query string false "Search by nama perusahaan or posisi"
+// @Success 200 {file} file "file ekspor"
+// @Failure 400 {object} map[string]interface{} "Format tidak valid"
+// @Failure 403 {object} map[string]interface{} "Butuh permission alumni:export"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /pekerjaan/export [get]
+// @Security BearerAuth
+func (s *PekerjaanService) Export(c *fiber.Ctx) error {
+	format, search, sortBy, order, ok := exportQuery(c)
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "format harus csv, xlsx atau ndjson"})
+	}
+
+	ctx, cancel := exportContext()
+	cursor, err := s.Repo.ExportCursor(ctx, search, sortBy, order)
+	if err != nil {
+		cancel()
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return streamExport(ctx, cancel, c, cursor, "pekerjaan", format, pekerjaanExportColumns, func(cur *mongo.Cursor) (any, []any, error) {
+		var p model.PekerjaanAlumni
+		if err := cur.Decode(&p); err != nil {
+			return nil, nil, err
+		}
+		return p, pekerjaanExportRow(&p), nil
+	})
+}
@@ -9,6 +9,7 @@ import (
 
 	"gofiber-mongo/app/model"
 	"gofiber-mongo/app/repository"
+	"gofiber-mongo/middleware"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -23,6 +24,7 @@ type IFileService interface {
 	GetCertificateByAlumniID(c *fiber.Ctx) error
 	DeletePhoto(c *fiber.Ctx) error
 	DeleteCertificate(c *fiber.Ctx) error
+	GetMine(c *fiber.Ctx) error
 }
 
 // FileService implements IFileService
@@ -98,7 +100,7 @@ func (s *FileService) UploadPhoto(c *fiber.Ctx) error {
 
 	// Check authorization
 	userID := c.Locals("user_id").(primitive.ObjectID)
-	role := c.Locals("role").(string)
+	canUploadAny := middleware.HasPermission(c, model.PermFilesUploadAny)
 
 	// Convert alumni_id to ObjectID
 	alumniObjID, err := primitive.ObjectIDFromHex(alumniID)
@@ -109,8 +111,8 @@ func (s *FileService) UploadPhoto(c *fiber.Ctx) error {
 		})
 	}
 
-	// Verify alumni exists and check ownership (only for non-admin)
-	if role != "admin" {
+	// Verify alumni exists and check ownership (only without files:upload_any)
+	if !canUploadAny {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
@@ -237,7 +239,7 @@ func (s *FileService) UploadCertificate(c *fiber.Ctx) error {
 
 	// Check authorization
 	userID := c.Locals("user_id").(primitive.ObjectID)
-	role := c.Locals("role").(string)
+	canUploadAny := middleware.HasPermission(c, model.PermFilesUploadAny)
 
 	// Convert alumni_id to ObjectID
 	alumniObjID, err := primitive.ObjectIDFromHex(alumniID)
@@ -248,8 +250,8 @@ func (s *FileService) UploadCertificate(c *fiber.Ctx) error {
 		})
 	}
 
-	// Verify alumni exists and check ownership (only for non-admin)
-	if role != "admin" {
+	// Verify alumni exists and check ownership (only without files:upload_any)
+	if !canUploadAny {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
@@ -424,7 +426,7 @@ func (s *FileService) GetCertificateByAlumniID(c *fiber.Ctx) error {
 func (s *FileService) DeletePhoto(c *fiber.Ctx) error {
 	photoID := c.Params("id")
 	userID := c.Locals("user_id").(primitive.ObjectID)
-	role := c.Locals("role").(string)
+	canDeleteAny := middleware.HasPermission(c, model.PermFilesDeleteAny)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -445,8 +447,8 @@ func (s *FileService) DeletePhoto(c *fiber.Ctx) error {
 		})
 	}
 
-	// Check authorization: only owner or admin can delete
-	if role != "admin" && photo.UserID != userID {
+	// Check authorization: only owner or files:delete_any can delete
+	if !canDeleteAny && photo.UserID != userID {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"success": false,
 			"message": "Anda tidak memiliki izin untuk menghapus foto ini",
@@ -490,7 +492,7 @@ func (s *FileService) DeletePhoto(c *fiber.Ctx) error {
 func (s *FileService) DeleteCertificate(c *fiber.Ctx) error {
 	certID := c.Params("id")
 	userID := c.Locals("user_id").(primitive.ObjectID)
-	role := c.Locals("role").(string)
+	canDeleteAny := middleware.HasPermission(c, model.PermFilesDeleteAny)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -511,8 +513,8 @@ func (s *FileService) DeleteCertificate(c *fiber.Ctx) error {
 		})
 	}
 
-	// Check authorization: only owner or admin can delete
-	if role != "admin" && cert.UserID != userID {
+	// Check authorization: only owner or files:delete_any can delete
+	if !canDeleteAny && cert.UserID != userID {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"success": false,
 			"message": "Anda tidak memiliki izin untuk menghapus sertifikat ini",
@@ -539,6 +541,77 @@ func (s *FileService) DeleteCertificate(c *fiber.Ctx) error {
 	})
 }
 
+// HandleGetMine godoc
+// @Summary Get my files
+// @Description Mengambil foto dan sertifikat milik alumni yang ditautkan ke user yang login
+// @Tags Files
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "photo and certificate data"
+// @Failure 404 {object} map[string]interface{} "Akun belum ditautkan ke data alumni"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/files [get]
+// @Security BearerAuth
+// GetMine retrieves photo and certificate of the caller's own alumni
+func (s *FileService) GetMine(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	alumniID, err := s.repo.FindAlumniIDByUserID(ctx, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Gagal mengambil data alumni",
+			"error":   err.Error(),
+		})
+	}
+
+	if alumniID == "" {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"message": "Akun belum ditautkan ke data alumni",
+		})
+	}
+
+	photo, err := s.repo.FindPhotoByAlumniID(ctx, alumniID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Gagal mengambil data foto",
+			"error":   err.Error(),
+		})
+	}
+
+	cert, err := s.repo.FindCertificateByAlumniID(ctx, alumniID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Gagal mengambil data sertifikat",
+			"error":   err.Error(),
+		})
+	}
+
+	data := fiber.Map{
+		"alumni_id":   alumniID,
+		"photo":       nil,
+		"certificate": nil,
+	}
+	if photo != nil {
+		data["photo"] = s.toPhotoResponse(photo)
+	}
+	if cert != nil {
+		data["certificate"] = s.toCertificateResponse(cert)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "File berhasil diambil",
+		"data":    data,
+	})
+}
+
 // Helper functions
 func (s *FileService) toPhotoResponse(photo *model.Photo) *model.PhotoResponse {
 	return &model.PhotoResponse{
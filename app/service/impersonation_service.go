@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"gofiber-mongo/app/model"
+	"gofiber-mongo/app/repository"
+	"gofiber-mongo/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ImpersonationService memungkinkan admin melihat aplikasi persis seperti yang dilihat user.
+// Token impersonation berumur pendek, membawa admin di claim "act", dan setiap request-nya
+// dicatat di audit log oleh middleware.AuthRequired.
+type ImpersonationService struct {
+	UserRepo  *repository.UserRepository
+	RoleRepo  *repository.RoleRepository
+	AuditRepo *repository.AuditLogRepository
+	EventRepo *repository.SecurityEventRepository
+}
+
+func NewImpersonationService(userRepo *repository.UserRepository, roleRepo *repository.RoleRepository,
+	auditRepo *repository.AuditLogRepository, eventRepo *repository.SecurityEventRepository) *ImpersonationService {
+	return &ImpersonationService{
+		UserRepo:  userRepo,
+		RoleRepo:  roleRepo,
+		AuditRepo: auditRepo,
+		EventRepo: eventRepo,
+	}
+}
+
+// HandleImpersonate godoc
+// @Summary Impersonate user
+// @Description Membuat access token berumur pendek untuk bertindak sebagai user (tanpa refresh token).
+// @Description Selama impersonation hanya GET, HEAD, OPTIONS dan POST /logout yang diizinkan, kecuali IMPERSONATION_ALLOW_DESTRUCTIVE=true atau request ada di IMPERSONATION_ALLOWED_WRITES. Endpoint kredensial dan ekspor data pribadi (/me/export) selalu ditolak, dan semua request dicatat di audit log.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param body body model.ImpersonateRequest true "Alasan impersonation"
+// @Success 201 {object} model.ImpersonateResponse
+// @Failure 400 {object} map[string]interface{} "Request tidak valid"
+// @Failure 403 {object} map[string]interface{} "Tidak boleh impersonate user ini"
+// @Failure 404 {object} map[string]interface{} "User tidak ditemukan"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /users/{id}/impersonate [post]
+// @Security BearerAuth
+func (s *ImpersonationService) Start(c *fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(*model.JWTClaims)
+	if !ok {
+		return c.Status(403).JSON(fiber.Map{"error": "Impersonation hanya bisa dimulai dari login user"})
+	}
+	if claims.Act != nil {
+		return c.Status(403).JSON(fiber.Map{"error": "Tidak bisa impersonate selama sedang impersonation"})
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
+	}
+	if isSelf(c, id) {
+		return c.Status(400).JSON(fiber.Map{"error": "Tidak bisa impersonate akun sendiri"})
+	}
+
+	var req model.ImpersonateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "reason tidak boleh kosong"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	target, err := s.UserRepo.FindByID(ctx, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if target == nil || target.IsDelete {
+		return c.Status(404).JSON(fiber.Map{"error": "User tidak ditemukan"})
+	}
+
+	// Admin tidak boleh mendapatkan permission yang tidak dimilikinya lewat impersonation
+	role, err := s.RoleRepo.FindByName(ctx, target.Role)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if exceedsCaller(c, role) {
+		return c.Status(403).JSON(fiber.Map{"error": "Tidak boleh impersonate user dengan permission melebihi Anda"})
+	}
+
+	actor, err := s.UserRepo.FindByID(ctx, claims.UserID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if actor == nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Token sudah tidak berlaku"})
+	}
+
+	token, err := utils.GenerateImpersonationToken(target, actor, claims.SessionID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := s.AuditRepo.Create(ctx, &model.AuditLog{
+		Action:        model.AuditActionImpersonationStart,
+		ActorID:       actor.ID,
+		ActorUsername: actor.Username,
+		UserID:        target.ID,
+		Method:        c.Method(),
+		Path:          c.Path(),
+		Status:        fiber.StatusCreated,
+		Reason:        req.Reason,
+		IP:            c.IP(),
+		UserAgent:     c.Get("User-Agent"),
+	}); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Juga tampil di riwayat keamanan user yang di-impersonate
+	event := adminSecurityEvent(c, model.SecurityEventImpersonation, target)
+	event.Reason = req.Reason
+	recordSecurityEvent(s.EventRepo, c, event)
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"data": model.ImpersonateResponse{
+			Token:     token,
+			ExpiresIn: int64(utils.ImpersonationTokenTTL().Seconds()),
+			User:      *target,
+		},
+	})
+}
+
+// HandleGetAuditLogs godoc
+// @Summary Get audit logs
+// @Description Mengambil audit log (impersonation) dengan filter admin, user dan aksi
+// @Tags Audit
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param actor_id query string false "Filter admin yang bertindak"
+// @Param user_id query string false "Filter user yang di-impersonate"
+// @Param action query string false "impersonation.start, impersonation.request"
+// @Success 200 {object} map[string]interface{} "audit log list with metadata"
+// @Failure 400 {object} map[string]interface{} "Filter tidak valid"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /audit-logs [get]
+// @Security BearerAuth
+func (s *ImpersonationService) GetAuditLogs(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	var actorID, userID primitive.ObjectID
+	if v := c.Query("actor_id"); v != "" {
+		id, err := primitive.ObjectIDFromHex(v)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "actor_id tidak valid"})
+		}
+		actorID = id
+	}
+	if v := c.Query("user_id"); v != "" {
+		id, err := primitive.ObjectIDFromHex(v)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "user_id tidak valid"})
+		}
+		userID = id
+	}
+	action := c.Query("action")
+
+	offset := (page - 1) * limit
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	logs, err := s.AuditRepo.GetAllWithFilter(ctx, actorID, userID, action, limit, offset)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if logs == nil {
+		logs = []model.AuditLog{}
+	}
+
+	total, err := s.AuditRepo.CountWithFilter(ctx, actorID, userID, action)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    logs,
+		"meta": model.MetaInfo{
+			Page:   page,
+			Limit:  limit,
+			Total:  int(total),
+			Pages:  (int(total) + limit - 1) / limit,
+			SortBy: "created_at",
+			Order:  "desc",
+		},
+	})
+}
@@ -0,0 +1,441 @@
+package service
+
+import (
+	"context"
+	"gofiber-mongo/app/model"
+	"gofiber-mongo/app/repository"
+	"gofiber-mongo/mailer"
+	"gofiber-mongo/utils"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Alasan alumni tidak bisa diundang
+const (
+	inviteSkipNoEmail         = "no_email"
+	inviteSkipAlreadyLinked   = "already_linked"
+	inviteSkipEmailRegistered = "email_registered"
+)
+
+var inviteSkipMessages = map[string]string{
+	inviteSkipNoEmail:         "Alumni tidak memiliki email",
+	inviteSkipAlreadyLinked:   "Alumni sudah memiliki akun",
+	inviteSkipEmailRegistered: "Email alumni sudah terdaftar sebagai user, gunakan klaim profil alumni",
+}
+
+// InvitationService mengundang alumni untuk membuat akun yang langsung tertaut ke data alumninya.
+type InvitationService struct {
+	Repo       *repository.InvitationRepository
+	AlumniRepo *repository.AlumniRepository
+	UserRepo   *repository.UserRepository
+	Mailer     mailer.Mailer
+}
+
+func NewInvitationService(repo *repository.InvitationRepository, alumniRepo *repository.AlumniRepository,
+	userRepo *repository.UserRepository, m mailer.Mailer) *InvitationService {
+	return &InvitationService{
+		Repo:       repo,
+		AlumniRepo: alumniRepo,
+		UserRepo:   userRepo,
+		Mailer:     m,
+	}
+}
+
+// invite membuat undangan baru untuk alumni (undangan pending sebelumnya dibatalkan) dan
+// mengirim link-nya ke email alumni. Jika alumni tidak bisa diundang, skip berisi alasannya.
+func (s *InvitationService) invite(ctx context.Context, alumni *model.Alumni, invitedBy primitive.ObjectID) (*model.Invitation, string, error) {
+	if alumni.Email == "" {
+		return nil, inviteSkipNoEmail, nil
+	}
+	if !alumni.UserID.IsZero() {
+		return nil, inviteSkipAlreadyLinked, nil
+	}
+	existing, err := s.UserRepo.FindByEmail(ctx, alumni.Email)
+	if err != nil {
+		return nil, "", err
+	}
+	if existing != nil {
+		return nil, inviteSkipEmailRegistered, nil
+	}
+
+	if err := s.Repo.RevokePendingForAlumni(ctx, alumni.ID); err != nil {
+		return nil, "", err
+	}
+
+	ttl := utils.DurationFromEnv("INVITATION_TTL", 7*24*time.Hour)
+	invitation := &model.Invitation{
+		ID:        primitive.NewObjectID(),
+		AlumniID:  alumni.ID,
+		NIM:       alumni.NIM,
+		Email:     alumni.Email,
+		InvitedBy: invitedBy,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	token, err := utils.GenerateInvitationToken(invitation.ID.Hex(), invitation.ExpiresAt)
+	if err != nil {
+		return nil, "", err
+	}
+	invitation.TokenHash = utils.HashToken(token)
+	if err := s.Repo.Create(ctx, invitation); err != nil {
+		return nil, "", err
+	}
+
+	sendMailAsync(s.Mailer, mailer.Message{
+		To:      alumni.Email,
+		Subject: "Undangan membuat akun alumni",
+		Body: "Halo " + alumni.Nama + ",\n\n" +
+			"Anda diundang untuk membuat akun pada sistem alumni (NIM " + alumni.NIM + ").\n" +
+			"Buka link berikut untuk membuat username dan password:\n\n" +
+			utils.FrontendURL("/accept-invitation?token="+token) + "\n\n" +
+			"Link berlaku selama " + ttl.String() + ".\n",
+	})
+	return invitation, "", nil
+}
+
+// findInvitation mengambil undangan pending dari token link undangan. Tanda tangan dan masa
+// berlaku token diverifikasi lebih dulu; nil berarti token tidak valid, expired, dibatalkan
+// atau sudah dipakai.
+func findInvitation(ctx context.Context, repo *repository.InvitationRepository, token string) (*model.Invitation, error) {
+	id, err := utils.ValidateInvitationToken(token)
+	if err != nil {
+		return nil, nil
+	}
+	invitation, err := repo.FindActiveByHash(ctx, utils.HashToken(token))
+	if err != nil || invitation == nil {
+		return nil, err
+	}
+	if invitation.ID.Hex() != id {
+		return nil, nil
+	}
+	return invitation, nil
+}
+
+// HandleCreate godoc
+// @Summary Undang alumni
+// @Description Mengirim link undangan pembuatan akun ke email alumni. Undangan pending sebelumnya untuk alumni yang sama dibatalkan.
+// @Tags Invitations
+// @Accept json
+// @Produce json
+// @Param body body model.CreateInvitationRequest true "Alumni yang diundang"
+// @Success 201 {object} map[string]interface{} "created invitation"
+// @Failure 400 {object} map[string]interface{} "Request tidak valid / alumni tanpa email"
+// @Failure 404 {object} map[string]interface{} "Alumni tidak ditemukan"
+// @Failure 409 {object} map[string]interface{} "Alumni sudah punya akun"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /invitations [post]
+// @Security BearerAuth
+func (s *InvitationService) Create(c *fiber.Ctx) error {
+	var req model.CreateInvitationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
+	}
+	alumniID, err := primitive.ObjectIDFromHex(req.AlumniID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "alumni_id tidak valid"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	alumni, err := s.AlumniRepo.GetByID(ctx, alumniID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if alumni == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Alumni tidak ditemukan"})
+	}
+
+	invitation, skip, err := s.invite(ctx, alumni, c.Locals("user_id").(primitive.ObjectID))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if skip != "" {
+		status := 409
+		if skip == inviteSkipNoEmail {
+			status = 400
+		}
+		return c.Status(status).JSON(fiber.Map{"error": inviteSkipMessages[skip], "code": skip})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"message": "Undangan dikirim ke " + maskEmail(invitation.Email),
+		"data":    invitation,
+	})
+}
+
+// HandleCreateBulk godoc
+// @Summary Undang alumni massal
+// @Description Mengundang semua alumni yang belum punya akun pada angkatan dan/atau jurusan tertentu
+// @Tags Invitations
+// @Accept json
+// @Produce json
+// @Param body body model.BulkInvitationRequest true "Filter angkatan / jurusan"
+// @Success 201 {object} map[string]interface{} "invited and skipped alumni"
+// @Failure 400 {object} map[string]interface{} "Filter kosong"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /invitations/bulk [post]
+// @Security BearerAuth
+func (s *InvitationService) CreateBulk(c *fiber.Ctx) error {
+	var req model.BulkInvitationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
+	}
+	req.Jurusan = strings.TrimSpace(req.Jurusan)
+	if req.Angkatan == 0 && req.Jurusan == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "angkatan atau jurusan harus diisi"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	list, err := s.AlumniRepo.GetUnlinkedByCohort(ctx, req.Angkatan, req.Jurusan)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	invitedBy := c.Locals("user_id").(primitive.ObjectID)
+	invited := []model.Invitation{}
+	skipped := []model.InvitationSkip{}
+	for i := range list {
+		invitation, skip, err := s.invite(ctx, &list[i], invitedBy)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error":   err.Error(),
+				"invited": len(invited),
+			})
+		}
+		if skip != "" {
+			skipped = append(skipped, model.InvitationSkip{AlumniID: list[i].ID, NIM: list[i].NIM, Reason: skip})
+			continue
+		}
+		invited = append(invited, *invitation)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"message": "Undangan dikirim",
+		"data": fiber.Map{
+			"invited": invited,
+			"skipped": skipped,
+		},
+	})
+}
+
+// HandleGetAll godoc
+// @Summary Daftar undangan
+// @Description Mengambil undangan berdasarkan status (default pending)
+// @Tags Invitations
+// @Produce json
+// @Param status query string false "pending, accepted, revoked, expired, all" default(pending)
+// @Success 200 {object} map[string]interface{} "invitation list"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /invitations [get]
+// @Security BearerAuth
+func (s *InvitationService) GetAll(c *fiber.Ctx) error {
+	status := c.Query("status", model.InvitationStatusPending)
+	if status == "all" {
+		status = ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	list, err := s.Repo.FindByStatus(ctx, status)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if list == nil {
+		list = []model.Invitation{}
+	}
+	return c.JSON(fiber.Map{"success": true, "data": list})
+}
+
+// HandleRevoke godoc
+// @Summary Batalkan undangan
+// @Description Membatalkan undangan yang masih pending; link undangan langsung tidak berlaku
+// @Tags Invitations
+// @Produce json
+// @Param id path string true "Invitation ID"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 400 {object} map[string]interface{} "ID tidak valid"
+// @Failure 404 {object} map[string]interface{} "Undangan tidak ditemukan"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /invitations/{id} [delete]
+// @Security BearerAuth
+func (s *InvitationService) Revoke(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	revoked, err := s.Repo.Revoke(ctx, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !revoked {
+		return c.Status(404).JSON(fiber.Map{"error": "Undangan tidak ditemukan atau sudah tidak pending"})
+	}
+	return c.JSON(fiber.Map{"success": true, "message": "Undangan dibatalkan"})
+}
+
+// HandlePreview godoc
+// @Summary Cek undangan
+// @Description Menampilkan data alumni dari link undangan sebelum membuat akun
+// @Tags Invitations
+// @Produce json
+// @Param token query string true "Token undangan"
+// @Success 200 {object} map[string]interface{} "invitation preview"
+// @Failure 400 {object} map[string]interface{} "Undangan tidak valid atau expired"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /invitations/preview [get]
+func (s *InvitationService) Preview(c *fiber.Ctx) error {
+	token := c.Query("token")
+	if token == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Token harus diisi"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	invitation, err := findInvitation(ctx, s.Repo, token)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if invitation == nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Undangan tidak valid atau expired"})
+	}
+	alumni, err := s.AlumniRepo.GetByID(ctx, invitation.AlumniID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if alumni == nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Undangan tidak valid atau expired"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"nama":       alumni.Nama,
+			"nim":        alumni.NIM,
+			"email":      maskEmail(invitation.Email),
+			"expires_at": invitation.ExpiresAt,
+		},
+	})
+}
+
+// HandleAccept godoc
+// @Summary Terima undangan
+// @Description Membuat akun dari link undangan. Email dianggap terverifikasi dan akun langsung ditautkan ke data alumni.
+// @Tags Invitations
+// @Accept json
+// @Produce json
+// @Param body body model.AcceptInvitationRequest true "Token, username dan password"
+// @Success 201 {object} map[string]interface{} "created user"
+// @Failure 400 {object} map[string]interface{} "Undangan tidak valid atau expired"
+// @Failure 409 {object} map[string]interface{} "Alumni sudah punya akun"
+// @Failure 422 {object} model.ValidationErrorResponse "Password tidak memenuhi kebijakan"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /invitations/accept [post]
+func (s *InvitationService) Accept(c *fiber.Ctx) error {
+	var req model.AcceptInvitationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
+	}
+	req.Username = strings.TrimSpace(req.Username)
+	if req.Token == "" || req.Username == "" || req.Password == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Token, username dan password harus diisi"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	invitation, err := findInvitation(ctx, s.Repo, req.Token)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if invitation == nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Undangan tidak valid atau expired"})
+	}
+
+	alumni, err := s.AlumniRepo.GetByID(ctx, invitation.AlumniID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if alumni == nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Undangan tidak valid atau expired"})
+	}
+	if !alumni.UserID.IsZero() {
+		return c.Status(409).JSON(fiber.Map{"error": inviteSkipMessages[inviteSkipAlreadyLinked]})
+	}
+
+	emailLocal := strings.SplitN(invitation.Email, "@", 2)[0]
+	if errs := utils.ValidatePassword("password", req.Password, req.Username, emailLocal, alumni.NIM); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	existingUser, _ := s.UserRepo.FindByUsername(ctx, req.Username)
+	if existingUser != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Username sudah terdaftar"})
+	}
+	existingUser, _ = s.UserRepo.FindByEmail(ctx, invitation.Email)
+	if existingUser != nil {
+		return c.Status(409).JSON(fiber.Map{"error": inviteSkipMessages[inviteSkipEmailRegistered]})
+	}
+
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal hash password"})
+	}
+
+	// Link undangan dikirim ke email alumni, jadi email sudah terbukti dimiliki
+	now := time.Now()
+	user, err := s.UserRepo.Create(ctx, &model.User{
+		Username:        req.Username,
+		Email:           invitation.Email,
+		EmailVerified:   true,
+		EmailVerifiedAt: &now,
+		Password:        hashedPassword,
+		Role:            model.RoleUser,
+		CreatedAt:       now,
+	})
+	if err != nil {
+		if _, dup := repository.DuplicateKeyField(err); dup {
+			return storeFailed(c, err)
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal membuat user"})
+	}
+
+	linked, err := s.AlumniRepo.LinkUser(ctx, alumni.ID, user.ID)
+	if err != nil || !linked {
+		// Alumni ditautkan ke akun lain di antara pengecekan dan pembuatan akun
+		_ = s.UserRepo.HardDelete(ctx, user.ID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(409).JSON(fiber.Map{"error": inviteSkipMessages[inviteSkipAlreadyLinked]})
+	}
+
+	if _, err := s.Repo.MarkAccepted(ctx, invitation.ID, user.ID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"message": "Akun berhasil dibuat dan ditautkan ke data alumni, silakan login",
+		"user": fiber.Map{
+			"id":             user.ID,
+			"username":       user.Username,
+			"email":          user.Email,
+			"email_verified": user.EmailVerified,
+			"role":           user.Role,
+			"alumni_id":      alumni.ID,
+		},
+	})
+}
@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"gofiber-mongo/app/model"
+	"gofiber-mongo/app/repository"
+	"gofiber-mongo/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MeService melayani endpoint self-service /api/me untuk user yang login.
+// Data alumni pemanggil di-resolve lewat Alumni.UserID, dan setiap akses ke pekerjaan
+// dibatasi pada pekerjaan milik alumni tersebut.
+type MeService struct {
+	UserRepo   *repository.UserRepository
+	AlumniRepo *repository.AlumniRepository
+	Pekerjaan  *PekerjaanService
+}
+
+func NewMeService(userRepo *repository.UserRepository, alumniRepo *repository.AlumniRepository,
+	pekerjaan *PekerjaanService) *MeService {
+	return &MeService{
+		UserRepo:   userRepo,
+		AlumniRepo: alumniRepo,
+		Pekerjaan:  pekerjaan,
+	}
+}
+
+// myAlumni mengambil data alumni yang ditautkan ke user pemanggil (nil jika belum ditautkan).
+func (s *MeService) myAlumni(ctx context.Context, c *fiber.Ctx) (*model.Alumni, error) {
+	return s.AlumniRepo.GetByUserID(ctx, c.Locals("user_id").(primitive.ObjectID))
+}
+
+// myPekerjaan mengambil pekerjaan berdasarkan :id jika milik alumni pemanggil.
+func (s *MeService) myPekerjaan(ctx context.Context, c *fiber.Ctx, alumni *model.Alumni) (*model.PekerjaanAlumni, error) {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return nil, nil
+	}
+	pekerjaan, err := s.Pekerjaan.Repo.GetByID(ctx, id)
+	if err != nil || pekerjaan == nil {
+		return nil, err
+	}
+	// Pekerjaan alumni lain diperlakukan seperti tidak ada
+	if pekerjaan.AlumniID != alumni.ID {
+		return nil, nil
+	}
+	return pekerjaan, nil
+}
+
+func notLinked(c *fiber.Ctx) error {
+	return c.Status(404).JSON(fiber.Map{"error": "Akun belum ditautkan ke data alumni"})
+}
+
+// HandleGetMe godoc
+// @Summary Profil saya
+// @Description Mengambil data akun user yang login beserta permission dan data alumni yang ditautkan (jika ada)
+// @Tags Me
+// @Produce json
+// @Success 200 {object} map[string]interface{} "user, permissions, alumni"
+// @Failure 404 {object} map[string]interface{} "User tidak ditemukan"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me [get]
+// @Security BearerAuth
+func (s *MeService) Get(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := s.UserRepo.FindByID(ctx, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if user == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "User tidak ditemukan"})
+	}
+
+	alumni, err := s.myAlumni(ctx, c)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	permissions, _ := c.Locals("permissions").([]string)
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"user":        user,
+			"permissions": permissions,
+			"alumni":      alumni,
+		},
+	})
+}
+
+// HandleGetMyAlumni godoc
+// @Summary Data alumni saya
+// @Description Mengambil data alumni yang ditautkan ke user yang login
+// @Tags Me
+// @Produce json
+// @Success 200 {object} map[string]interface{} "alumni data"
+// @Failure 404 {object} map[string]interface{} "Akun belum ditautkan"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/alumni [get]
+// @Security BearerAuth
+func (s *MeService) GetAlumni(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	alumni, err := s.myAlumni(ctx, c)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if alumni == nil {
+		return notLinked(c)
+	}
+	setETag(c, alumni.Version)
+	return c.JSON(fiber.Map{"success": true, "data": alumni})
+}
+
+// HandleUpdateMyAlumni godoc
+// @Summary Ubah data alumni saya
+// @Description Memperbarui data kontak (no telepon, alamat) alumni milik user yang login
+// @Tags Me
+// @Accept json
+// @Produce json
+// @Param If-Match header string false "ETag dari GET /me/alumni (wajib jika REQUIRE_IF_MATCH=true)"
+// @Param body body model.UpdateMyAlumniRequest true "Data kontak"
+// @Success 200 {object} map[string]interface{} "updated alumni"
+// @Failure 400 {object} map[string]interface{} "Request tidak valid"
+// @Failure 404 {object} map[string]interface{} "Akun belum ditautkan"
+// @Failure 412 {object} map[string]interface{} "Data sudah diubah pihak lain"
+// @Failure 422 {object} model.ValidationErrorResponse "Validasi gagal"
+// @Failure 428 {object} map[string]interface{} "If-Match wajib diisi"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/alumni [put]
+// @Security BearerAuth
+func (s *MeService) UpdateAlumni(c *fiber.Ctx) error {
+	var req model.UpdateMyAlumniRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
+	}
+	if errs := utils.ValidateStruct(req); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	alumni, err := s.myAlumni(ctx, c)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if alumni == nil {
+		return notLinked(c)
+	}
+
+	if ok, err := checkIfMatch(c, alumni.Version); !ok {
+		return err
+	}
+
+	updated, err := s.AlumniRepo.UpdateContact(ctx, alumni.ID, &alumni.Version, req)
+	if err != nil {
+		return storeFailed(c, err)
+	}
+	setETag(c, updated.Version)
+	return c.JSON(fiber.Map{"success": true, "data": updated})
+}
+
+// HandleGetMyPekerjaan godoc
+// @Summary Daftar pekerjaan saya
+// @Description Mengambil daftar pekerjaan milik alumni yang ditautkan ke user yang login
+// @Tags Me
+// @Produce json
+// @Success 200 {object} map[string]interface{} "pekerjaan list"
+// @Failure 404 {object} map[string]interface{} "Akun belum ditautkan"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/pekerjaan [get]
+// @Security BearerAuth
+func (s *MeService) GetPekerjaan(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	alumni, err := s.myAlumni(ctx, c)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if alumni == nil {
+		return notLinked(c)
+	}
+
+	list, err := s.Pekerjaan.Repo.GetByAlumniID(ctx, alumni.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if list == nil {
+		list = []model.PekerjaanAlumni{}
+	}
+	return c.JSON(fiber.Map{"success": true, "data": list})
+}
+
+// HandleGetMyPekerjaanByID godoc
+// @Summary Detail pekerjaan saya
+// @Description Mengambil pekerjaan milik alumni user yang login berdasarkan ID
+// @Tags Me
+// @Produce json
+// @Param id path string true "Pekerjaan ID"
+// @Success 200 {object} map[string]interface{} "pekerjaan data"
+// @Failure 404 {object} map[string]interface{} "Akun belum ditautkan atau pekerjaan tidak ditemukan"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/pekerjaan/{id} [get]
+// @Security BearerAuth
+func (s *MeService) GetPekerjaanByID(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	alumni, err := s.myAlumni(ctx, c)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if alumni == nil {
+		return notLinked(c)
+	}
+
+	pekerjaan, err := s.myPekerjaan(ctx, c, alumni)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if pekerjaan == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Pekerjaan tidak ditemukan"})
+	}
+	setETag(c, pekerjaan.Version)
+	return c.JSON(fiber.Map{"success": true, "data": pekerjaan})
+}
+
+// HandleCreateMyPekerjaan godoc
+// @Summary Tambah pekerjaan saya
+// @Description Menambah pekerjaan untuk alumni user yang login. alumni_id pada body diabaikan.
+// @Tags Me
+// @Accept json
+// @Produce json
+// @Param body body model.CreatePekerjaanRequest true "Pekerjaan data"
+// @Success 201 {object} map[string]interface{} "created pekerjaan"
+// @Failure 400 {object} map[string]interface{} "Request tidak valid"
+// @Failure 404 {object} map[string]interface{} "Akun belum ditautkan"
+// @Failure 422 {object} model.ValidationErrorResponse "Validasi gagal"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/pekerjaan [post]
+// @Security BearerAuth
+func (s *MeService) CreatePekerjaan(c *fiber.Ctx) error {
+	var req model.CreatePekerjaanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	alumni, err := s.myAlumni(ctx, c)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if alumni == nil {
+		return notLinked(c)
+	}
+
+	req.AlumniID = alumni.ID.Hex()
+	if errs := utils.ValidateStruct(req); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	newData, err := s.Pekerjaan.Repo.Create(ctx, req)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(fiber.Map{"success": true, "data": newData})
+}
+
+// HandleUpdateMyPekerjaan godoc
+// @Summary Ubah pekerjaan saya
+// @Description Memperbarui pekerjaan milik alumni user yang login
+// @Tags Me
+// @Accept json
+// @Produce json
+// @Param id path string true "Pekerjaan ID"
+// @Param If-Match header string false "ETag dari GET /me/pekerjaan/{id} (wajib jika REQUIRE_IF_MATCH=true)"
+// @Param body body model.UpdatePekerjaanRequest true "Pekerjaan data"
+// @Success 200 {object} map[string]interface{} "updated pekerjaan"
+// @Failure 400 {object} map[string]interface{} "Request tidak valid"
+// @Failure 404 {object} map[string]interface{} "Akun belum ditautkan atau pekerjaan tidak ditemukan"
+// @Failure 412 {object} map[string]interface{} "Data sudah diubah pihak lain"
+// @Failure 422 {object} model.ValidationErrorResponse "Validasi gagal"
+// @Failure 428 {object} map[string]interface{} "If-Match wajib diisi"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/pekerjaan/{id} [put]
+// @Security BearerAuth
+func (s *MeService) UpdatePekerjaan(c *fiber.Ctx) error {
+	var req model.UpdatePekerjaanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
+	}
+	if errs := utils.ValidateStruct(req); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	alumni, err := s.myAlumni(ctx, c)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if alumni == nil {
+		return notLinked(c)
+	}
+
+	pekerjaan, err := s.myPekerjaan(ctx, c, alumni)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if pekerjaan == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Pekerjaan tidak ditemukan"})
+	}
+
+	if ok, err := checkIfMatch(c, pekerjaan.Version); !ok {
+		return err
+	}
+
+	updated, err := s.Pekerjaan.Repo.Update(ctx, pekerjaan.ID, &pekerjaan.Version, req)
+	if err != nil {
+		return storeFailed(c, err)
+	}
+	setETag(c, updated.Version)
+	return c.JSON(fiber.Map{"success": true, "data": updated})
+}
+
+// HandleDeleteMyPekerjaan godoc
+// @Summary Hapus pekerjaan saya
+// @Description Menghapus (soft delete) pekerjaan milik alumni user yang login. Bisa direstore lewat /trash/pekerjaan.
+// @Tags Me
+// @Produce json
+// @Param id path string true "Pekerjaan ID"
+// @Param If-Match header string false "ETag dari GET /me/pekerjaan/{id} (wajib jika REQUIRE_IF_MATCH=true)"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 404 {object} map[string]interface{} "Akun belum ditautkan atau pekerjaan tidak ditemukan"
+// @Failure 412 {object} map[string]interface{} "Data sudah diubah pihak lain"
+// @Failure 428 {object} map[string]interface{} "If-Match wajib diisi"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/pekerjaan/{id} [delete]
+// @Security BearerAuth
+func (s *MeService) DeletePekerjaan(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	alumni, err := s.myAlumni(ctx, c)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if alumni == nil {
+		return notLinked(c)
+	}
+
+	pekerjaan, err := s.myPekerjaan(ctx, c, alumni)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if pekerjaan == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Pekerjaan tidak ditemukan"})
+	}
+
+	if ok, err := checkIfMatch(c, pekerjaan.Version); !ok {
+		return err
+	}
+
+	if err := s.Pekerjaan.Repo.SoftDelete(ctx, pekerjaan.ID, &pekerjaan.Version); err != nil {
+		return storeFailed(c, err)
+	}
+	return c.JSON(fiber.Map{"success": true, "message": "Pekerjaan berhasil dihapus"})
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"gofiber-mongo/app/model"
+	"gofiber-mongo/utils"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const mergePatchContentType = "application/merge-patch+json"
+
+// applyMergePatch menerapkan JSON Merge Patch (RFC 7396): null menghapus field, objek
+// digabung secara rekursif, nilai lain menggantikan nilai lama.
+func applyMergePatch(target, patch any) any {
+	patchObj, isObject := patch.(map[string]any)
+	if !isObject {
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = map[string]any{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = applyMergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
+
+// mergePatchRequest menerapkan body merge patch pada current (request update yang dibentuk dari
+// data tersimpan) lalu mengisi out dengan hasilnya dan memvalidasinya. Field yang dihapus
+// dengan null menjadi nilai kosong sehingga field wajib akan gagal validasi.
+// Jika ok bernilai false, respons error sudah dikirim dan err adalah hasil pengirimannya.
+func mergePatchRequest(c *fiber.Ctx, current, out any) (ok bool, err error) {
+	mediaType := strings.TrimSpace(strings.SplitN(c.Get(fiber.HeaderContentType), ";", 2)[0])
+	if mediaType != mergePatchContentType && mediaType != fiber.MIMEApplicationJSON {
+		c.Set("Accept-Patch", mergePatchContentType)
+		return false, c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+			"error": "Content-Type harus " + mergePatchContentType,
+		})
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(c.Body()))
+	decoder.UseNumber()
+	var patch any
+	if err := decoder.Decode(&patch); err != nil {
+		return false, c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
+	}
+	patchObj, isObject := patch.(map[string]any)
+	if !isObject {
+		return false, c.Status(400).JSON(fiber.Map{"error": "Merge patch harus berupa objek JSON"})
+	}
+
+	raw, err := json.Marshal(current)
+	if err != nil {
+		return false, c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	var target map[string]any
+	decoder = json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&target); err != nil {
+		return false, c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Field di luar request update (mis. nim, alumni_id, is_delete) tidak bisa diubah lewat PATCH
+	errs := []model.FieldError{}
+	for key := range patchObj {
+		if _, known := target[key]; !known {
+			errs = append(errs, model.FieldError{Field: key, Code: "unknown_field", Message: key + " tidak dikenal atau tidak bisa diubah"})
+		}
+	}
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+		return false, validationFailed(c, errs)
+	}
+
+	merged, err := json.Marshal(applyMergePatch(target, patchObj))
+	if err != nil {
+		return false, c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := json.Unmarshal(merged, out); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return false, validationFailed(c, []model.FieldError{{
+				Field:   typeErr.Field,
+				Code:    "invalid_type",
+				Message: typeErr.Field + " harus bertipe " + typeErr.Type.String(),
+			}})
+		}
+		return false, c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
+	}
+
+	if errs := utils.ValidateStruct(out); len(errs) > 0 {
+		return false, validationFailed(c, errs)
+	}
+	return true, nil
+}
+
+// alumniUpdateRequest membentuk request update dari data alumni tersimpan (dasar merge patch).
+func alumniUpdateRequest(a *model.Alumni) model.UpdateAlumniRequest {
+	req := model.UpdateAlumniRequest{
+		Nama:       a.Nama,
+		Jurusan:    a.Jurusan,
+		Angkatan:   a.Angkatan,
+		TahunLulus: a.TahunLulus,
+		Email:      a.Email,
+		NoTelepon:  a.NoTelepon,
+	}
+	if a.Alamat != nil {
+		req.Alamat = *a.Alamat
+	}
+	return req
+}
+
+// pekerjaanUpdateRequest membentuk request update dari data pekerjaan tersimpan (dasar merge patch).
+func pekerjaanUpdateRequest(p *model.PekerjaanAlumni) model.UpdatePekerjaanRequest {
+	req := model.UpdatePekerjaanRequest{
+		NamaPerusahaan:     p.NamaPerusahaan,
+		PosisiJabatan:      p.PosisiJabatan,
+		BidangIndustri:     p.BidangIndustri,
+		LokasiKerja:        p.LokasiKerja,
+		GajiRange:          p.GajiRange,
+		TanggalMulaiKerja:  p.TanggalMulaiKerja.Format("2006-01-02"),
+		StatusPekerjaan:    p.StatusPekerjaan,
+		DeskripsiPekerjaan: p.DeskripsiPekerjaan,
+	}
+	if p.TanggalSelesaiKerja != nil {
+		selesai := p.TanggalSelesaiKerja.Format("2006-01-02")
+		req.TanggalSelesaiKerja = &selesai
+	}
+	return req
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"encoding/json"
+	"gofiber-mongo/app/model"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func decodeJSON(t *testing.T, s string) any {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("json %q: %v", s, err)
+	}
+	return v
+}
+
+// Contoh dari RFC 7396 Appendix A.
+func TestApplyMergePatchRFC7396(t *testing.T) {
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		got := applyMergePatch(decodeJSON(t, tt.target), decodeJSON(t, tt.patch))
+		if want := decodeJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+			t.Errorf("applyMergePatch(%s, %s) = %v, want %s", tt.target, tt.patch, got, tt.want)
+		}
+	}
+}
+
+func TestMergePatchRequest(t *testing.T) {
+	current := model.UpdateAlumniRequest{
+		Nama:       "Budi Santoso",
+		Jurusan:    "Informatika",
+		Angkatan:   2018,
+		TahunLulus: 2022,
+		Email:      "budi@example.com",
+		Alamat:     "Surabaya",
+	}
+
+	var got model.UpdateAlumniRequest
+	app := fiber.New()
+	app.Patch("/", func(c *fiber.Ctx) error {
+		got = model.UpdateAlumniRequest{}
+		if ok, err := mergePatchRequest(c, current, &got); !ok {
+			return err
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		wantField   string
+		want        *model.UpdateAlumniRequest
+	}{
+		{
+			name:        "ganti satu field",
+			contentType: mergePatchContentType,
+			body:        `{"alamat":"Malang"}`,
+			status:      fiber.StatusNoContent,
+			want: func() *model.UpdateAlumniRequest {
+				r := current
+				r.Alamat = "Malang"
+				return &r
+			}(),
+		},
+		{
+			name:        "null mengosongkan field opsional",
+			contentType: fiber.MIMEApplicationJSON + "; charset=utf-8",
+			body:        `{"alamat":null}`,
+			status:      fiber.StatusNoContent,
+			want: func() *model.UpdateAlumniRequest {
+				r := current
+				r.Alamat = ""
+				return &r
+			}(),
+		},
+		{name: "null pada field wajib gagal validasi", contentType: mergePatchContentType, body: `{"nama":null}`, status: fiber.StatusUnprocessableEntity, wantField: "nama"},
+		{name: "field tidak dikenal", contentType: mergePatchContentType, body: `{"nim":"123"}`, status: fiber.StatusUnprocessableEntity, wantField: "nim"},
+		{name: "tipe salah", contentType: mergePatchContentType, body: `{"angkatan":"dua ribu"}`, status: fiber.StatusUnprocessableEntity, wantField: "angkatan"},
+		{name: "aturan antar field tetap dicek", contentType: mergePatchContentType, body: `{"tahun_lulus":2010}`, status: fiber.StatusUnprocessableEntity, wantField: "tahun_lulus"},
+		{name: "bukan objek", contentType: mergePatchContentType, body: `["nama"]`, status: fiber.StatusBadRequest},
+		{name: "json rusak", contentType: mergePatchContentType, body: `{"nama":`, status: fiber.StatusBadRequest},
+		{name: "content type lain", contentType: "text/plain", body: `{"alamat":"Malang"}`, status: fiber.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, tt.contentType)
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status %d, want %d", resp.StatusCode, tt.status)
+			}
+			if tt.status == fiber.StatusUnsupportedMediaType && resp.Header.Get("Accept-Patch") != mergePatchContentType {
+				t.Fatalf("Accept-Patch = %q", resp.Header.Get("Accept-Patch"))
+			}
+			if tt.want != nil && got != *tt.want {
+				t.Fatalf("hasil = %+v, want %+v", got, *tt.want)
+			}
+			if tt.wantField != "" {
+				var body model.ValidationErrorResponse
+				if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+					t.Fatal(err)
+				}
+				if len(body.Errors) == 0 || body.Errors[0].Field != tt.wantField {
+					t.Fatalf("errors = %+v, want field %s", body.Errors, tt.wantField)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"gofiber-mongo/app/model"
+	"gofiber-mongo/app/repository"
+	"gofiber-mongo/oidc"
+	"gofiber-mongo/utils"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// OIDCService menangani login SSO lewat identity provider OpenID Connect
+// (authorization code + PKCE). Setelah identitas terverifikasi, token diterbitkan
+// dengan mekanisme yang sama seperti /login.
+type OIDCService struct {
+	Auth           *AuthService
+	Provider       *oidc.Provider
+	StateRepo      *repository.OIDCStateRepository
+	InvitationRepo *repository.InvitationRepository
+	AlumniRepo     *repository.AlumniRepository
+}
+
+// NewOIDCService membaca konfigurasi provider dari environment. Provider bernilai nil
+// jika OIDC_ISSUER / OIDC_CLIENT_ID tidak di-set; endpoint SSO lalu mengembalikan 404.
+func NewOIDCService(db *mongo.Database, auth *AuthService) *OIDCService {
+	s := &OIDCService{
+		Auth:           auth,
+		StateRepo:      repository.NewOIDCStateRepository(db),
+		InvitationRepo: repository.NewInvitationRepository(db),
+		AlumniRepo:     repository.NewAlumniRepository(db),
+	}
+	if cfg, ok := oidc.ConfigFromEnv(utils.AppURL("/api/oidc/callback")); ok {
+		s.Provider = oidc.NewProvider(cfg)
+	}
+	return s
+}
+
+var usernameUnsafeChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// uniqueUsername menurunkan username dari claim provider dan menambahkan sufiks acak jika sudah dipakai.
+func (s *OIDCService) uniqueUsername(ctx context.Context, claims *oidc.Claims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base = strings.SplitN(claims.Email, "@", 2)[0]
+	}
+	base = usernameUnsafeChars.ReplaceAllString(base, "")
+	if base == "" {
+		base = "user"
+	}
+
+	candidate := base
+	for i := 0; i < 5; i++ {
+		existing, err := s.Auth.UserRepo.FindByUsername(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if existing == nil {
+			return candidate, nil
+		}
+		suffix := make([]byte, 3)
+		if _, err := rand.Read(suffix); err != nil {
+			return "", err
+		}
+		candidate = base + "-" + hex.EncodeToString(suffix)
+	}
+	return candidate, nil
+}
+
+// HandleLogin godoc
+// @Summary Login SSO (OIDC)
+// @Description Memulai login SSO: redirect ke identity provider dengan PKCE. Dengan redirect=false, URL dikembalikan sebagai JSON.
+// @Tags Auth
+// @Produce json
+// @Param redirect query bool false "false untuk menerima authorization_url sebagai JSON" default(true)
+// @Param invitation query string false "Token dari link undangan, untuk membuat akun lewat SSO yang langsung tertaut ke data alumni"
+// @Success 200 {object} map[string]interface{} "authorization_url"
+// @Success 302 {string} string "redirect ke identity provider"
+// @Failure 400 {object} map[string]interface{} "Undangan tidak valid atau expired"
+// @Failure 404 {object} map[string]interface{} "SSO tidak dikonfigurasi"
+// @Failure 502 {object} map[string]interface{} "Identity provider tidak dapat dihubungi"
+// @Router /oidc/login [get]
+func (s *OIDCService) Login(c *fiber.Ctx) error {
+	if s.Provider == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Login SSO tidak dikonfigurasi"})
+	}
+
+	state, err := oidc.NewState()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal generate state"})
+	}
+	nonce, err := oidc.NewState()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal generate nonce"})
+	}
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal generate code verifier"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var invitationHash string
+	if token := c.Query("invitation"); token != "" {
+		invitation, err := findInvitation(ctx, s.InvitationRepo, token)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		if invitation == nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Undangan tidak valid atau expired"})
+		}
+		invitationHash = invitation.TokenHash
+	}
+
+	authURL, err := s.Provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		log.Printf("OIDC: %v", err)
+		return c.Status(502).JSON(fiber.Map{"error": "Identity provider tidak dapat dihubungi"})
+	}
+
+	err = s.StateRepo.Create(ctx, &model.OIDCState{
+		StateHash:      utils.HashToken(state),
+		CodeVerifier:   verifier,
+		Nonce:          nonce,
+		InvitationHash: invitationHash,
+		ExpiresAt:      time.Now().Add(utils.DurationFromEnv("OIDC_STATE_TTL", 10*time.Minute)),
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if c.Query("redirect") == "false" {
+		return c.JSON(fiber.Map{"success": true, "authorization_url": authURL})
+	}
+	return c.Redirect(authURL, fiber.StatusFound)
+}
+
+// HandleCallback godoc
+// @Summary Callback login SSO (OIDC)
+// @Description Menukar authorization code dari identity provider, memverifikasi ID token, lalu membuat atau menautkan user.
+// @Description User ditautkan berdasarkan subject, atau email yang sudah diverifikasi provider. Response sama dengan /login.
+// @Description Jika OPEN_REGISTRATION=false, akun baru hanya dibuat jika login dimulai dari link undangan dengan email yang sama.
+// @Tags Auth
+// @Produce json
+// @Param code query string true "Authorization code"
+// @Param state query string true "State dari /oidc/login"
+// @Success 200 {object} model.LoginResponse
+// @Failure 400 {object} map[string]interface{} "State atau code tidak valid"
+// @Failure 401 {object} map[string]interface{} "Login SSO gagal"
+// @Failure 403 {object} map[string]interface{} "Akun tidak diizinkan"
+// @Failure 404 {object} map[string]interface{} "SSO tidak dikonfigurasi"
+// @Failure 409 {object} map[string]interface{} "Alumni dari undangan sudah punya akun"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /oidc/callback [get]
+func (s *OIDCService) Callback(c *fiber.Ctx) error {
+	if s.Provider == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Login SSO tidak dikonfigurasi"})
+	}
+
+	if errCode := c.Query("error"); errCode != "" {
+		return c.Status(401).JSON(fiber.Map{
+			"error":       "Login SSO dibatalkan atau ditolak provider",
+			"provider":    errCode,
+			"description": c.Query("error_description"),
+		})
+	}
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Parameter code dan state harus diisi"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	pending, err := s.StateRepo.Consume(ctx, utils.HashToken(state))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if pending == nil {
+		return c.Status(400).JSON(fiber.Map{"error": "State tidak valid atau expired, silakan ulangi login SSO"})
+	}
+
+	token, err := s.Provider.Exchange(ctx, code, pending.CodeVerifier)
+	if err != nil {
+		log.Printf("OIDC: %v", err)
+		return c.Status(401).JSON(fiber.Map{"error": "Login SSO gagal"})
+	}
+	claims, err := s.Provider.VerifyIDToken(ctx, token.IDToken, pending.Nonce)
+	if err != nil {
+		log.Printf("OIDC: %v", err)
+		return c.Status(401).JSON(fiber.Map{"error": "Login SSO gagal"})
+	}
+
+	issuer := s.Provider.Issuer()
+	user, err := s.Auth.UserRepo.FindByOIDCSubject(ctx, issuer, claims.Subject)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Login pertama: tautkan ke akun dengan email yang sama, hanya jika provider sudah memverifikasi email tersebut
+	if user == nil && claims.Email != "" && claims.EmailVerified {
+		user, err = s.Auth.UserRepo.FindByEmail(ctx, claims.Email)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		if user != nil {
+			if user.OIDCSubject != "" {
+				return c.Status(403).JSON(fiber.Map{"error": "Email sudah ditautkan ke identitas SSO lain"})
+			}
+			if err := s.Auth.UserRepo.LinkOIDC(ctx, user.ID, issuer, claims.Subject); err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+			}
+		}
+	}
+
+	if user == nil {
+		if !utils.BoolFromEnv("OIDC_ALLOW_SIGNUP", true) {
+			return c.Status(403).JSON(fiber.Map{"error": "Akun belum terdaftar. Hubungi admin"})
+		}
+		if claims.Email == "" {
+			return c.Status(400).JSON(fiber.Map{"error": "Identity provider tidak mengirim email"})
+		}
+
+		// Sama seperti /register: jika registrasi terbuka dinonaktifkan, akun baru hanya lewat undangan
+		var invitation *model.Invitation
+		if pending.InvitationHash != "" {
+			invitation, err = s.InvitationRepo.FindActiveByHash(ctx, pending.InvitationHash)
+			if err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+			}
+			if invitation == nil {
+				return c.Status(400).JSON(fiber.Map{"error": "Undangan tidak valid atau expired"})
+			}
+			if !claims.EmailVerified || !strings.EqualFold(invitation.Email, claims.Email) {
+				return c.Status(403).JSON(fiber.Map{"error": "Email akun SSO tidak sama dengan email undangan"})
+			}
+		} else if !utils.BoolFromEnv("OPEN_REGISTRATION", true) {
+			return c.Status(403).JSON(fiber.Map{"error": "Registrasi terbuka dinonaktifkan. Gunakan link undangan dari admin"})
+		}
+
+		existing, err := s.Auth.UserRepo.FindByEmail(ctx, claims.Email)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		if existing != nil {
+			return c.Status(403).JSON(fiber.Map{"error": "Email sudah terdaftar dan belum diverifikasi oleh identity provider"})
+		}
+
+		username, err := s.uniqueUsername(ctx, claims)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		// User SSO tidak punya password; password acak bisa diganti lewat lupa password
+		random, _, err := utils.GenerateOpaqueToken()
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Gagal generate password"})
+		}
+		hashedPassword, err := utils.HashPassword(random)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Gagal hash password"})
+		}
+
+		role := os.Getenv("OIDC_DEFAULT_ROLE")
+		if role == "" {
+			role = model.RoleUser
+		}
+		newUser := &model.User{
+			Username:      username,
+			Email:         claims.Email,
+			EmailVerified: claims.EmailVerified,
+			Password:      hashedPassword,
+			Role:          role,
+			IsDelete:      false,
+			CreatedAt:     time.Now(),
+			OIDCIssuer:    issuer,
+			OIDCSubject:   claims.Subject,
+		}
+		if claims.EmailVerified {
+			now := time.Now()
+			newUser.EmailVerifiedAt = &now
+		}
+		user, err = s.Auth.UserRepo.Create(ctx, newUser)
+		if err != nil {
+			if _, dup := repository.DuplicateKeyField(err); dup {
+				return storeFailed(c, err)
+			}
+			return c.Status(500).JSON(fiber.Map{"error": "Gagal membuat user"})
+		}
+
+		if invitation != nil {
+			linked, err := s.AlumniRepo.LinkUser(ctx, invitation.AlumniID, user.ID)
+			if err != nil || !linked {
+				// Alumni sudah ditautkan ke akun lain sejak undangan dibuat
+				_ = s.Auth.UserRepo.HardDelete(ctx, user.ID)
+				if err != nil {
+					return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+				}
+				return c.Status(409).JSON(fiber.Map{"error": inviteSkipMessages[inviteSkipAlreadyLinked]})
+			}
+			if _, err := s.InvitationRepo.MarkAccepted(ctx, invitation.ID, user.ID); err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+			}
+		}
+	}
+
+	if user.IsDelete {
+		s.Auth.loginFailed(c, model.SecurityEventLoginOIDC, model.SecurityOutcomeBlocked, "account_deleted", user.Username, user)
+		return c.Status(403).JSON(fiber.Map{"error": "Akun sudah dinonaktifkan"})
+	}
+
+	if !user.EmailVerified && claims.EmailVerified && strings.EqualFold(user.Email, claims.Email) {
+		if err := s.Auth.UserRepo.MarkEmailVerified(ctx, user.ID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		user.EmailVerified = true
+	}
+	if !user.EmailVerified && utils.BoolFromEnv("REQUIRE_EMAIL_VERIFICATION", true) {
+		s.Auth.loginFailed(c, model.SecurityEventLoginOIDC, model.SecurityOutcomeBlocked, "email_not_verified", user.Username, user)
+		return c.Status(403).JSON(fiber.Map{
+			"error": "Email belum diverifikasi. Silakan cek email atau minta link verifikasi baru",
+			"code":  "email_not_verified",
+		})
+	}
+
+	if user.TwoFactorEnabled {
+		return s.Auth.twoFactorChallenge(ctx, c, user)
+	}
+
+	resp, err := s.Auth.startSession(ctx, c, user)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal generate token"})
+	}
+	s.Auth.recordEvent(c, userSecurityEvent(model.SecurityEventLoginOIDC, model.SecurityOutcomeSuccess, user))
+	return c.JSON(resp)
+}
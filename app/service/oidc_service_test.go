@@ -0,0 +1,331 @@
+package service
+
+import (
+	"encoding/json"
+	"gofiber-mongo/app/model"
+	"gofiber-mongo/oidc"
+	"gofiber-mongo/oidc/oidctest"
+	"gofiber-mongo/utils"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+const testOIDCEmail = "staff@example.ac.id"
+
+// startMockOIDC menjalankan provider dari package oidctest di port acak dan mengarahkan
+// konfigurasi OIDC API ke sana.
+func startMockOIDC(t *testing.T) string {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(nil)
+	issuer := "http://" + srv.Listener.Addr().String()
+	provider, err := oidctest.NewServer(oidctest.Config{
+		Issuer:        issuer,
+		ClientID:      "alumni-api",
+		Email:         testOIDCEmail,
+		EmailVerified: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Config.Handler = provider.Handler()
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	t.Setenv("OIDC_ISSUER", issuer)
+	t.Setenv("OIDC_CLIENT_ID", "alumni-api")
+	t.Setenv("OIDC_CLIENT_SECRET", "")
+	t.Setenv("OIDC_REDIRECT_URL", "")
+	t.Setenv("APP_URL", "http://api.test")
+	return issuer
+}
+
+func newOIDCTestApp(mt *mtest.T) *fiber.App {
+	svc := NewOIDCService(mt.DB, NewAuthService(mt.DB, nil))
+	app := fiber.New()
+	app.Get("/api/oidc/login", svc.Login)
+	app.Get("/api/oidc/callback", svc.Callback)
+	return app
+}
+
+// oidcLogin menjalankan /api/oidc/login lalu /authorize provider, dan mengembalikan state yang
+// disimpan API beserta URL callback dari provider. query ditambahkan ke URL login.
+func oidcLogin(mt *mtest.T, app *fiber.App, query string) (model.OIDCState, *url.URL) {
+	mt.Helper()
+
+	mt.AddMockResponses(mtest.CreateSuccessResponse())
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/oidc/login"+query, nil), -1)
+	if err != nil {
+		mt.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusFound {
+		mt.Fatalf("login: status %d, want 302", resp.StatusCode)
+	}
+	authURL, err := url.Parse(resp.Header.Get(fiber.HeaderLocation))
+	if err != nil {
+		mt.Fatal(err)
+	}
+
+	var stored model.OIDCState
+	insert := mt.GetStartedEvent()
+	for insert != nil && insert.CommandName != "insert" {
+		insert = mt.GetStartedEvent()
+	}
+	if insert == nil {
+		mt.Fatalf("login: state tidak disimpan, event %v", insert)
+	}
+	doc := insert.Command.Lookup("documents").Array().Index(0).Value().Document()
+	if err := bson.Unmarshal(doc, &stored); err != nil {
+		mt.Fatal(err)
+	}
+
+	q := authURL.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") != oidc.CodeChallengeS256(stored.CodeVerifier) {
+		mt.Fatalf("login: code_challenge %q tidak sesuai code_verifier yang disimpan", q.Get("code_challenge"))
+	}
+	if stored.StateHash != utils.HashToken(q.Get("state")) {
+		mt.Fatal("login: state yang disimpan bukan hash dari state di URL")
+	}
+	if stored.Nonce == "" || q.Get("nonce") != stored.Nonce {
+		mt.Fatalf("login: nonce %q tidak sama dengan yang disimpan", q.Get("nonce"))
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	authResp, err := client.Get(authURL.String())
+	if err != nil {
+		mt.Fatal(err)
+	}
+	authResp.Body.Close()
+	callback, err := url.Parse(authResp.Header.Get("Location"))
+	if err != nil || callback.Query().Get("code") == "" {
+		mt.Fatalf("authorize: redirect tanpa code (status %d)", authResp.StatusCode)
+	}
+	return stored, callback
+}
+
+func stateResponse(state model.OIDCState) bson.D {
+	raw, _ := bson.Marshal(state)
+	return bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: bson.Raw(raw)}}
+}
+
+func toDoc(t testing.TB, v any) bson.D {
+	t.Helper()
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc bson.D
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func emptyCursor(ns string) bson.D {
+	return mtest.CreateCursorResponse(0, ns, mtest.FirstBatch)
+}
+
+func TestOIDCLoginCallback(t *testing.T) {
+	issuer := startMockOIDC(t)
+	t.Setenv("JWT_SECRET", "test-secret-test-secret-test-secret")
+	t.Setenv("REQUIRE_EMAIL_VERIFICATION", "true")
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	user := model.User{
+		ID:            primitive.NewObjectID(),
+		Username:      "staff",
+		Email:         testOIDCEmail,
+		EmailVerified: true,
+		Role:          model.RoleUser,
+		OIDCIssuer:    issuer,
+		OIDCSubject:   "mock|" + testOIDCEmail,
+	}
+	userDoc := toDoc(t, user)
+
+	mt.Run("user tertaut menerima token", func(mt *mtest.T) {
+		app := newOIDCTestApp(mt)
+		stored, callback := oidcLogin(mt, app, "")
+
+		mt.AddMockResponses(
+			stateResponse(stored),
+			mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch, userDoc),
+			mtest.CreateSuccessResponse(), // session
+			mtest.CreateSuccessResponse(), // refresh token
+			mtest.CreateSuccessResponse(), // security event
+		)
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, callback.RequestURI(), nil), -1)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			mt.Fatalf("callback: status %d, want 200", resp.StatusCode)
+		}
+
+		consume := mt.GetStartedEvent()
+		filter := consume.Command.Lookup("query").Document()
+		if got := filter.Lookup("state_hash").StringValue(); got != utils.HashToken(callback.Query().Get("state")) {
+			mt.Fatalf("callback: state_hash %q tidak sesuai state dari provider", got)
+		}
+
+		var login model.LoginResponse
+		if err := json.NewDecoder(resp.Body).Decode(&login); err != nil {
+			mt.Fatal(err)
+		}
+		claims, err := utils.ValidateToken(login.Token)
+		if err != nil {
+			mt.Fatalf("access token tidak valid: %v", err)
+		}
+		if claims.UserID != user.ID || login.RefreshToken == "" {
+			mt.Fatalf("token untuk user %s, want %s", claims.UserID.Hex(), user.ID.Hex())
+		}
+	})
+
+	tests := []struct {
+		name   string
+		tamper func(*model.OIDCState) bson.D
+		status int
+	}{
+		{
+			name:   "state tidak dikenal",
+			tamper: func(*model.OIDCState) bson.D { return bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: nil}} },
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "code_verifier lain ditolak provider",
+			tamper: func(s *model.OIDCState) bson.D {
+				s.CodeVerifier, _ = oidc.NewCodeVerifier()
+				return stateResponse(*s)
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name: "nonce tidak cocok",
+			tamper: func(s *model.OIDCState) bson.D {
+				s.Nonce = "nonce-lain"
+				return stateResponse(*s)
+			},
+			status: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			app := newOIDCTestApp(mt)
+			stored, callback := oidcLogin(mt, app, "")
+
+			mt.AddMockResponses(tt.tamper(&stored))
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, callback.RequestURI(), nil), -1)
+			if err != nil {
+				mt.Fatal(err)
+			}
+			if resp.StatusCode != tt.status {
+				mt.Fatalf("callback: status %d, want %d", resp.StatusCode, tt.status)
+			}
+		})
+	}
+}
+
+func TestOIDCSignupClosedRegistration(t *testing.T) {
+	startMockOIDC(t)
+	t.Setenv("JWT_SECRET", "test-secret-test-secret-test-secret")
+	t.Setenv("OIDC_ALLOW_SIGNUP", "true")
+	t.Setenv("OPEN_REGISTRATION", "false")
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("tanpa undangan ditolak", func(mt *mtest.T) {
+		app := newOIDCTestApp(mt)
+		stored, callback := oidcLogin(mt, app, "")
+
+		mt.AddMockResponses(
+			stateResponse(stored),
+			emptyCursor("db.users"), // subject belum tertaut
+			emptyCursor("db.users"), // email belum terdaftar
+		)
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, callback.RequestURI(), nil), -1)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusForbidden {
+			mt.Fatalf("callback: status %d, want 403", resp.StatusCode)
+		}
+		for e := mt.GetStartedEvent(); e != nil; e = mt.GetStartedEvent() {
+			if e.CommandName == "insert" {
+				mt.Fatalf("user dibuat tanpa undangan: %v", e.Command)
+			}
+		}
+	})
+
+	mt.Run("undangan dengan email sama membuat akun tertaut", func(mt *mtest.T) {
+		app := newOIDCTestApp(mt)
+
+		invitation := model.Invitation{
+			ID:        primitive.NewObjectID(),
+			AlumniID:  primitive.NewObjectID(),
+			Email:     testOIDCEmail,
+			Status:    model.InvitationStatusPending,
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		token, err := utils.GenerateInvitationToken(invitation.ID.Hex(), invitation.ExpiresAt)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		invitation.TokenHash = utils.HashToken(token)
+		invitationDoc := toDoc(mt, invitation)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.invitations", mtest.FirstBatch, invitationDoc))
+		stored, callback := oidcLogin(mt, app, "?invitation="+token)
+		if stored.InvitationHash != invitation.TokenHash {
+			mt.Fatal("login: hash undangan tidak disimpan di state")
+		}
+
+		updated := mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1})
+		mt.AddMockResponses(
+			stateResponse(stored),
+			emptyCursor("db.users"), // subject belum tertaut
+			emptyCursor("db.users"), // email belum terdaftar
+			mtest.CreateCursorResponse(0, "db.invitations", mtest.FirstBatch, invitationDoc),
+			emptyCursor("db.users"),       // email belum terdaftar
+			emptyCursor("db.users"),       // username tersedia
+			mtest.CreateSuccessResponse(), // user
+			updated,                       // alumni ditautkan
+			updated,                       // undangan diterima
+			mtest.CreateSuccessResponse(), // session
+			mtest.CreateSuccessResponse(), // refresh token
+			mtest.CreateSuccessResponse(), // security event
+		)
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, callback.RequestURI(), nil), -1)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			mt.Fatalf("callback: status %d, want 200", resp.StatusCode)
+		}
+
+		var userID primitive.ObjectID
+		linked, accepted := false, false
+		for e := mt.GetStartedEvent(); e != nil; e = mt.GetStartedEvent() {
+			switch {
+			case e.CommandName == "insert" && e.Command.Lookup("insert").StringValue() == "users":
+				userID = e.Command.Lookup("documents").Array().Index(0).Value().Document().Lookup("_id").ObjectID()
+			case e.CommandName == "update" && e.Command.Lookup("update").StringValue() == "alumni":
+				update := e.Command.Lookup("updates").Array().Index(0).Value().Document()
+				linked = update.Lookup("q", "_id").ObjectID() == invitation.AlumniID &&
+					update.Lookup("u", "$set", "user_id").ObjectID() == userID
+			case e.CommandName == "update" && e.Command.Lookup("update").StringValue() == "invitations":
+				update := e.Command.Lookup("updates").Array().Index(0).Value().Document()
+				accepted = update.Lookup("q", "_id").ObjectID() == invitation.ID
+			}
+		}
+		if userID.IsZero() || !linked || !accepted {
+			mt.Fatalf("user %s dibuat, alumni ditautkan %v, undangan diterima %v", userID.Hex(), linked, accepted)
+		}
+	})
+}
@@ -2,9 +2,10 @@ package service
 
 import (
 	"context"
-	"errors"
 	"gofiber-mongo/app/model"
 	"gofiber-mongo/app/repository"
+	"gofiber-mongo/middleware"
+	"gofiber-mongo/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"strconv"
 	"strings"
@@ -26,53 +27,6 @@ func NewPekerjaanService(repo *repository.PekerjaanRepository, db *mongo.Databas
 	}
 }
 
-func (s *PekerjaanService) validateCreateRequest(req model.CreatePekerjaanRequest) error {
-	if req.AlumniID == "" {
-		return errors.New("alumni_id tidak boleh kosong")
-	}
-	if req.NamaPerusahaan == "" {
-		return errors.New("nama_perusahaan tidak boleh kosong")
-	}
-	if req.PosisiJabatan == "" {
-		return errors.New("posisi_jabatan tidak boleh kosong")
-	}
-	if req.BidangIndustri == "" {
-		return errors.New("bidang_industri tidak boleh kosong")
-	}
-	if req.LokasiKerja == "" {
-		return errors.New("lokasi_kerja tidak boleh kosong")
-	}
-	if req.TanggalMulaiKerja == "" {
-		return errors.New("tanggal_mulai_kerja tidak boleh kosong")
-	}
-	if req.StatusPekerjaan == "" {
-		return errors.New("status_pekerjaan tidak boleh kosong")
-	}
-	return nil
-}
-
-func (s *PekerjaanService) validateUpdateRequest(req model.UpdatePekerjaanRequest) error {
-	if req.NamaPerusahaan == "" {
-		return errors.New("nama_perusahaan tidak boleh kosong")
-	}
-	if req.PosisiJabatan == "" {
-		return errors.New("posisi_jabatan tidak boleh kosong")
-	}
-	if req.BidangIndustri == "" {
-		return errors.New("bidang_industri tidak boleh kosong")
-	}
-	if req.LokasiKerja == "" {
-		return errors.New("lokasi_kerja tidak boleh kosong")
-	}
-	if req.TanggalMulaiKerja == "" {
-		return errors.New("tanggal_mulai_kerja tidak boleh kosong")
-	}
-	if req.StatusPekerjaan == "" {
-		return errors.New("status_pekerjaan tidak boleh kosong")
-	}
-	return nil
-}
-
 // HandleRestore godoc
 // @Summary Restore pekerjaan dari trash
 // @Description Mengembalikan pekerjaan yang sudah dihapus (soft delete) kembali ke data aktif
@@ -82,14 +36,12 @@ func (s *PekerjaanService) validateUpdateRequest(req model.UpdatePekerjaanReques
 // @Param id path string true "Pekerjaan ID"
 // @Success 200 {object} map[string]interface{} "success response"
 // @Failure 400 {object} map[string]interface{} "ID tidak valid"
-// @Failure 404 {object} map[string]interface{} "Data tidak ditemukan"
+// @Failure 404 {object} map[string]interface{} "Data tidak ditemukan atau akun belum ditautkan"
 // @Failure 500 {object} map[string]interface{} "error"
 // @Router /trash/pekerjaan/{id}/restore [put]
 // @Security BearerAuth
 func (s *PekerjaanService) Restore(c *fiber.Ctx) error {
-	role := c.Locals("role").(string)
-	userIDStr := c.Locals("user_id").(string)
-	userID, _ := primitive.ObjectIDFromHex(userIDStr)
+	userID := c.Locals("user_id").(primitive.ObjectID)
 
 	idStr := c.Params("id")
 	id, err := primitive.ObjectIDFromHex(idStr)
@@ -101,15 +53,15 @@ func (s *PekerjaanService) Restore(c *fiber.Ctx) error {
 	defer cancel()
 
 	// Get pekerjaan to check alumni_id
-	pekerjaan, err := s.Repo.GetByID(ctx, id)
+	pekerjaan, err := s.Repo.GetTrashedByID(ctx, id)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
-	if pekerjaan == nil || !pekerjaan.IsDelete {
+	if pekerjaan == nil {
 		return c.Status(404).JSON(fiber.Map{"error": "Data tidak ditemukan atau belum dihapus"})
 	}
 
-	if role == "admin" {
+	if middleware.HasPermission(c, model.PermPekerjaanRestore) {
 		err = s.Repo.RestoreByID(ctx, id)
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
@@ -120,10 +72,12 @@ func (s *PekerjaanService) Restore(c *fiber.Ctx) error {
 		})
 	}
 
-	alumniRepo := repository.NewAlumniRepository(s.DB)
-	alumni, err := alumniRepo.GetByID(ctx, userID)
-	if err != nil || alumni == nil {
-		return c.Status(403).JSON(fiber.Map{"error": "Data alumni tidak ditemukan"})
+	alumni, err := repository.NewAlumniRepository(s.DB).GetByUserID(ctx, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if alumni == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Akun belum ditautkan ke data alumni"})
 	}
 
 	if alumni.ID != pekerjaan.AlumniID {
@@ -150,14 +104,12 @@ func (s *PekerjaanService) Restore(c *fiber.Ctx) error {
 // @Param id path string true "Pekerjaan ID"
 // @Success 200 {object} map[string]interface{} "success response"
 // @Failure 400 {object} map[string]interface{} "ID tidak valid"
-// @Failure 404 {object} map[string]interface{} "Data tidak ditemukan"
+// @Failure 404 {object} map[string]interface{} "Data tidak ditemukan atau akun belum ditautkan"
 // @Failure 500 {object} map[string]interface{} "error"
 // @Router /trash/pekerjaan/{id}/permanent [delete]
 // @Security BearerAuth
 func (s *PekerjaanService) HardDelete(c *fiber.Ctx) error {
-	role := c.Locals("role").(string)
-	userIDStr := c.Locals("user_id").(string)
-	userID, _ := primitive.ObjectIDFromHex(userIDStr)
+	userID := c.Locals("user_id").(primitive.ObjectID)
 
 	idStr := c.Params("id")
 	id, err := primitive.ObjectIDFromHex(idStr)
@@ -168,15 +120,15 @@ func (s *PekerjaanService) HardDelete(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	pekerjaan, err := s.Repo.GetByID(ctx, id)
+	pekerjaan, err := s.Repo.GetTrashedByID(ctx, id)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
-	if pekerjaan == nil || !pekerjaan.IsDelete {
+	if pekerjaan == nil {
 		return c.Status(404).JSON(fiber.Map{"error": "Data tidak ditemukan atau belum dihapus (soft delete)"})
 	}
 
-	if role == "admin" {
+	if middleware.HasPermission(c, model.PermPekerjaanHardDelete) {
 		err = s.Repo.HardDeleteByID(ctx, id)
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
@@ -187,10 +139,12 @@ func (s *PekerjaanService) HardDelete(c *fiber.Ctx) error {
 		})
 	}
 
-	alumniRepo := repository.NewAlumniRepository(s.DB)
-	alumni, err := alumniRepo.GetByID(ctx, userID)
-	if err != nil || alumni == nil {
-		return c.Status(403).JSON(fiber.Map{"error": "Data alumni tidak ditemukan"})
+	alumni, err := repository.NewAlumniRepository(s.DB).GetByUserID(ctx, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if alumni == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Akun belum ditautkan ke data alumni"})
 	}
 
 	if alumni.ID != pekerjaan.AlumniID {
@@ -215,18 +169,17 @@ func (s *PekerjaanService) HardDelete(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Success 200 {object} map[string]interface{} "trashed data list"
+// @Failure 404 {object} map[string]interface{} "Akun belum ditautkan"
 // @Failure 500 {object} map[string]interface{} "error"
 // @Router /trash/pekerjaan [get]
 // @Security BearerAuth
 func (s *PekerjaanService) GetTrashed(c *fiber.Ctx) error {
-	role := c.Locals("role").(string)
-	userIDStr := c.Locals("user_id").(string)
-	userID, _ := primitive.ObjectIDFromHex(userIDStr)
+	userID := c.Locals("user_id").(primitive.ObjectID)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if role == "admin" {
+	if middleware.HasPermission(c, model.PermPekerjaanRestore) {
 		data, err := s.Repo.GetTrashed(ctx)
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
@@ -238,10 +191,12 @@ func (s *PekerjaanService) GetTrashed(c *fiber.Ctx) error {
 		})
 	}
 
-	alumniRepo := repository.NewAlumniRepository(s.DB)
-	alumni, err := alumniRepo.GetByID(ctx, userID)
-	if err != nil || alumni == nil {
-		return c.Status(403).JSON(fiber.Map{"error": "Data alumni tidak ditemukan"})
+	alumni, err := repository.NewAlumniRepository(s.DB).GetByUserID(ctx, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if alumni == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Akun belum ditautkan ke data alumni"})
 	}
 
 	ownData, err := s.Repo.GetTrashedByAlumni(ctx, alumni.ID)
@@ -263,9 +218,12 @@ func (s *PekerjaanService) GetTrashed(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param id path string true "Pekerjaan ID"
+// @Param If-Match header string false "ETag dari GET /pekerjaan/{id} (wajib jika REQUIRE_IF_MATCH=true)"
 // @Success 200 {object} map[string]interface{} "success response"
 // @Failure 400 {object} map[string]interface{} "ID tidak valid"
-// @Failure 404 {object} map[string]interface{} "Data tidak ditemukan"
+// @Failure 404 {object} map[string]interface{} "Data tidak ditemukan atau akun belum ditautkan"
+// @Failure 412 {object} map[string]interface{} "Data sudah diubah pihak lain"
+// @Failure 428 {object} map[string]interface{} "If-Match wajib diisi"
 // @Failure 500 {object} map[string]interface{} "error"
 // @Router /pekerjaan/{id} [delete]
 // @Security BearerAuth
@@ -276,20 +234,11 @@ func (s *PekerjaanService) SoftDelete(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
 	}
 
-	role := c.Locals("role").(string)
 	userID := c.Locals("user_id").(primitive.ObjectID)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if role == "admin" {
-		err := s.Repo.SoftDelete(ctx, id)
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
-		}
-		return c.JSON(fiber.Map{"success": true, "message": "Pekerjaan berhasil dihapus oleh admin"})
-	}
-
 	pekerjaan, err := s.Repo.GetByID(ctx, id)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
@@ -298,19 +247,35 @@ func (s *PekerjaanService) SoftDelete(c *fiber.Ctx) error {
 		return c.Status(404).JSON(fiber.Map{"error": "Pekerjaan tidak ditemukan"})
 	}
 
-	alumniRepo := repository.NewAlumniRepository(s.DB)
-	alumni, err := alumniRepo.GetByID(ctx, userID)
-	if err != nil || alumni == nil {
-		return c.Status(403).JSON(fiber.Map{"error": "Data alumni tidak ditemukan"})
+	if middleware.HasPermission(c, model.PermPekerjaanDelete) {
+		if ok, err := checkIfMatch(c, pekerjaan.Version); !ok {
+			return err
+		}
+		err := s.Repo.SoftDelete(ctx, id, &pekerjaan.Version)
+		if err != nil {
+			return storeFailed(c, err)
+		}
+		return c.JSON(fiber.Map{"success": true, "message": "Pekerjaan berhasil dihapus oleh admin"})
+	}
+
+	alumni, err := repository.NewAlumniRepository(s.DB).GetByUserID(ctx, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if alumni == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Akun belum ditautkan ke data alumni"})
 	}
 
 	if alumni.ID != pekerjaan.AlumniID {
 		return c.Status(403).JSON(fiber.Map{"error": "Tidak boleh hapus pekerjaan orang lain"})
 	}
+	if ok, err := checkIfMatch(c, pekerjaan.Version); !ok {
+		return err
+	}
 
-	err = s.Repo.SoftDelete(ctx, id)
+	err = s.Repo.SoftDelete(ctx, id, &pekerjaan.Version)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return storeFailed(c, err)
 	}
 	return c.JSON(fiber.Map{"success": true, "message": "Pekerjaan berhasil dihapus"})
 }
@@ -410,6 +375,7 @@ func (s *PekerjaanService) GetByID(c *fiber.Ctx) error {
 	if data == nil {
 		return c.Status(404).JSON(fiber.Map{"error": "Pekerjaan tidak ditemukan"})
 	}
+	setETag(c, data.Version)
 	return c.JSON(fiber.Map{"success": true, "data": data})
 }
 
@@ -451,6 +417,7 @@ func (s *PekerjaanService) GetByAlumniID(c *fiber.Ctx) error {
 // @Param body body model.CreatePekerjaanRequest true "Pekerjaan data"
 // @Success 201 {object} map[string]interface{} "created pekerjaan"
 // @Failure 400 {object} map[string]interface{} "Request tidak valid"
+// @Failure 422 {object} model.ValidationErrorResponse "Validasi gagal"
 // @Failure 500 {object} map[string]interface{} "error"
 // @Router /pekerjaan [post]
 // @Security BearerAuth
@@ -460,8 +427,8 @@ func (s *PekerjaanService) Create(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
 	}
 
-	if err := s.validateCreateRequest(req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	if errs := utils.ValidateStruct(req); len(errs) > 0 {
+		return validationFailed(c, errs)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -481,9 +448,14 @@ func (s *PekerjaanService) Create(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param id path string true "Pekerjaan ID"
+// @Param If-Match header string false "ETag dari GET /pekerjaan/{id} (wajib jika REQUIRE_IF_MATCH=true)"
 // @Param body body model.UpdatePekerjaanRequest true "Pekerjaan data"
 // @Success 200 {object} map[string]interface{} "updated pekerjaan"
 // @Failure 400 {object} map[string]interface{} "ID tidak valid"
+// @Failure 404 {object} map[string]interface{} "Pekerjaan tidak ditemukan"
+// @Failure 412 {object} map[string]interface{} "Data sudah diubah pihak lain"
+// @Failure 422 {object} model.ValidationErrorResponse "Validasi gagal"
+// @Failure 428 {object} map[string]interface{} "If-Match wajib diisi"
 // @Failure 500 {object} map[string]interface{} "error"
 // @Router /pekerjaan/{id} [put]
 // @Security BearerAuth
@@ -499,17 +471,83 @@ func (s *PekerjaanService) Update(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
 	}
 
-	if err := s.validateUpdateRequest(req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	if errs := utils.ValidateStruct(req); len(errs) > 0 {
+		return validationFailed(c, errs)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	updated, err := s.Repo.Update(ctx, id, req)
+	current, err := s.Repo.GetByID(ctx, id)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
+	if current == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Pekerjaan tidak ditemukan"})
+	}
+	if ok, err := checkIfMatch(c, current.Version); !ok {
+		return err
+	}
+
+	updated, err := s.Repo.Update(ctx, id, &current.Version, req)
+	if err != nil {
+		return storeFailed(c, err)
+	}
+	setETag(c, updated.Version)
+	return c.JSON(fiber.Map{"success": true, "data": updated})
+}
+
+// HandlePatch godoc
+// @Summary Patch pekerjaan
+// @Description Memperbarui sebagian data pekerjaan dengan JSON Merge Patch (RFC 7396). Field yang tidak dikirim tetap, null menghapus field (mis. tanggal_selesai_kerja). Hasil gabungan divalidasi dengan aturan yang sama seperti PUT dan hanya field yang berubah yang ditulis.
+// @Tags Pekerjaan
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path string true "Pekerjaan ID"
+// @Param If-Match header string false "ETag dari GET /pekerjaan/{id} (wajib jika REQUIRE_IF_MATCH=true)"
+// @Param body body model.UpdatePekerjaanRequest true "Field pekerjaan yang diubah"
+// @Success 200 {object} map[string]interface{} "updated pekerjaan"
+// @Failure 400 {object} map[string]interface{} "ID atau request tidak valid"
+// @Failure 404 {object} map[string]interface{} "Pekerjaan tidak ditemukan"
+// @Failure 412 {object} map[string]interface{} "Data sudah diubah pihak lain"
+// @Failure 415 {object} map[string]interface{} "Content-Type tidak didukung"
+// @Failure 422 {object} model.ValidationErrorResponse "Validasi gagal"
+// @Failure 428 {object} map[string]interface{} "If-Match wajib diisi"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /pekerjaan/{id} [patch]
+// @Security BearerAuth
+func (s *PekerjaanService) Patch(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pekerjaan, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if pekerjaan == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Pekerjaan tidak ditemukan"})
+	}
+	if ok, err := checkIfMatch(c, pekerjaan.Version); !ok {
+		return err
+	}
+
+	current := pekerjaanUpdateRequest(pekerjaan)
+	var req model.UpdatePekerjaanRequest
+	if ok, err := mergePatchRequest(c, current, &req); !ok {
+		return err
+	}
+
+	updated, err := s.Repo.Patch(ctx, id, pekerjaan.Version, current, req)
+	if err != nil {
+		return storeFailed(c, err)
+	}
+	setETag(c, updated.Version)
 	return c.JSON(fiber.Map{"success": true, "data": updated})
 }
 
@@ -520,8 +558,12 @@ func (s *PekerjaanService) Update(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param id path string true "Pekerjaan ID"
+// @Param If-Match header string false "ETag dari GET /pekerjaan/{id} (wajib jika REQUIRE_IF_MATCH=true)"
 // @Success 200 {object} map[string]interface{} "success response"
 // @Failure 400 {object} map[string]interface{} "ID tidak valid"
+// @Failure 404 {object} map[string]interface{} "Pekerjaan tidak ditemukan"
+// @Failure 412 {object} map[string]interface{} "Data sudah diubah pihak lain"
+// @Failure 428 {object} map[string]interface{} "If-Match wajib diisi"
 // @Failure 500 {object} map[string]interface{} "error"
 // @Router /pekerjaan/{id} [delete]
 // @Security BearerAuth
@@ -535,8 +577,19 @@ func (s *PekerjaanService) Delete(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := s.Repo.Delete(ctx, id); err != nil {
+	pekerjaan, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
+	if pekerjaan == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Pekerjaan tidak ditemukan"})
+	}
+	if ok, err := checkIfMatch(c, pekerjaan.Version); !ok {
+		return err
+	}
+
+	if err := s.Repo.Delete(ctx, id, &pekerjaan.Version); err != nil {
+		return storeFailed(c, err)
+	}
 	return c.JSON(fiber.Map{"success": true, "message": "Pekerjaan berhasil dihapus"})
 }
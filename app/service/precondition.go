@@ -0,0 +1,49 @@
+package service
+
+import (
+	"gofiber-mongo/utils"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// etag membentuk ETag dari versi dokumen.
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+func setETag(c *fiber.Ctx, version int64) {
+	c.Set(fiber.HeaderETag, etag(version))
+}
+
+// checkIfMatch mencocokkan header If-Match dengan versi dokumen yang baru dibaca. Tanpa header,
+// request diteruskan kecuali REQUIRE_IF_MATCH=true. ETag lemah (W/"...") tidak pernah cocok.
+// Jika ok bernilai false, respons 428 / 412 sudah dikirim.
+func checkIfMatch(c *fiber.Ctx, version int64) (ok bool, err error) {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" {
+		if utils.BoolFromEnv("REQUIRE_IF_MATCH", false) {
+			return false, c.Status(fiber.StatusPreconditionRequired).JSON(fiber.Map{
+				"error": "Header If-Match wajib diisi dengan ETag terbaru",
+			})
+		}
+		return true, nil
+	}
+
+	current := etag(version)
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag == "*" || tag == current {
+			return true, nil
+		}
+	}
+	setETag(c, version)
+	return false, preconditionFailed(c)
+}
+
+// preconditionFailed mengirim 412 saat dokumen sudah diubah pihak lain.
+func preconditionFailed(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{
+		"error": "Data sudah diubah oleh pengguna lain, muat ulang data lalu coba lagi",
+	})
+}
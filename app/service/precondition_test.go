@@ -0,0 +1,56 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCheckIfMatch(t *testing.T) {
+	app := fiber.New()
+	app.Put("/", func(c *fiber.Ctx) error {
+		if ok, err := checkIfMatch(c, 7); !ok {
+			return err
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	tests := []struct {
+		name     string
+		require  string
+		ifMatch  string
+		status   int
+		wantETag string
+	}{
+		{name: "tanpa header", status: fiber.StatusNoContent},
+		{name: "tanpa header saat diwajibkan", require: "true", status: fiber.StatusPreconditionRequired},
+		{name: "versi cocok", ifMatch: `"7"`, status: fiber.StatusNoContent},
+		{name: "versi cocok saat diwajibkan", require: "true", ifMatch: `"7"`, status: fiber.StatusNoContent},
+		{name: "salah satu dari daftar", ifMatch: `"5", "7"`, status: fiber.StatusNoContent},
+		{name: "wildcard", ifMatch: "*", status: fiber.StatusNoContent},
+		{name: "versi lama", ifMatch: `"6"`, status: fiber.StatusPreconditionFailed, wantETag: `"7"`},
+		{name: "etag lemah tidak cocok", ifMatch: `W/"7"`, status: fiber.StatusPreconditionFailed, wantETag: `"7"`},
+		{name: "tanpa tanda kutip", ifMatch: "7", status: fiber.StatusPreconditionFailed, wantETag: `"7"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("REQUIRE_IF_MATCH", tt.require)
+			req := httptest.NewRequest(http.MethodPut, "/", nil)
+			if tt.ifMatch != "" {
+				req.Header.Set(fiber.HeaderIfMatch, tt.ifMatch)
+			}
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status %d, want %d", resp.StatusCode, tt.status)
+			}
+			if got := resp.Header.Get(fiber.HeaderETag); got != tt.wantETag {
+				t.Fatalf("ETag = %q, want %q", got, tt.wantETag)
+			}
+		})
+	}
+}
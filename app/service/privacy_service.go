@@ -0,0 +1,648 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"gofiber-mongo/app/model"
+	"gofiber-mongo/app/repository"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PrivacyService memenuhi hak subjek data (UU PDP): ekspor seluruh data pribadi user dalam
+// bentuk ZIP, dan penghapusan data atas permintaan user setelah dikonfirmasi admin.
+type PrivacyService struct {
+	Repo           *repository.ErasureRepository
+	UserRepo       *repository.UserRepository
+	AlumniRepo     *repository.AlumniRepository
+	PekerjaanRepo  *repository.PekerjaanRepository
+	FileRepo       repository.IFileRepository
+	ClaimRepo      *repository.AlumniClaimRepository
+	InvitationRepo *repository.InvitationRepository
+	TokenRepo      *repository.TokenRepository
+	SessionRepo    *repository.SessionRepository
+	EventRepo      *repository.SecurityEventRepository
+	AuditRepo      *repository.AuditLogRepository
+	ThrottleRepo   *repository.LoginThrottleRepository
+	UploadPath     string
+}
+
+func NewPrivacyService(db *mongo.Database, fileRepo repository.IFileRepository, uploadPath string) *PrivacyService {
+	return &PrivacyService{
+		Repo:           repository.NewErasureRepository(db),
+		UserRepo:       repository.NewUserRepository(db),
+		AlumniRepo:     repository.NewAlumniRepository(db),
+		PekerjaanRepo:  repository.NewPekerjaanRepository(db),
+		FileRepo:       fileRepo,
+		ClaimRepo:      repository.NewAlumniClaimRepository(db),
+		InvitationRepo: repository.NewInvitationRepository(db),
+		TokenRepo:      repository.NewTokenRepository(db),
+		SessionRepo:    repository.NewSessionRepository(db),
+		EventRepo:      repository.NewSecurityEventRepository(db),
+		AuditRepo:      repository.NewAuditLogRepository(db),
+		ThrottleRepo:   repository.NewLoginThrottleRepository(db),
+		UploadPath:     uploadPath,
+	}
+}
+
+// uploadFile memastikan path file berada di dalam direktori upload sebelum dibaca atau dihapus.
+func (s *PrivacyService) uploadFile(path string) (string, bool) {
+	root, err := filepath.Abs(s.UploadPath)
+	if err != nil {
+		return "", false
+	}
+	full, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(root, full)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return full, true
+}
+
+func writeZipJSON(zw *zip.Writer, name string, v any) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeZipUpload menyalin file upload ke dalam ZIP. File yang sudah tidak ada dilewati.
+func (s *PrivacyService) writeZipUpload(zw *zip.Writer, name, path string) error {
+	full, ok := s.uploadFile(path)
+	if !ok {
+		return nil
+	}
+	f, err := os.Open(full)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// buildExport menyusun ZIP berisi data user, alumni, pekerjaan, klaim, riwayat keamanan, metadata file,
+// serta file foto dan sertifikat aslinya.
+func (s *PrivacyService) buildExport(ctx context.Context, user *model.User) ([]byte, error) {
+	alumniList, err := s.AlumniRepo.GetAllByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := s.ClaimRepo.FindByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.EventRepo.FindByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	pekerjaan := []model.PekerjaanAlumni{}
+	photos := []model.Photo{}
+	certificates := []model.Certificate{}
+	for _, alumni := range alumniList {
+		list, err := s.PekerjaanRepo.GetAllByAlumniID(ctx, alumni.ID)
+		if err != nil {
+			return nil, err
+		}
+		pekerjaan = append(pekerjaan, list...)
+
+		p, err := s.FileRepo.FindPhotosByAlumniID(ctx, alumni.ID)
+		if err != nil {
+			return nil, err
+		}
+		photos = append(photos, p...)
+
+		c, err := s.FileRepo.FindCertificatesByAlumniID(ctx, alumni.ID)
+		if err != nil {
+			return nil, err
+		}
+		certificates = append(certificates, c...)
+	}
+	if alumniList == nil {
+		alumniList = []model.Alumni{}
+	}
+	if claims == nil {
+		claims = []model.AlumniClaim{}
+	}
+	if events == nil {
+		events = []model.SecurityEvent{}
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	entries := []struct {
+		name string
+		data any
+	}{
+		{"user.json", user},
+		{"alumni.json", alumniList},
+		{"pekerjaan.json", pekerjaan},
+		{"alumni_claims.json", claims},
+		{"security_events.json", events},
+		{"files.json", fiber.Map{"photos": photos, "certificates": certificates}},
+	}
+	for _, e := range entries {
+		if err := writeZipJSON(zw, e.name, e.data); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, p := range photos {
+		if p.IsDelete {
+			continue
+		}
+		if err := s.writeZipUpload(zw, "files/photos/"+p.ID.Hex()+filepath.Ext(p.FilePath), p.FilePath); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range certificates {
+		if c.IsDelete {
+			continue
+		}
+		if err := s.writeZipUpload(zw, "files/certificates/"+c.ID.Hex()+filepath.Ext(c.FilePath), c.FilePath); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *PrivacyService) sendExport(c *fiber.Ctx, userID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	user, err := s.UserRepo.FindByID(ctx, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if user == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "User tidak ditemukan"})
+	}
+
+	data, err := s.buildExport(ctx, user)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	filename := "data-pribadi-" + user.Username + "-" + time.Now().Format("20060102") + ".zip"
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	return c.Send(data)
+}
+
+// HandleExportMine godoc
+// @Summary Ekspor data pribadi saya
+// @Description Mengunduh ZIP berisi data akun, alumni, pekerjaan, klaim alumni, serta file foto dan sertifikat
+// @Tags Privacy
+// @Produce application/zip
+// @Success 200 {file} file "ZIP data pribadi"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/export [get]
+// @Security BearerAuth
+func (s *PrivacyService) ExportMine(c *fiber.Ctx) error {
+	return s.sendExport(c, c.Locals("user_id").(primitive.ObjectID))
+}
+
+// HandleExportUser godoc
+// @Summary Ekspor data pribadi user (admin)
+// @Description Mengunduh ZIP data pribadi user, mis. untuk memenuhi permintaan yang masuk di luar aplikasi
+// @Tags Privacy
+// @Produce application/zip
+// @Param id path string true "User ID"
+// @Success 200 {file} file "ZIP data pribadi"
+// @Failure 400 {object} map[string]interface{} "ID tidak valid"
+// @Failure 404 {object} map[string]interface{} "User tidak ditemukan"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /users/{id}/export [get]
+// @Security BearerAuth
+func (s *PrivacyService) ExportUser(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
+	}
+	return s.sendExport(c, id)
+}
+
+// erase menjalankan penghapusan data pribadi user. Foto dan sertifikat (record dan file di
+// direktori upload), session, token, event keamanan dan counter login selalu dihapus; audit log
+// dianonimkan; data alumni dan akun dihapus permanen atau dianonimkan sesuai mode.
+func (s *PrivacyService) erase(ctx context.Context, userID primitive.ObjectID, mode string) (*model.ErasureSummary, error) {
+	user, err := s.UserRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user tidak ditemukan")
+	}
+
+	// Putus semua login lebih dulu supaya tidak ada akses selama penghapusan berjalan
+	if err := revokeAllSessions(ctx, s.TokenRepo, s.SessionRepo, userID); err != nil {
+		return nil, err
+	}
+
+	alumniList, err := s.AlumniRepo.GetAllByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &model.ErasureSummary{}
+	for _, alumni := range alumniList {
+		photos, err := s.FileRepo.FindPhotosByAlumniID(ctx, alumni.ID)
+		if err != nil {
+			return nil, err
+		}
+		certificates, err := s.FileRepo.FindCertificatesByAlumniID(ctx, alumni.ID)
+		if err != nil {
+			return nil, err
+		}
+		paths := []string{}
+		for _, p := range photos {
+			paths = append(paths, p.FilePath)
+		}
+		for _, c := range certificates {
+			paths = append(paths, c.FilePath)
+		}
+		for _, path := range paths {
+			full, ok := s.uploadFile(path)
+			if !ok {
+				log.Printf("Peringatan: file %q di luar direktori upload, tidak dihapus", path)
+				continue
+			}
+			if err := os.Remove(full); err == nil {
+				summary.Files++
+			} else if !os.IsNotExist(err) {
+				return nil, err
+			}
+		}
+
+		nPhotos, nCerts, err := s.FileRepo.DeleteFilesByAlumniID(ctx, alumni.ID)
+		if err != nil {
+			return nil, err
+		}
+		summary.Photos += nPhotos
+		summary.Certificates += nCerts
+
+		if err := s.InvitationRepo.DeleteByAlumni(ctx, alumni.ID); err != nil {
+			return nil, err
+		}
+
+		if mode == model.ErasureModeDelete {
+			n, err := s.PekerjaanRepo.DeleteByAlumniID(ctx, alumni.ID)
+			if err != nil {
+				return nil, err
+			}
+			summary.Pekerjaan += n
+			if err := s.AlumniRepo.Delete(ctx, alumni.ID, nil); err != nil {
+				return nil, err
+			}
+		} else if err := s.AlumniRepo.Anonymize(ctx, alumni.ID); err != nil {
+			return nil, err
+		}
+	}
+	if len(alumniList) > 0 {
+		summary.Alumni = mode
+	}
+
+	if err := s.ClaimRepo.DeleteByUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := s.EventRepo.DeleteByUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := s.AuditRepo.AnonymizeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := s.ThrottleRepo.DeleteByUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := s.SessionRepo.DeleteByUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := s.TokenRepo.DeleteAllForUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if mode == model.ErasureModeDelete {
+		err = s.UserRepo.HardDelete(ctx, userID)
+	} else {
+		err = s.UserRepo.Anonymize(ctx, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	summary.User = mode
+
+	return summary, nil
+}
+
+// HandleCreateErasure godoc
+// @Summary Minta penghapusan data pribadi
+// @Description Mengajukan penghapusan akun dan data pribadi. Penghapusan dijalankan setelah dikonfirmasi admin.
+// @Tags Privacy
+// @Accept json
+// @Produce json
+// @Param body body model.CreateErasureRequest false "Alasan"
+// @Success 201 {object} map[string]interface{} "erasure request"
+// @Failure 403 {object} map[string]interface{} "Bukan login user"
+// @Failure 404 {object} map[string]interface{} "User tidak ditemukan"
+// @Failure 409 {object} map[string]interface{} "Masih ada permintaan yang belum selesai"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/erasure [post]
+// @Security BearerAuth
+func (s *PrivacyService) CreateErasure(c *fiber.Ctx) error {
+	// Hanya pemilik akun yang login sendiri yang boleh meminta, bukan API key atau impersonation
+	claims, ok := c.Locals("claims").(*model.JWTClaims)
+	if !ok || claims.Act != nil {
+		return c.Status(403).JSON(fiber.Map{"error": "Permintaan penghapusan hanya untuk login user"})
+	}
+	userID := claims.UserID
+
+	var req model.CreateErasureRequest
+	_ = c.BodyParser(&req)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := s.UserRepo.FindByID(ctx, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if user == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "User tidak ditemukan"})
+	}
+
+	open, err := s.Repo.FindOpenByUser(ctx, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if open != nil {
+		return c.Status(409).JSON(fiber.Map{"error": "Masih ada permintaan penghapusan yang belum selesai", "data": open})
+	}
+
+	erasure := &model.ErasureRequest{
+		UserID:   userID,
+		Username: user.Username,
+		Reason:   strings.TrimSpace(req.Reason),
+	}
+	if err := s.Repo.Create(ctx, erasure); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"message": "Permintaan penghapusan data diterima dan menunggu konfirmasi admin",
+		"data":    erasure,
+	})
+}
+
+// HandleGetMyErasure godoc
+// @Summary Riwayat permintaan penghapusan saya
+// @Tags Privacy
+// @Produce json
+// @Success 200 {object} map[string]interface{} "erasure request list"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/erasure [get]
+// @Security BearerAuth
+func (s *PrivacyService) GetMyErasure(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	list, err := s.Repo.FindByUser(ctx, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if list == nil {
+		list = []model.ErasureRequest{}
+	}
+	return c.JSON(fiber.Map{"success": true, "data": list})
+}
+
+// HandleCancelErasure godoc
+// @Summary Batalkan permintaan penghapusan
+// @Description Membatalkan permintaan penghapusan yang belum dikonfirmasi admin
+// @Tags Privacy
+// @Produce json
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 404 {object} map[string]interface{} "Tidak ada permintaan pending"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/erasure [delete]
+// @Security BearerAuth
+func (s *PrivacyService) CancelErasure(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	open, err := s.Repo.FindOpenByUser(ctx, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if open == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Tidak ada permintaan penghapusan yang pending"})
+	}
+
+	ok, err := s.Repo.UpdateStatus(ctx, open.ID, []string{model.ErasureStatusPending}, bson.M{
+		"status": model.ErasureStatusCancelled,
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !ok {
+		return c.Status(409).JSON(fiber.Map{"error": "Permintaan sudah diproses admin dan tidak bisa dibatalkan"})
+	}
+	return c.JSON(fiber.Map{"success": true, "message": "Permintaan penghapusan dibatalkan"})
+}
+
+// HandleGetErasureRequests godoc
+// @Summary Antrean permintaan penghapusan (admin)
+// @Description Mengambil permintaan penghapusan data berdasarkan status (default pending)
+// @Tags Privacy
+// @Produce json
+// @Param status query string false "pending, running, completed, failed, rejected, cancelled, all" default(pending)
+// @Success 200 {object} map[string]interface{} "erasure request list"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /erasure-requests [get]
+// @Security BearerAuth
+func (s *PrivacyService) GetErasureRequests(c *fiber.Ctx) error {
+	status := c.Query("status", model.ErasureStatusPending)
+	if status == "all" {
+		status = ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	list, err := s.Repo.FindByStatus(ctx, status)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if list == nil {
+		list = []model.ErasureRequest{}
+	}
+	return c.JSON(fiber.Map{"success": true, "data": list})
+}
+
+// HandleApproveErasure godoc
+// @Summary Konfirmasi penghapusan data (admin)
+// @Description Menjalankan penghapusan data user. confirm_username harus sama dengan username pemilik data.
+// @Description mode "anonymize" menghapus identitas tetapi mempertahankan data pekerjaan untuk statistik; "delete" menghapus permanen semuanya.
+// @Tags Privacy
+// @Accept json
+// @Produce json
+// @Param id path string true "Erasure request ID"
+// @Param body body model.ApproveErasureRequest true "Mode dan konfirmasi"
+// @Success 200 {object} map[string]interface{} "completed erasure request"
+// @Failure 400 {object} map[string]interface{} "Request tidak valid / konfirmasi tidak cocok"
+// @Failure 403 {object} map[string]interface{} "Tidak bisa menyetujui permintaan sendiri"
+// @Failure 404 {object} map[string]interface{} "Permintaan tidak ditemukan"
+// @Failure 409 {object} map[string]interface{} "Permintaan sudah diproses"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /erasure-requests/{id}/approve [put]
+// @Security BearerAuth
+func (s *PrivacyService) ApproveErasure(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
+	}
+
+	var req model.ApproveErasureRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
+	}
+	if req.Mode != model.ErasureModeAnonymize && req.Mode != model.ErasureModeDelete {
+		return c.Status(400).JSON(fiber.Map{"error": "mode harus anonymize atau delete"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	erasure, err := s.Repo.FindByID(ctx, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if erasure == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Permintaan penghapusan tidak ditemukan"})
+	}
+	if isSelf(c, erasure.UserID) {
+		return c.Status(403).JSON(fiber.Map{"error": "Tidak bisa mengonfirmasi penghapusan akun sendiri"})
+	}
+	if req.ConfirmUsername != erasure.Username {
+		return c.Status(400).JSON(fiber.Map{"error": "confirm_username tidak cocok dengan username pemilik data"})
+	}
+
+	reviewer := c.Locals("user_id").(primitive.ObjectID)
+	now := time.Now()
+	ok, err := s.Repo.UpdateStatus(ctx, id, []string{model.ErasureStatusPending, model.ErasureStatusFailed}, bson.M{
+		"status":      model.ErasureStatusRunning,
+		"mode":        req.Mode,
+		"reviewed_by": reviewer,
+		"reviewed_at": now,
+		"review_note": strings.TrimSpace(req.Note),
+		"error":       "",
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !ok {
+		return c.Status(409).JSON(fiber.Map{"error": "Permintaan sudah diproses"})
+	}
+
+	summary, err := s.erase(ctx, erasure.UserID, req.Mode)
+	if err != nil {
+		// Status failed bisa dikonfirmasi ulang; langkah yang sudah selesai aman diulang
+		if _, uerr := s.Repo.UpdateStatus(ctx, id, []string{model.ErasureStatusRunning}, bson.M{
+			"status": model.ErasureStatusFailed,
+			"error":  err.Error(),
+		}); uerr != nil {
+			log.Printf("Gagal menandai permintaan penghapusan %s gagal: %v", id.Hex(), uerr)
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if _, err := s.Repo.UpdateStatus(ctx, id, []string{model.ErasureStatusRunning}, bson.M{
+		"status":  model.ErasureStatusCompleted,
+		"summary": summary,
+	}); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Data pribadi user berhasil dihapus",
+		"data":    summary,
+	})
+}
+
+// HandleRejectErasure godoc
+// @Summary Tolak permintaan penghapusan (admin)
+// @Description Menolak permintaan penghapusan, mis. karena ada kewajiban hukum menyimpan data
+// @Tags Privacy
+// @Accept json
+// @Produce json
+// @Param id path string true "Erasure request ID"
+// @Param body body model.RejectErasureRequest false "Alasan penolakan"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 400 {object} map[string]interface{} "ID tidak valid"
+// @Failure 409 {object} map[string]interface{} "Permintaan sudah diproses"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /erasure-requests/{id}/reject [put]
+// @Security BearerAuth
+func (s *PrivacyService) RejectErasure(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
+	}
+
+	var req model.RejectErasureRequest
+	_ = c.BodyParser(&req)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ok, err := s.Repo.UpdateStatus(ctx, id, []string{model.ErasureStatusPending, model.ErasureStatusFailed}, bson.M{
+		"status":      model.ErasureStatusRejected,
+		"reviewed_by": c.Locals("user_id").(primitive.ObjectID),
+		"reviewed_at": time.Now(),
+		"review_note": strings.TrimSpace(req.Note),
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !ok {
+		return c.Status(409).JSON(fiber.Map{"error": "Permintaan tidak ditemukan atau sudah diproses"})
+	}
+	return c.JSON(fiber.Map{"success": true, "message": "Permintaan penghapusan ditolak"})
+}
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"gofiber-mongo/app/model"
+	"gofiber-mongo/app/repository"
+	"gofiber-mongo/middleware"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RoleService struct {
+	Repo *repository.RoleRepository
+}
+
+func NewRoleService(repo *repository.RoleRepository) *RoleService {
+	return &RoleService{
+		Repo: repo,
+	}
+}
+
+// invalidPermissions mengembalikan permission yang tidak dikenal aplikasi.
+func invalidPermissions(perms []string) []string {
+	invalid := []string{}
+	for _, p := range perms {
+		if !model.IsValidPermission(p) {
+			invalid = append(invalid, p)
+		}
+	}
+	return invalid
+}
+
+// unheldPermission mengembalikan permission pertama yang diminta tetapi tidak dimiliki pemanggil.
+// Pemegang roles:manage tidak boleh membuat role yang lebih kuat dari dirinya sendiri.
+func unheldPermission(c *fiber.Ctx, perms []string) string {
+	for _, p := range perms {
+		if !middleware.HasPermission(c, p) {
+			return p
+		}
+	}
+	return ""
+}
+
+func forbidUnheldPermission(c *fiber.Ctx, p string) error {
+	return c.Status(403).JSON(fiber.Map{
+		"error":      "Tidak bisa memberikan permission yang tidak Anda miliki",
+		"permission": p,
+	})
+}
+
+// HandleGetPermissions godoc
+// @Summary Daftar permission
+// @Description Mengambil semua permission yang dikenal aplikasi
+// @Tags Roles
+// @Produce json
+// @Success 200 {object} map[string]interface{} "permission list"
+// @Router /permissions [get]
+// @Security BearerAuth
+func (s *RoleService) GetPermissions(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"success": true, "data": model.AllPermissions})
+}
+
+// HandleGetAll godoc
+// @Summary Get all roles
+// @Description Mengambil semua role beserta permission-nya
+// @Tags Roles
+// @Produce json
+// @Success 200 {object} map[string]interface{} "role list"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /roles [get]
+// @Security BearerAuth
+func (s *RoleService) GetAll(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	roles, err := s.Repo.GetAll(ctx)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"success": true, "data": roles})
+}
+
+// HandleGetByID godoc
+// @Summary Get role by ID
+// @Description Mengambil role berdasarkan ID
+// @Tags Roles
+// @Produce json
+// @Param id path string true "Role ID"
+// @Success 200 {object} map[string]interface{} "role data"
+// @Failure 400 {object} map[string]interface{} "ID tidak valid"
+// @Failure 404 {object} map[string]interface{} "Role tidak ditemukan"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /roles/{id} [get]
+// @Security BearerAuth
+func (s *RoleService) GetByID(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	role, err := s.Repo.FindByID(ctx, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if role == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Role tidak ditemukan"})
+	}
+	return c.JSON(fiber.Map{"success": true, "data": role})
+}
+
+// HandleCreate godoc
+// @Summary Create role
+// @Description Membuat role baru dengan daftar permission
+// @Tags Roles
+// @Accept json
+// @Produce json
+// @Param body body model.CreateRoleRequest true "Role data"
+// @Success 201 {object} map[string]interface{} "created role"
+// @Failure 400 {object} map[string]interface{} "Request tidak valid"
+// @Failure 403 {object} map[string]interface{} "Permission tidak dimiliki pemanggil"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /roles [post]
+// @Security BearerAuth
+func (s *RoleService) Create(c *fiber.Ctx) error {
+	var req model.CreateRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "name tidak boleh kosong"})
+	}
+	if invalid := invalidPermissions(req.Permissions); len(invalid) > 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Permission tidak dikenal", "permissions": invalid})
+	}
+	if p := unheldPermission(c, req.Permissions); p != "" {
+		return forbidUnheldPermission(c, p)
+	}
+	if req.Permissions == nil {
+		req.Permissions = []string{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	existing, err := s.Repo.FindByName(ctx, req.Name)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if existing != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Role sudah ada"})
+	}
+
+	role, err := s.Repo.Create(ctx, req)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(fiber.Map{"success": true, "data": role})
+}
+
+// HandleUpdate godoc
+// @Summary Update role
+// @Description Memperbarui deskripsi dan permission role. Permission role admin dan role pemanggil sendiri tidak bisa diubah,
+// @Description dan pemanggil hanya bisa memberikan permission yang ia miliki.
+// @Tags Roles
+// @Accept json
+// @Produce json
+// @Param id path string true "Role ID"
+// @Param body body model.UpdateRoleRequest true "Role data"
+// @Success 200 {object} map[string]interface{} "updated role"
+// @Failure 400 {object} map[string]interface{} "Request tidak valid"
+// @Failure 403 {object} map[string]interface{} "Permission tidak dimiliki pemanggil atau role sendiri"
+// @Failure 404 {object} map[string]interface{} "Role tidak ditemukan"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /roles/{id} [put]
+// @Security BearerAuth
+func (s *RoleService) Update(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
+	}
+
+	var req model.UpdateRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
+	}
+	if invalid := invalidPermissions(req.Permissions); len(invalid) > 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Permission tidak dikenal", "permissions": invalid})
+	}
+	if p := unheldPermission(c, req.Permissions); p != "" {
+		return forbidUnheldPermission(c, p)
+	}
+	if req.Permissions == nil {
+		req.Permissions = []string{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	role, err := s.Repo.FindByID(ctx, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if role == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Role tidak ditemukan"})
+	}
+	if role.Name == model.RoleAdmin {
+		return c.Status(400).JSON(fiber.Map{"error": "Permission role admin tidak bisa diubah"})
+	}
+	if role.Name == c.Locals("role") {
+		return c.Status(403).JSON(fiber.Map{"error": "Tidak bisa mengubah role Anda sendiri"})
+	}
+
+	updated, err := s.Repo.Update(ctx, id, req)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"success": true, "data": updated})
+}
+
+// HandleDelete godoc
+// @Summary Delete role
+// @Description Menghapus role yang bukan bawaan sistem dan tidak dipakai user aktif
+// @Tags Roles
+// @Produce json
+// @Param id path string true "Role ID"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 400 {object} map[string]interface{} "Role tidak bisa dihapus"
+// @Failure 404 {object} map[string]interface{} "Role tidak ditemukan"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /roles/{id} [delete]
+// @Security BearerAuth
+func (s *RoleService) Delete(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	role, err := s.Repo.FindByID(ctx, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if role == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Role tidak ditemukan"})
+	}
+	if role.IsSystem {
+		return c.Status(400).JSON(fiber.Map{"error": "Role bawaan sistem tidak bisa dihapus"})
+	}
+
+	count, err := s.Repo.CountUsers(ctx, role.Name)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if count > 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Role masih dipakai oleh user", "users": count})
+	}
+
+	if err := s.Repo.Delete(ctx, id); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"success": true, "message": "Role berhasil dihapus"})
+}
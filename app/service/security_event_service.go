@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"gofiber-mongo/app/model"
+	"gofiber-mongo/app/repository"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SecurityEventService struct {
+	Repo *repository.SecurityEventRepository
+}
+
+func NewSecurityEventService(repo *repository.SecurityEventRepository) *SecurityEventService {
+	return &SecurityEventService{
+		Repo: repo,
+	}
+}
+
+// userSecurityEvent membuat event untuk akun user yang terdampak.
+func userSecurityEvent(eventType, outcome string, user *model.User) *model.SecurityEvent {
+	return &model.SecurityEvent{
+		Type:     eventType,
+		Outcome:  outcome,
+		UserID:   &user.ID,
+		Username: user.Username,
+	}
+}
+
+// adminSecurityEvent membuat event untuk aksi admin (user pemanggil) atas akun user lain.
+func adminSecurityEvent(c *fiber.Ctx, eventType string, user *model.User) *model.SecurityEvent {
+	event := userSecurityEvent(eventType, model.SecurityOutcomeSuccess, user)
+	if actorID, ok := c.Locals("user_id").(primitive.ObjectID); ok {
+		event.ActorID = &actorID
+		event.ActorUsername, _ = c.Locals("username").(string)
+	}
+	return event
+}
+
+// recordSecurityEvent melengkapi event dengan IP dan user agent lalu menyimpannya.
+// Gagal mencatat tidak menggagalkan request; kesalahan hanya ditulis ke log.
+func recordSecurityEvent(repo *repository.SecurityEventRepository, c *fiber.Ctx, event *model.SecurityEvent) {
+	event.IP = c.IP()
+	event.UserAgent = c.Get(fiber.HeaderUserAgent)
+
+	// Selama impersonation, admin di claim "act" yang sebenarnya bertindak
+	if claims, ok := c.Locals("claims").(*model.JWTClaims); ok && claims.Act != nil && event.ActorID == nil {
+		event.ActorID = &claims.Act.UserID
+		event.ActorUsername = claims.Act.Username
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := repo.Create(ctx, event); err != nil {
+		log.Printf("Gagal mencatat security event %s: %v", event.Type, err)
+	}
+}
+
+func securityEventPage(c *fiber.Ctx) (page, limit int) {
+	page, _ = strconv.Atoi(c.Query("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ = strconv.Atoi(c.Query("limit", "20"))
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	return page, limit
+}
+
+func (s *SecurityEventService) list(c *fiber.Ctx, filter model.SecurityEventFilter) error {
+	page, limit := securityEventPage(c)
+	offset := (page - 1) * limit
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events, err := s.Repo.GetAllWithFilter(ctx, filter, limit, offset)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if events == nil {
+		events = []model.SecurityEvent{}
+	}
+
+	total, err := s.Repo.CountWithFilter(ctx, filter)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    events,
+		"meta": model.MetaInfo{
+			Page:   page,
+			Limit:  limit,
+			Total:  int(total),
+			Pages:  (int(total) + limit - 1) / limit,
+			SortBy: "created_at",
+			Order:  "desc",
+		},
+	})
+}
+
+// HandleGetMySecurityEvents godoc
+// @Summary Riwayat keamanan akun saya
+// @Description Mengambil riwayat login, login gagal, perubahan password/2FA dan aksi admin atas akun user yang login
+// @Tags Security Events
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param type query string false "Filter jenis event (login, password.change, role.change, ...)"
+// @Param outcome query string false "success, failure, blocked"
+// @Success 200 {object} map[string]interface{} "security event list with metadata"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/security-events [get]
+// @Security BearerAuth
+func (s *SecurityEventService) GetMine(c *fiber.Ctx) error {
+	return s.list(c, model.SecurityEventFilter{
+		UserID:  c.Locals("user_id").(primitive.ObjectID),
+		Type:    c.Query("type"),
+		Outcome: c.Query("outcome"),
+	})
+}
+
+// HandleGetSecurityEvents godoc
+// @Summary Get security events
+// @Description Mengambil security event semua user dengan filter user, admin, jenis, hasil, IP dan rentang tanggal
+// @Tags Security Events
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param user_id query string false "Filter user yang terdampak"
+// @Param actor_id query string false "Filter admin yang bertindak"
+// @Param type query string false "Filter jenis event (login, password.change, role.change, ...)"
+// @Param outcome query string false "success, failure, blocked"
+// @Param ip query string false "Filter alamat IP"
+// @Param from query string false "Tanggal awal (YYYY-MM-DD)"
+// @Param to query string false "Tanggal akhir, inklusif (YYYY-MM-DD)"
+// @Success 200 {object} map[string]interface{} "security event list with metadata"
+// @Failure 400 {object} map[string]interface{} "Filter tidak valid"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /security-events [get]
+// @Security BearerAuth
+func (s *SecurityEventService) GetAll(c *fiber.Ctx) error {
+	filter := model.SecurityEventFilter{
+		Type:    c.Query("type"),
+		Outcome: c.Query("outcome"),
+		IP:      c.Query("ip"),
+	}
+
+	if v := c.Query("user_id"); v != "" {
+		id, err := primitive.ObjectIDFromHex(v)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "user_id tidak valid"})
+		}
+		filter.UserID = id
+	}
+	if v := c.Query("actor_id"); v != "" {
+		id, err := primitive.ObjectIDFromHex(v)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "actor_id tidak valid"})
+		}
+		filter.ActorID = id
+	}
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "from harus berformat YYYY-MM-DD"})
+		}
+		filter.From = &t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "to harus berformat YYYY-MM-DD"})
+		}
+		t = t.AddDate(0, 0, 1)
+		filter.To = &t
+	}
+
+	return s.list(c, filter)
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"gofiber-mongo/app/model"
+	"gofiber-mongo/app/repository"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SessionService struct {
+	Repo      *repository.SessionRepository
+	TokenRepo *repository.TokenRepository
+	EventRepo *repository.SecurityEventRepository
+}
+
+func NewSessionService(repo *repository.SessionRepository, tokenRepo *repository.TokenRepository,
+	eventRepo *repository.SecurityEventRepository) *SessionService {
+	return &SessionService{
+		Repo:      repo,
+		TokenRepo: tokenRepo,
+		EventRepo: eventRepo,
+	}
+}
+
+// sessionRevoked mencatat session user yang diakhiri. sessionID kosong berarti semua session.
+// Jika userID bukan pemanggil, pemanggil dicatat sebagai admin yang bertindak.
+func (s *SessionService) sessionRevoked(c *fiber.Ctx, userID primitive.ObjectID, sessionID string) {
+	event := &model.SecurityEvent{
+		Type:     model.SecurityEventSessionRevoke,
+		Outcome:  model.SecurityOutcomeSuccess,
+		UserID:   &userID,
+		Metadata: map[string]string{"session_id": sessionID},
+	}
+	if sessionID == "" {
+		event.Metadata = map[string]string{"scope": "all"}
+	}
+	if isSelf(c, userID) {
+		event.Username, _ = c.Locals("username").(string)
+	} else if actorID, ok := c.Locals("user_id").(primitive.ObjectID); ok {
+		event.ActorID = &actorID
+		event.ActorUsername, _ = c.Locals("username").(string)
+	}
+	recordSecurityEvent(s.EventRepo, c, event)
+}
+
+// revokeAllSessions mengakhiri semua session user beserta refresh token-nya.
+// Access token ikut tidak berlaku karena AuthRequired memeriksa session dari claim sid.
+func revokeAllSessions(ctx context.Context, tokenRepo *repository.TokenRepository, sessionRepo *repository.SessionRepository, userID primitive.ObjectID) error {
+	if err := sessionRepo.RevokeAllForUser(ctx, userID); err != nil {
+		return err
+	}
+	return tokenRepo.RevokeAllForUser(ctx, userID)
+}
+
+// revokeOtherSessions seperti revokeAllSessions, tetapi session keepID tetap aktif.
+func revokeOtherSessions(ctx context.Context, tokenRepo *repository.TokenRepository, sessionRepo *repository.SessionRepository, userID primitive.ObjectID, keepID string) error {
+	if err := sessionRepo.RevokeOthersForUser(ctx, userID, keepID); err != nil {
+		return err
+	}
+	return tokenRepo.RevokeOthersForUser(ctx, userID, keepID)
+}
+
+func currentSessionID(c *fiber.Ctx) string {
+	claims, _ := c.Locals("claims").(*model.JWTClaims)
+	if claims == nil {
+		return ""
+	}
+	return claims.SessionID
+}
+
+// HandleGetMine godoc
+// @Summary Daftar session login saya
+// @Description Mengambil semua session aktif milik user yang login (perangkat, IP, waktu login dan terakhir aktif)
+// @Tags Sessions
+// @Produce json
+// @Success 200 {object} map[string]interface{} "session list"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/sessions [get]
+// @Security BearerAuth
+func (s *SessionService) GetMine(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sessions, err := s.Repo.FindActiveByUser(ctx, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if sessions == nil {
+		sessions = []model.Session{}
+	}
+
+	current := currentSessionID(c)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current
+	}
+	return c.JSON(fiber.Map{"success": true, "data": sessions})
+}
+
+// HandleRevokeMine godoc
+// @Summary Akhiri satu session
+// @Description Logout dari satu perangkat. Access token dan refresh token session tersebut langsung tidak berlaku.
+// @Tags Sessions
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 404 {object} map[string]interface{} "Session tidak ditemukan"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/sessions/{id} [delete]
+// @Security BearerAuth
+func (s *SessionService) RevokeMine(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(primitive.ObjectID)
+	id := c.Params("id")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := s.Repo.FindByID(ctx, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if session == nil || session.UserID != userID || session.RevokedAt != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Session tidak ditemukan"})
+	}
+
+	if err := s.Repo.Revoke(ctx, id); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := s.TokenRepo.RevokeFamily(ctx, id); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	s.sessionRevoked(c, userID, id)
+	return c.JSON(fiber.Map{"success": true, "message": "Session berhasil diakhiri"})
+}
+
+// HandleRevokeAllMine godoc
+// @Summary Logout dari semua perangkat
+// @Description Mengakhiri semua session milik user yang login, termasuk session saat ini
+// @Tags Sessions
+// @Produce json
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/sessions [delete]
+// @Security BearerAuth
+func (s *SessionService) RevokeAllMine(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := revokeAllSessions(ctx, s.TokenRepo, s.Repo, userID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	s.sessionRevoked(c, userID, "")
+	return c.JSON(fiber.Map{"success": true, "message": "Berhasil logout dari semua perangkat"})
+}
+
+// HandleGetByUser godoc
+// @Summary Daftar session user (admin)
+// @Description Mengambil semua session aktif milik user tertentu
+// @Tags Sessions
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]interface{} "session list"
+// @Failure 400 {object} map[string]interface{} "ID tidak valid"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /users/{id}/sessions [get]
+// @Security BearerAuth
+func (s *SessionService) GetByUser(c *fiber.Ctx) error {
+	userID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sessions, err := s.Repo.FindActiveByUser(ctx, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if sessions == nil {
+		sessions = []model.Session{}
+	}
+	return c.JSON(fiber.Map{"success": true, "data": sessions})
+}
+
+// HandleRevokeAllForUser godoc
+// @Summary Paksa logout user (admin)
+// @Description Mengakhiri semua session milik user tertentu
+// @Tags Sessions
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 400 {object} map[string]interface{} "ID tidak valid"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /users/{id}/sessions [delete]
+// @Security BearerAuth
+func (s *SessionService) RevokeAllForUser(c *fiber.Ctx) error {
+	userID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := revokeAllSessions(ctx, s.TokenRepo, s.Repo, userID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	s.sessionRevoked(c, userID, "")
+	return c.JSON(fiber.Map{"success": true, "message": "Semua session user berhasil diakhiri"})
+}
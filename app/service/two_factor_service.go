@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"gofiber-mongo/app/model"
+	"gofiber-mongo/app/repository"
+	"gofiber-mongo/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const recoveryCodeCount = 10
+
+type TwoFactorService struct {
+	UserRepo  *repository.UserRepository
+	EventRepo *repository.SecurityEventRepository
+}
+
+func NewTwoFactorService(userRepo *repository.UserRepository, eventRepo *repository.SecurityEventRepository) *TwoFactorService {
+	return &TwoFactorService{
+		UserRepo:  userRepo,
+		EventRepo: eventRepo,
+	}
+}
+
+// verifySecondFactor memeriksa kode TOTP (dengan proteksi replay) atau recovery code (sekali pakai).
+func verifySecondFactor(ctx context.Context, repo *repository.UserRepository, user *model.User, code, recoveryCode string) (bool, error) {
+	if !user.TwoFactorEnabled {
+		return false, nil
+	}
+
+	if code != "" {
+		step, ok := utils.VerifyTOTP(user.TOTPSecret, code, time.Now())
+		if !ok {
+			return false, nil
+		}
+		return repo.UseTOTPStep(ctx, user.ID, step)
+	}
+
+	if recoveryCode != "" {
+		return repo.UseRecoveryCode(ctx, user.ID, utils.HashRecoveryCode(recoveryCode))
+	}
+
+	return false, nil
+}
+
+func (s *TwoFactorService) currentUser(c *fiber.Ctx, ctx context.Context) (*model.User, error) {
+	userID := c.Locals("user_id").(primitive.ObjectID)
+	return s.UserRepo.FindByID(ctx, userID)
+}
+
+// HandleTwoFactorStatus godoc
+// @Summary Status 2FA
+// @Description Menampilkan apakah 2FA aktif, wajib untuk role user, dan sisa recovery code
+// @Tags Two Factor
+// @Produce json
+// @Success 200 {object} map[string]interface{} "status 2FA"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/2fa [get]
+// @Security BearerAuth
+func (s *TwoFactorService) Status(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := s.currentUser(c, ctx)
+	if err != nil || user == nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal mengambil data user"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"enabled":                  user.TwoFactorEnabled,
+			"required":                 utils.RequiresTwoFactor(user.Role),
+			"recovery_codes_remaining": len(user.RecoveryCodes),
+		},
+	})
+}
+
+// HandleTwoFactorSetup godoc
+// @Summary Mulai pendaftaran 2FA
+// @Description Membuat secret TOTP baru (belum aktif) dan URI otpauth:// untuk QR code. Aktifkan dengan /me/2fa/enable.
+// @Tags Two Factor
+// @Produce json
+// @Success 200 {object} map[string]interface{} "secret dan provisioning URI"
+// @Failure 400 {object} map[string]interface{} "2FA sudah aktif"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/2fa/setup [post]
+// @Security BearerAuth
+func (s *TwoFactorService) Setup(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := s.currentUser(c, ctx)
+	if err != nil || user == nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal mengambil data user"})
+	}
+	if user.TwoFactorEnabled {
+		return c.Status(400).JSON(fiber.Map{"error": "2FA sudah aktif. Nonaktifkan terlebih dahulu untuk mendaftar ulang"})
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal membuat secret"})
+	}
+	if err := s.UserRepo.SetPendingTOTPSecret(ctx, user.ID, secret); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"secret":           secret,
+			"provisioning_uri": utils.TOTPProvisioningURI(user.Username, secret),
+		},
+	})
+}
+
+// HandleTwoFactorEnable godoc
+// @Summary Aktifkan 2FA
+// @Description Memverifikasi kode TOTP dari secret hasil setup lalu mengaktifkan 2FA. Recovery code hanya ditampilkan sekali.
+// @Tags Two Factor
+// @Accept json
+// @Produce json
+// @Param body body model.TwoFactorCodeRequest true "Kode TOTP"
+// @Success 200 {object} map[string]interface{} "recovery codes"
+// @Failure 400 {object} map[string]interface{} "Kode tidak valid"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/2fa/enable [post]
+// @Security BearerAuth
+func (s *TwoFactorService) Enable(c *fiber.Ctx) error {
+	var req model.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Kode harus diisi"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := s.currentUser(c, ctx)
+	if err != nil || user == nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal mengambil data user"})
+	}
+	if user.TwoFactorEnabled {
+		return c.Status(400).JSON(fiber.Map{"error": "2FA sudah aktif"})
+	}
+	if user.TOTPPendingSecret == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Jalankan setup 2FA terlebih dahulu"})
+	}
+
+	step, ok := utils.VerifyTOTP(user.TOTPPendingSecret, req.Code, time.Now())
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "Kode tidak valid"})
+	}
+
+	codes, hashes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal membuat recovery code"})
+	}
+	if err := s.UserRepo.EnableTwoFactor(ctx, user.ID, user.TOTPPendingSecret, step, hashes); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	recordSecurityEvent(s.EventRepo, c, userSecurityEvent(model.SecurityEventTwoFactorEnable, model.SecurityOutcomeSuccess, user))
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "2FA berhasil diaktifkan. Simpan recovery code di tempat aman",
+		"data":    fiber.Map{"recovery_codes": codes},
+	})
+}
+
+// HandleTwoFactorDisable godoc
+// @Summary Nonaktifkan 2FA
+// @Description Menonaktifkan 2FA dengan password dan kode TOTP / recovery code. Tidak diizinkan untuk role yang wajib 2FA.
+// @Tags Two Factor
+// @Accept json
+// @Produce json
+// @Param body body model.DisableTwoFactorRequest true "Password dan kode"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 400 {object} map[string]interface{} "Kode atau password salah"
+// @Failure 403 {object} map[string]interface{} "2FA wajib untuk role ini"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/2fa/disable [post]
+// @Security BearerAuth
+func (s *TwoFactorService) Disable(c *fiber.Ctx) error {
+	var req model.DisableTwoFactorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := s.currentUser(c, ctx)
+	if err != nil || user == nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal mengambil data user"})
+	}
+	if !user.TwoFactorEnabled {
+		return c.Status(400).JSON(fiber.Map{"error": "2FA belum aktif"})
+	}
+	if utils.RequiresTwoFactor(user.Role) {
+		return c.Status(403).JSON(fiber.Map{"error": "2FA wajib untuk role " + user.Role})
+	}
+	if !utils.CheckPassword(req.Password, user.Password) {
+		s.disableFailed(c, user)
+		return c.Status(400).JSON(fiber.Map{"error": "Password atau kode salah"})
+	}
+
+	ok, err := verifySecondFactor(ctx, s.UserRepo, user, req.Code, req.RecoveryCode)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !ok {
+		s.disableFailed(c, user)
+		return c.Status(400).JSON(fiber.Map{"error": "Password atau kode salah"})
+	}
+
+	if err := s.UserRepo.DisableTwoFactor(ctx, user.ID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	recordSecurityEvent(s.EventRepo, c, userSecurityEvent(model.SecurityEventTwoFactorDisable, model.SecurityOutcomeSuccess, user))
+	return c.JSON(fiber.Map{"success": true, "message": "2FA berhasil dinonaktifkan"})
+}
+
+func (s *TwoFactorService) disableFailed(c *fiber.Ctx, user *model.User) {
+	event := userSecurityEvent(model.SecurityEventTwoFactorDisable, model.SecurityOutcomeFailure, user)
+	event.Reason = "invalid_credentials"
+	recordSecurityEvent(s.EventRepo, c, event)
+}
+
+// HandleRegenerateRecoveryCodes godoc
+// @Summary Buat ulang recovery code
+// @Description Mengganti semua recovery code lama dengan yang baru. Memerlukan kode TOTP.
+// @Tags Two Factor
+// @Accept json
+// @Produce json
+// @Param body body model.TwoFactorCodeRequest true "Kode TOTP"
+// @Success 200 {object} map[string]interface{} "recovery codes"
+// @Failure 400 {object} map[string]interface{} "Kode tidak valid"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /me/2fa/recovery-codes [post]
+// @Security BearerAuth
+func (s *TwoFactorService) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	var req model.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Kode harus diisi"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := s.currentUser(c, ctx)
+	if err != nil || user == nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal mengambil data user"})
+	}
+
+	ok, err := verifySecondFactor(ctx, s.UserRepo, user, req.Code, "")
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "Kode tidak valid"})
+	}
+
+	codes, hashes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal membuat recovery code"})
+	}
+	if err := s.UserRepo.SetRecoveryCodes(ctx, user.ID, hashes); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	recordSecurityEvent(s.EventRepo, c, userSecurityEvent(model.SecurityEventRecoveryCodes, model.SecurityOutcomeSuccess, user))
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    fiber.Map{"recovery_codes": codes},
+	})
+}
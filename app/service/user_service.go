@@ -2,23 +2,420 @@ package service
 
 import (
 	"context"
+	"gofiber-mongo/app/model"
 	"gofiber-mongo/app/repository"
+	"gofiber-mongo/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type UserService struct {
-	Repo *repository.UserRepository
+	Repo        *repository.UserRepository
+	TokenRepo   *repository.TokenRepository
+	RoleRepo    *repository.RoleRepository
+	SessionRepo *repository.SessionRepository
+	AlumniRepo  *repository.AlumniRepository
+	EventRepo   *repository.SecurityEventRepository
 }
 
-func NewUserService(repo *repository.UserRepository) *UserService {
+func NewUserService(repo *repository.UserRepository, tokenRepo *repository.TokenRepository, roleRepo *repository.RoleRepository,
+	sessionRepo *repository.SessionRepository, alumniRepo *repository.AlumniRepository,
+	eventRepo *repository.SecurityEventRepository) *UserService {
 	return &UserService{
-		Repo: repo,
+		Repo:        repo,
+		TokenRepo:   tokenRepo,
+		RoleRepo:    roleRepo,
+		SessionRepo: sessionRepo,
+		AlumniRepo:  alumniRepo,
+		EventRepo:   eventRepo,
 	}
 }
 
+// isSelf mengecek apakah target aksi admin adalah akun pemanggil sendiri.
+func isSelf(c *fiber.Ctx, id primitive.ObjectID) bool {
+	userID, _ := c.Locals("user_id").(primitive.ObjectID)
+	return userID == id
+}
+
+// exceedsCaller mengecek apakah role memiliki permission yang tidak dimiliki pemanggil.
+func exceedsCaller(c *fiber.Ctx, role *model.Role) bool {
+	if role == nil {
+		return false
+	}
+	permissions, _ := c.Locals("permissions").([]string)
+	for _, p := range role.Permissions {
+		if !slices.Contains(permissions, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// canManage mengecek apakah pemanggil boleh mengubah akun user. Admin tidak boleh mereset
+// password atau menghapus user yang permission-nya melebihi miliknya sendiri.
+func (s *UserService) canManage(ctx context.Context, c *fiber.Ctx, user *model.User) (bool, error) {
+	role, err := s.RoleRepo.FindByName(ctx, user.Role)
+	if err != nil {
+		return false, err
+	}
+	return !exceedsCaller(c, role), nil
+}
+
+func forbiddenTarget(c *fiber.Ctx) error {
+	return c.Status(403).JSON(fiber.Map{"error": "Tidak boleh mengubah user dengan permission melebihi Anda"})
+}
+
+// HandleGetAll godoc
+// @Summary Get all users
+// @Description Mengambil daftar user dengan pagination, pencarian dan filter role/status
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param sortBy query string false "Sort field (username, email, role, created_at)" default(created_at)
+// @Param order query string false "Sort order (asc/desc)" default(desc)
+// @Param search query string false "Search by username or email"
+// @Param role query string false "Filter role"
+// @Param status query string false "active (default), deleted, all"
+// @Success 200 {object} map[string]interface{} "user list with metadata"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /users [get]
+// @Security BearerAuth
+func (s *UserService) GetAll(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	sortBy := c.Query("sortBy", "created_at")
+	order := strings.ToLower(c.Query("order", "desc"))
+	if order != "asc" {
+		order = "desc"
+	}
+	search := c.Query("search", "")
+	role := c.Query("role", "")
+	status := c.Query("status", "active")
+
+	offset := (page - 1) * limit
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	users, err := s.Repo.GetAllWithFilter(ctx, search, role, status, sortBy, order, limit, offset)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if users == nil {
+		users = []model.User{}
+	}
+
+	total, err := s.Repo.CountWithFilter(ctx, search, role, status)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	pages := (int(total) + limit - 1) / limit
+
+	meta := model.MetaInfo{
+		Page:   page,
+		Limit:  limit,
+		Total:  int(total),
+		Pages:  pages,
+		SortBy: sortBy,
+		Order:  order,
+		Search: search,
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    users,
+		"meta":    meta,
+	})
+}
+
+// HandleGetByID godoc
+// @Summary Get user by ID
+// @Description Mengambil detail user berdasarkan ID (termasuk user yang sudah dihapus)
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]interface{} "user data"
+// @Failure 400 {object} map[string]interface{} "ID tidak valid"
+// @Failure 404 {object} map[string]interface{} "User tidak ditemukan"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /users/{id} [get]
+// @Security BearerAuth
+func (s *UserService) GetByID(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := s.Repo.FindByID(ctx, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if user == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "User tidak ditemukan"})
+	}
+	return c.JSON(fiber.Map{"success": true, "data": user})
+}
+
+// HandleUpdateRole godoc
+// @Summary Ganti role user
+// @Description Mengganti role user. Token akses lama user langsung tidak berlaku.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param body body model.UpdateUserRoleRequest true "Role baru"
+// @Success 200 {object} map[string]interface{} "updated user"
+// @Failure 400 {object} map[string]interface{} "Request tidak valid"
+// @Failure 404 {object} map[string]interface{} "User tidak ditemukan"
+// @Failure 403 {object} map[string]interface{} "Permission user melebihi pemanggil"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /users/{id}/role [put]
+// @Security BearerAuth
+func (s *UserService) UpdateRole(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
+	}
+
+	var req model.UpdateUserRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
+	}
+	req.Role = strings.TrimSpace(req.Role)
+	if req.Role == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "role tidak boleh kosong"})
+	}
+	if isSelf(c, id) {
+		return c.Status(400).JSON(fiber.Map{"error": "Tidak bisa mengganti role akun sendiri"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	role, err := s.RoleRepo.FindByName(ctx, req.Role)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if role == nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Role tidak ditemukan"})
+	}
+	if exceedsCaller(c, role) {
+		return c.Status(403).JSON(fiber.Map{"error": "Tidak boleh memberikan role dengan permission melebihi Anda"})
+	}
+
+	user, err := s.Repo.FindByID(ctx, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if user == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "User tidak ditemukan"})
+	}
+	if allowed, err := s.canManage(ctx, c, user); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	} else if !allowed {
+		return forbiddenTarget(c)
+	}
+
+	if err := s.Repo.UpdateRole(ctx, id, role.Name); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	event := adminSecurityEvent(c, model.SecurityEventRoleChange, user)
+	event.Metadata = map[string]string{"from": user.Role, "to": role.Name}
+	recordSecurityEvent(s.EventRepo, c, event)
+	user.Role = role.Name
+	return c.JSON(fiber.Map{"success": true, "data": user})
+}
+
+// HandleResetPassword godoc
+// @Summary Reset password user (admin)
+// @Description Admin mengganti password user. Semua session user diakhiri.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param body body model.AdminResetPasswordRequest true "Password baru"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 400 {object} map[string]interface{} "Request tidak valid"
+// @Failure 404 {object} map[string]interface{} "User tidak ditemukan"
+// @Failure 422 {object} model.ValidationErrorResponse "Password tidak memenuhi kebijakan"
+// @Failure 403 {object} map[string]interface{} "Permission user melebihi pemanggil"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /users/{id}/password [put]
+// @Security BearerAuth
+func (s *UserService) ResetPassword(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
+	}
+
+	var req model.AdminResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
+	}
+	if req.Password == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "password tidak boleh kosong"})
+	}
+	// Password sendiri diganti lewat PUT /me/password yang memeriksa password lama
+	if isSelf(c, id) {
+		return c.Status(400).JSON(fiber.Map{"error": "Gunakan PUT /api/me/password untuk mengganti password sendiri"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := s.Repo.FindByID(ctx, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if user == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "User tidak ditemukan"})
+	}
+	if allowed, err := s.canManage(ctx, c, user); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	} else if !allowed {
+		return forbiddenTarget(c)
+	}
+
+	personal, err := passwordPersonalInfo(ctx, s.AlumniRepo, user)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if errs := utils.ValidatePassword("password", req.Password, personal...); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	hash, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal memproses password"})
+	}
+	if err := s.Repo.UpdatePassword(ctx, id, hash); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := revokeAllSessions(ctx, s.TokenRepo, s.SessionRepo, id); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	recordSecurityEvent(s.EventRepo, c, adminSecurityEvent(c, model.SecurityEventPasswordAdminReset, user))
+	return c.JSON(fiber.Map{"success": true, "message": "Password user berhasil direset"})
+}
+
+// HandleRestore godoc
+// @Summary Restore user
+// @Description Mengembalikan user yang sudah di-soft delete
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 400 {object} map[string]interface{} "ID tidak valid"
+// @Failure 403 {object} map[string]interface{} "Permission user melebihi pemanggil"
+// @Failure 404 {object} map[string]interface{} "User tidak ditemukan di trash"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /users/{id}/restore [put]
+// @Security BearerAuth
+func (s *UserService) Restore(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := s.Repo.FindByID(ctx, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if user == nil || !user.IsDelete {
+		return c.Status(404).JSON(fiber.Map{"error": "User tidak ditemukan di trash"})
+	}
+	if allowed, err := s.canManage(ctx, c, user); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	} else if !allowed {
+		return forbiddenTarget(c)
+	}
+
+	if err := s.Repo.Restore(ctx, id); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	recordSecurityEvent(s.EventRepo, c, adminSecurityEvent(c, model.SecurityEventUserRestore, user))
+	return c.JSON(fiber.Map{"success": true, "message": "User berhasil direstore"})
+}
+
+// HandleHardDelete godoc
+// @Summary Hard delete user
+// @Description Menghapus user secara permanen dari database dan mencabut semua token-nya
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 400 {object} map[string]interface{} "ID tidak valid"
+// @Failure 404 {object} map[string]interface{} "User tidak ditemukan"
+// @Failure 403 {object} map[string]interface{} "Permission user melebihi pemanggil"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /users/{id}/permanent [delete]
+// @Security BearerAuth
+func (s *UserService) HardDelete(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
+	}
+	if isSelf(c, id) {
+		return c.Status(400).JSON(fiber.Map{"error": "Tidak bisa menghapus akun sendiri"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := s.Repo.FindByID(ctx, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if user == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "User tidak ditemukan"})
+	}
+	if allowed, err := s.canManage(ctx, c, user); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	} else if !allowed {
+		return forbiddenTarget(c)
+	}
+
+	if err := revokeAllSessions(ctx, s.TokenRepo, s.SessionRepo, id); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := s.Repo.HardDelete(ctx, id); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	recordSecurityEvent(s.EventRepo, c, adminSecurityEvent(c, model.SecurityEventUserPurge, user))
+	return c.JSON(fiber.Map{"success": true, "message": "User berhasil dihapus permanen"})
+}
+
 // HandleSoftDelete godoc
 // @Summary Soft delete user
 // @Description Menghapus user dengan soft delete (set is_delete flag)
@@ -28,6 +425,8 @@ func NewUserService(repo *repository.UserRepository) *UserService {
 // @Param id path string true "User ID"
 // @Success 200 {object} map[string]interface{} "success response"
 // @Failure 400 {object} map[string]interface{} "ID tidak valid"
+// @Failure 403 {object} map[string]interface{} "Permission user melebihi pemanggil"
+// @Failure 404 {object} map[string]interface{} "User tidak ditemukan"
 // @Failure 500 {object} map[string]interface{} "error"
 // @Router /users/{id} [delete]
 // @Security BearerAuth
@@ -37,13 +436,123 @@ func (s *UserService) SoftDelete(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
 	}
+	if isSelf(c, id) {
+		return c.Status(400).JSON(fiber.Map{"error": "Tidak bisa menghapus akun sendiri"})
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	user, err := s.Repo.FindByID(ctx, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if user == nil || user.IsDelete {
+		return c.Status(404).JSON(fiber.Map{"error": "User tidak ditemukan"})
+	}
+	if allowed, err := s.canManage(ctx, c, user); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	} else if !allowed {
+		return forbiddenTarget(c)
+	}
+
 	err = s.Repo.SoftDelete(ctx, id)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
+
+	// Akhiri semua session agar user tidak bisa login ulang lewat refresh
+	if err := revokeAllSessions(ctx, s.TokenRepo, s.SessionRepo, id); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	recordSecurityEvent(s.EventRepo, c, adminSecurityEvent(c, model.SecurityEventUserDelete, user))
 	return c.JSON(fiber.Map{"success": true, "message": "User berhasil dihapus (soft delete)"})
 }
+
+// HandleVerifyEmail godoc
+// @Summary Verifikasi email user (admin override)
+// @Description Admin menandai email user sebagai terverifikasi tanpa token
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 400 {object} map[string]interface{} "ID tidak valid"
+// @Failure 403 {object} map[string]interface{} "Permission user melebihi pemanggil"
+// @Failure 404 {object} map[string]interface{} "User tidak ditemukan"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /users/{id}/verify-email [put]
+// @Security BearerAuth
+func (s *UserService) VerifyEmail(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := s.Repo.FindByID(ctx, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if user == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "User tidak ditemukan"})
+	}
+	if allowed, err := s.canManage(ctx, c, user); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	} else if !allowed {
+		return forbiddenTarget(c)
+	}
+
+	if err := s.Repo.MarkEmailVerified(ctx, id); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	recordSecurityEvent(s.EventRepo, c, adminSecurityEvent(c, model.SecurityEventEmailVerify, user))
+	return c.JSON(fiber.Map{"success": true, "message": "Email user berhasil diverifikasi oleh admin"})
+}
+
+// HandleUnlock godoc
+// @Summary Buka kunci akun user
+// @Description Admin mereset counter login gagal dan menghapus lockout sementara
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]interface{} "success response"
+// @Failure 400 {object} map[string]interface{} "ID tidak valid"
+// @Failure 403 {object} map[string]interface{} "Permission user melebihi pemanggil"
+// @Failure 404 {object} map[string]interface{} "User tidak ditemukan"
+// @Failure 500 {object} map[string]interface{} "error"
+// @Router /users/{id}/unlock [put]
+// @Security BearerAuth
+func (s *UserService) Unlock(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID tidak valid"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := s.Repo.FindByID(ctx, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if user == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "User tidak ditemukan"})
+	}
+	if allowed, err := s.canManage(ctx, c, user); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	} else if !allowed {
+		return forbiddenTarget(c)
+	}
+
+	if err := s.Repo.ResetFailedLogins(ctx, id); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	recordSecurityEvent(s.EventRepo, c, adminSecurityEvent(c, model.SecurityEventUserUnlock, user))
+	return c.JSON(fiber.Map{"success": true, "message": "Akun user berhasil dibuka"})
+}
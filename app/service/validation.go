@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gofiber-mongo/app/model"
+	"gofiber-mongo/app/repository"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// validationFailed mengirim respons 422 berisi semua field yang tidak valid.
+func validationFailed(c *fiber.Ctx, errs []model.FieldError) error {
+	return c.Status(fiber.StatusUnprocessableEntity).JSON(model.ValidationErrorResponse{
+		Error:  "Validasi gagal",
+		Errors: errs,
+	})
+}
+
+var duplicateMessages = map[string]string{
+	"nim":          "NIM sudah terdaftar",
+	"email":        "Email sudah terdaftar",
+	"username":     "Username sudah terdaftar",
+	"oidc_subject": "Identitas SSO sudah ditautkan ke akun lain",
+}
+
+// storeFailed mengirim 409 Conflict jika err adalah pelanggaran unique index
+// (lihat repository.EnsureIndexes), 412 jika versi dokumen sudah berubah, selain itu 500.
+func storeFailed(c *fiber.Ctx, err error) error {
+	if errors.Is(err, repository.ErrVersionMismatch) {
+		return preconditionFailed(c)
+	}
+	field, ok := repository.DuplicateKeyField(err)
+	if !ok {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	message, known := duplicateMessages[field]
+	if !known {
+		message = "Data sudah ada"
+	}
+	return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+		"error": message,
+		"code":  "duplicate",
+		"field": field,
+	})
+}
+
+// passwordPersonalInfo mengumpulkan data user yang tidak boleh dipakai di dalam password:
+// username, bagian lokal email, dan NIM alumni yang ditautkan ke akun.
+func passwordPersonalInfo(ctx context.Context, alumniRepo *repository.AlumniRepository, user *model.User) ([]string, error) {
+	info := []string{user.Username, strings.SplitN(user.Email, "@", 2)[0]}
+
+	alumni, err := alumniRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if alumni != nil {
+		info = append(info, alumni.NIM)
+	}
+	return info, nil
+}
@@ -0,0 +1,43 @@
+// Command mock-oidc menjalankan identity provider OpenID Connect palsu (package oidctest)
+// untuk pengembangan lokal dan pengujian login SSO.
+//
+//	go run ./cmd/mock-oidc
+//
+// lalu set di .env API:
+//
+//	OIDC_ISSUER=http://localhost:9000
+//	OIDC_CLIENT_ID=alumni-api
+package main
+
+import (
+	"gofiber-mongo/oidc/oidctest"
+	"log"
+	"net/http"
+	"os"
+)
+
+func env(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func main() {
+	addr := env("MOCK_OIDC_ADDR", ":9000")
+
+	cfg := oidctest.Config{
+		Issuer:        env("MOCK_OIDC_ISSUER", "http://localhost:9000"),
+		ClientID:      env("MOCK_OIDC_CLIENT_ID", "alumni-api"),
+		Email:         env("MOCK_OIDC_EMAIL", "staff@example.ac.id"),
+		EmailVerified: env("MOCK_OIDC_EMAIL_VERIFIED", "true") == "true",
+		Name:          os.Getenv("MOCK_OIDC_NAME"),
+	}
+	s, err := oidctest.NewServer(cfg)
+	if err != nil {
+		log.Fatalf("Gagal generate RSA key: %v", err)
+	}
+
+	log.Printf("Mock OIDC provider berjalan di %s (issuer %s, client_id %s)", addr, cfg.Issuer, cfg.ClientID)
+	log.Fatal(http.ListenAndServe(addr, s.Handler()))
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"context"
+	"gofiber-mongo/middleware"
 	"gofiber-mongo/route"
 	"time"
 
@@ -27,6 +28,8 @@ func NewApp() *fiber.App {
 
 	db := client.Database("alumni_db")
 
+	route.RegisterAuthRoutes(app, db)
+	app.Use("/api", middleware.AuthRequired(db))
 	route.RegisterRoutes(app, db)
 
 	return app
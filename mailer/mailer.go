@@ -0,0 +1,48 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"strconv"
+)
+
+// Message adalah email teks sederhana.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer mengirim email. Implementasi: SMTPMailer (produksi) dan OutboxMailer (lokal/test).
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewFromEnv memilih implementasi berdasarkan MAIL_DRIVER ("smtp" atau "outbox", default "outbox").
+func NewFromEnv() Mailer {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@localhost"
+	}
+
+	switch os.Getenv("MAIL_DRIVER") {
+	case "smtp":
+		port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+		if err != nil {
+			port = 587
+		}
+		return &SMTPMailer{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}
+	default:
+		dir := os.Getenv("MAIL_OUTBOX_DIR")
+		if dir == "" {
+			dir = "./storage/outbox"
+		}
+		return &OutboxMailer{Dir: dir, From: from}
+	}
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	_ "gofiber-mongo/docs" // Import docs for Swagger
-	"gofiber-mongo/app/model"
 	"gofiber-mongo/app/repository"
 	"gofiber-mongo/middleware"
 	"gofiber-mongo/route"
@@ -30,6 +29,10 @@ import (
 // @in header
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key untuk akses mesin-ke-mesin (gfm_<prefix>_<secret>).
 
 func connectMongoDB() *mongo.Database {
 	mongoURI := os.Getenv("MONGODB_URI")
@@ -72,8 +75,34 @@ func main() {
 		os.Setenv("APP_PORT", "3000")
 	}
 
+	if err := utils.InitKeys(); err != nil {
+		log.Fatalf("Konfigurasi kunci JWT tidak valid: %v", err)
+	}
+
 	db := connectMongoDB()
 
+	// User lama (sebelum ada verifikasi email) dianggap sudah terverifikasi
+	migrateCtx, cancelMigrate := context.WithTimeout(context.Background(), 10*time.Second)
+	if n, err := repository.NewUserRepository(db).MarkLegacyEmailsVerified(migrateCtx); err != nil {
+		log.Printf("Peringatan: gagal menandai email user lama sebagai terverifikasi: %v", err)
+	} else if n > 0 {
+		log.Printf("%d user lama ditandai email terverifikasi", n)
+	}
+
+	// Role bawaan (admin, user) untuk RBAC
+	if err := repository.NewRoleRepository(db).EnsureDefaultRoles(migrateCtx); err != nil {
+		log.Fatalf("Gagal menyiapkan role bawaan: %v", err)
+	}
+	cancelMigrate()
+
+	// Index (termasuk unique NIM / username / email). Gagal membuat index tidak menghentikan
+	// server, tapi duplikat tidak akan terdeteksi sampai data bentrok dibereskan.
+	indexCtx, cancelIndex := context.WithTimeout(context.Background(), 60*time.Second)
+	if err := repository.EnsureIndexes(indexCtx, db); err != nil {
+		log.Printf("Peringatan: gagal membuat index: %v", err)
+	}
+	cancelIndex()
+
 	app := fiber.New(fiber.Config{
 		BodyLimit: 10 * 1024 * 1024, // 10MB
 	})
@@ -83,101 +112,14 @@ func main() {
 	app.Get("/swagger/*", fiberSwagger.WrapHandler)
 
 	// =====================
-	// REGISTER HANDLER
+	// PUBLIC AUTH ROUTES
 	// =====================
-	app.Post("/api/register", func(c *fiber.Ctx) error {
-		var req model.LoginRequest
-		if err := c.BodyParser(&req); err != nil {
-			return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
-		}
-
-		if req.Username == "" || req.Password == "" {
-			return c.Status(400).JSON(fiber.Map{"error": "Username dan password harus diisi"})
-		}
-
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-
-		userRepo := repository.NewUserRepository(db)
-
-		existingUser, _ := userRepo.FindByUsername(ctx, req.Username)
-		if existingUser != nil {
-			return c.Status(400).JSON(fiber.Map{"error": "Username sudah terdaftar"})
-		}
-
-		hashedPassword, err := utils.HashPassword(req.Password)
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "Gagal hash password"})
-		}
-
-		newUser := &model.User{
-			Username:  req.Username,
-			Email:     req.Username + "@example.com",
-			Password:  hashedPassword,
-			Role:      "user",
-			IsDelete:  false,
-			CreatedAt: time.Now(),
-		}
-
-		createdUser, err := userRepo.Create(ctx, newUser)
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "Gagal membuat user"})
-		}
-
-		return c.Status(201).JSON(fiber.Map{
-			"message": "User berhasil dibuat",
-			"user": fiber.Map{
-				"id":       createdUser.ID,
-				"username": createdUser.Username,
-				"role":     createdUser.Role,
-			},
-		})
-	})
-
-	// =====================
-	// LOGIN HANDLER
-	// =====================
-	app.Post("/api/login", func(c *fiber.Ctx) error {
-		var req model.LoginRequest
-		if err := c.BodyParser(&req); err != nil {
-			return c.Status(400).JSON(fiber.Map{"error": "Request tidak valid"})
-		}
-
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-
-		userRepo := repository.NewUserRepository(db)
-		user, err := userRepo.FindByUsername(ctx, req.Username)
-		if err != nil || user == nil {
-			user, err = userRepo.FindByEmail(ctx, req.Username)
-			if err != nil || user == nil {
-				return c.Status(401).JSON(fiber.Map{"error": "Username atau password salah"})
-			}
-		}
-
-		if user.IsDelete {
-			return c.Status(401).JSON(fiber.Map{"error": "User telah dihapus"})
-		}
-
-		if !utils.CheckPassword(req.Password, user.Password) {
-			return c.Status(401).JSON(fiber.Map{"error": "Username atau password salah"})
-		}
-
-		token, err := utils.GenerateToken(user)
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "Gagal generate token"})
-		}
-
-		return c.JSON(model.LoginResponse{
-			User:  *user,
-			Token: token,
-		})
-	})
+	route.RegisterAuthRoutes(app, db)
 
 	// =====================
 	// PROTECTED ROUTES
 	// =====================
-	app.Use("/api", middleware.AuthRequired())
+	app.Use("/api", middleware.AuthRequired(db))
 
 	route.RegisterRoutes(app, db)
 
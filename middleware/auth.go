@@ -1,15 +1,34 @@
 package middleware
 
 import (
+	"context"
+	"crypto/subtle"
+	"gofiber-mongo/app/model"
+	"gofiber-mongo/app/repository"
 	"gofiber-mongo/utils"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // Middleware untuk memerlukan login
-func AuthRequired() fiber.Handler {
+func AuthRequired(db *mongo.Database) fiber.Handler {
+	userRepo := repository.NewUserRepository(db)
+	tokenRepo := repository.NewTokenRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	auditRepo := repository.NewAuditLogRepository(db)
+
 	return func(c *fiber.Ctx) error {
+		// API key untuk akses mesin-ke-mesin, lewat header X-API-Key atau "Authorization: ApiKey KEY"
+		if key := apiKeyFromRequest(c); key != "" {
+			return authenticateAPIKey(c, key, apiKeyRepo, userRepo, roleRepo)
+		}
+
 		// Ambil token dari header Authorization
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
@@ -34,23 +53,204 @@ func AuthRequired() fiber.Handler {
 			})
 		}
 
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		// Token yang sudah di-logout
+		if claims.ID != "" {
+			revoked, err := tokenRepo.IsAccessTokenRevoked(ctx, claims.ID)
+			if err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+			}
+			if revoked {
+				return c.Status(401).JSON(fiber.Map{
+					"error": "Token sudah dicabut",
+				})
+			}
+		}
+
+		// Session yang sudah di-logout (dari perangkat ini, perangkat lain, atau oleh admin)
+		if claims.SessionID != "" {
+			session, err := sessionRepo.FindByID(ctx, claims.SessionID)
+			if err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+			}
+			if session == nil || session.RevokedAt != nil || session.UserID != claims.UserID {
+				return c.Status(401).JSON(fiber.Map{
+					"error": "Sesi sudah berakhir, silakan login ulang",
+				})
+			}
+			if err := sessionRepo.Touch(ctx, session.ID, c.IP()); err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+			}
+		}
+
+		// Token impersonation hanya berlaku selama admin-nya masih login dan masih berhak
+		if claims.Act != nil {
+			ok, err := impersonatorValid(ctx, claims.Act, userRepo, roleRepo, sessionRepo)
+			if err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+			}
+			if !ok {
+				return c.Status(401).JSON(fiber.Map{
+					"error": "Sesi impersonation sudah berakhir",
+				})
+			}
+		}
+
+		// User yang dihapus atau diubah role-nya tidak boleh memakai token lama
+		user, err := userRepo.FindByID(ctx, claims.UserID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		if user == nil || user.IsDelete || user.Role != claims.Role {
+			return c.Status(401).JSON(fiber.Map{
+				"error": "Token sudah tidak berlaku",
+			})
+		}
+
+		// Role yang wajib 2FA tapi belum mendaftar hanya boleh mengakses endpoint pendaftaran 2FA
+		if claims.Act == nil && utils.RequiresTwoFactor(user.Role) && !user.TwoFactorEnabled &&
+			!strings.HasPrefix(c.Path(), "/api/me/2fa") && c.Path() != "/api/logout" {
+			return c.Status(403).JSON(fiber.Map{
+				"error": "Aktifkan 2FA terlebih dahulu untuk role " + user.Role,
+				"code":  "two_factor_setup_required",
+			})
+		}
+
+		// Permission di-resolve dari role setiap request, jadi perubahan role langsung berlaku
+		permissions := []string{}
+		role, err := roleRepo.FindByName(ctx, user.Role)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		if role != nil {
+			permissions = role.Permissions
+		}
+
 		// Simpan informasi user di context
 		c.Locals("user_id", claims.UserID)
 		c.Locals("username", claims.Username)
 		c.Locals("role", claims.Role)
+		c.Locals("permissions", permissions)
+		c.Locals("claims", claims)
+
+		if claims.Act != nil {
+			c.Locals("impersonator_id", claims.Act.UserID)
+			return serveImpersonated(c, claims, auditRepo)
+		}
 
 		return c.Next()
 	}
 }
 
-// Middleware untuk memerlukan role admin
-func AdminOnly() fiber.Handler {
+func apiKeyFromRequest(c *fiber.Ctx) string {
+	if key := c.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if key, ok := strings.CutPrefix(c.Get("Authorization"), "ApiKey "); ok {
+		return strings.TrimSpace(key)
+	}
+	return ""
+}
+
+func authenticateAPIKey(c *fiber.Ctx, key string, apiKeyRepo *repository.APIKeyRepository,
+	userRepo *repository.UserRepository, roleRepo *repository.RoleRepository) error {
+	invalid := func() error {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "API key tidak valid atau expired",
+		})
+	}
+
+	// Endpoint akun pribadi (/api/me, logout) hanya untuk login user, bukan API key
+	if personalEndpoint(c.Path()) {
+		return c.Status(403).JSON(fiber.Map{
+			"error": "Endpoint ini tidak dapat diakses dengan API key",
+		})
+	}
+
+	// Format: gfm_<prefix>_<secret>
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != model.APIKeyPrefix {
+		return invalid()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	apiKey, err := apiKeyRepo.FindByPrefix(ctx, parts[1])
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if apiKey == nil || subtle.ConstantTimeCompare([]byte(apiKey.KeyHash), []byte(utils.HashToken(key))) != 1 {
+		return invalid()
+	}
+	if apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && time.Now().After(*apiKey.ExpiresAt)) {
+		return invalid()
+	}
+
+	// Key ikut mati jika pembuatnya dihapus, dan tidak pernah melebihi permission pembuatnya saat ini
+	creator, err := userRepo.FindByID(ctx, apiKey.CreatedBy)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if creator == nil || creator.IsDelete {
+		return invalid()
+	}
+	role, err := roleRepo.FindByName(ctx, creator.Role)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	permissions := []string{}
+	if role != nil {
+		for _, p := range apiKey.Permissions {
+			if slices.Contains(role.Permissions, p) {
+				permissions = append(permissions, p)
+			}
+		}
+	}
+
+	if err := apiKeyRepo.TouchLastUsed(ctx, apiKey.ID, c.IP()); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	c.Locals("user_id", apiKey.CreatedBy)
+	c.Locals("username", "apikey:"+apiKey.Name)
+	c.Locals("role", "")
+	c.Locals("permissions", permissions)
+	c.Locals("api_key_id", apiKey.ID)
+
+	return c.Next()
+}
+
+// personalEndpoint mengecek apakah path adalah endpoint akun pribadi. Routing Fiber tidak
+// membedakan huruf besar dan garis miring di akhir, jadi path dinormalisasi dulu.
+func personalEndpoint(path string) bool {
+	path = strings.TrimRight(strings.ToLower(path), "/")
+	return path == "/api/me" || strings.HasPrefix(path, "/api/me/") || path == "/api/logout"
+}
+
+// HasPermission memeriksa apakah pemanggil (hasil AuthRequired) memiliki permission tertentu.
+func HasPermission(c *fiber.Ctx, permission string) bool {
+	permissions, _ := c.Locals("permissions").([]string)
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware untuk memerlukan semua permission yang disebutkan
+func RequirePermission(permissions ...string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		role := c.Locals("role").(string)
-		if role != "admin" {
-			return c.Status(403).JSON(fiber.Map{
-				"error": "Akses ditolak. Hanya admin yang diizinkan",
-			})
+		for _, p := range permissions {
+			if !HasPermission(c, p) {
+				return c.Status(403).JSON(fiber.Map{
+					"error":      "Akses ditolak. Permission tidak mencukupi",
+					"permission": p,
+				})
+			}
 		}
 		return c.Next()
 	}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"gofiber-mongo/app/model"
+	"gofiber-mongo/app/repository"
+	"gofiber-mongo/utils"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Endpoint kredensial, akses akun dan ekspor data pribadi yang tidak pernah boleh diakses
+// selama impersonation, termasuk dengan GET
+var impersonationForbiddenPrefixes = []string{
+	"/api/me/password",
+	"/api/me/2fa",
+	"/api/me/sessions",
+	"/api/me/erasure",
+	"/api/me/export",
+	"/api/api-keys",
+}
+
+// impersonationAlwaysAllowed adalah request yang mengubah data tetapi selalu diizinkan,
+// mis. logout supaya admin bisa mengakhiri impersonation.
+var impersonationAlwaysAllowed = []utils.ImpersonationWrite{
+	{Method: fiber.MethodPost, Path: "/api/logout"},
+}
+
+// impersonatorValid memastikan admin di claim "act" masih ada, masih punya permission
+// users:impersonate, dan session login-nya belum dicabut.
+func impersonatorValid(ctx context.Context, act *model.ActorClaim, userRepo *repository.UserRepository,
+	roleRepo *repository.RoleRepository, sessionRepo *repository.SessionRepository) (bool, error) {
+	actor, err := userRepo.FindByID(ctx, act.UserID)
+	if err != nil {
+		return false, err
+	}
+	if actor == nil || actor.IsDelete {
+		return false, nil
+	}
+
+	role, err := roleRepo.FindByName(ctx, actor.Role)
+	if err != nil {
+		return false, err
+	}
+	if role == nil || !slices.Contains(role.Permissions, model.PermUsersImpersonate) {
+		return false, nil
+	}
+
+	if act.SessionID != "" {
+		session, err := sessionRepo.FindByID(ctx, act.SessionID)
+		if err != nil {
+			return false, err
+		}
+		if session == nil || session.RevokedAt != nil || session.UserID != actor.ID {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// pathWithin mengecek apakah path sama dengan prefix atau berada di bawahnya.
+func pathWithin(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// impersonationBlocked menentukan apakah request tidak boleh dijalankan selama impersonation.
+// Endpoint kredensial dan ekspor data pribadi selalu ditolak. Selain itu, request baca (GET,
+// HEAD, OPTIONS) dan logout diizinkan; request lain yang mengubah data hanya diizinkan jika
+// IMPERSONATION_ALLOW_DESTRUCTIVE=true atau cocok dengan IMPERSONATION_ALLOWED_WRITES.
+func impersonationBlocked(c *fiber.Ctx) bool {
+	// Routing Fiber tidak membedakan huruf besar dan garis miring di akhir
+	path := strings.TrimRight(strings.ToLower(c.Path()), "/")
+	for _, prefix := range impersonationForbiddenPrefixes {
+		if pathWithin(path, prefix) {
+			return true
+		}
+	}
+	if strings.HasSuffix(path, "/impersonate") {
+		return true
+	}
+
+	switch c.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return false
+	}
+	for _, w := range impersonationAlwaysAllowed {
+		if w.Method == c.Method() && path == w.Path {
+			return false
+		}
+	}
+
+	if utils.ImpersonationAllowDestructive() {
+		return false
+	}
+	for _, w := range utils.ImpersonationAllowedWrites() {
+		if w.Method == c.Method() && pathWithin(path, w.Path) {
+			return false
+		}
+	}
+	return true
+}
+
+// serveImpersonated menjalankan request impersonation dan mencatatnya di audit log.
+// Entry ditulis sebelum handler dijalankan; jika gagal, request ditolak.
+func serveImpersonated(c *fiber.Ctx, claims *model.JWTClaims, auditRepo *repository.AuditLogRepository) error {
+	blocked := impersonationBlocked(c)
+	entry := &model.AuditLog{
+		Action:        model.AuditActionImpersonationRequest,
+		ActorID:       claims.Act.UserID,
+		ActorUsername: claims.Act.Username,
+		UserID:        claims.UserID,
+		Method:        c.Method(),
+		Path:          c.Path(),
+		Blocked:       blocked,
+		IP:            c.IP(),
+		UserAgent:     c.Get("User-Agent"),
+	}
+	if blocked {
+		entry.Status = fiber.StatusForbidden
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := auditRepo.Create(ctx, entry); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if blocked {
+		return c.Status(403).JSON(fiber.Map{
+			"error": "Aksi ini tidak diizinkan selama impersonation",
+			"code":  "impersonation_forbidden",
+		})
+	}
+
+	err := c.Next()
+
+	status := c.Response().StatusCode()
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		status = fiberErr.Code
+	} else if err != nil {
+		status = fiber.StatusInternalServerError
+	}
+
+	statusCtx, statusCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer statusCancel()
+	if serr := auditRepo.SetStatus(statusCtx, entry.ID, status); serr != nil {
+		log.Printf("Gagal mencatat status audit log %s: %v", entry.ID.Hex(), serr)
+	}
+	return err
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestImpersonationBlocked(t *testing.T) {
+	var blocked bool
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		blocked = impersonationBlocked(c)
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	tests := []struct {
+		name        string
+		destructive string
+		writes      string
+		method      string
+		path        string
+		want        bool
+	}{
+		{name: "GET diizinkan", method: http.MethodGet, path: "/api/alumni", want: false},
+		{name: "HEAD diizinkan", method: http.MethodHead, path: "/api/me", want: false},
+		{name: "OPTIONS diizinkan", method: http.MethodOptions, path: "/api/alumni", want: false},
+		{name: "POST ditolak secara default", method: http.MethodPost, path: "/api/me/pekerjaan", want: true},
+		{name: "PUT ditolak secara default", method: http.MethodPut, path: "/api/me/alumni", want: true},
+		{name: "PATCH ditolak secara default", method: http.MethodPatch, path: "/api/pekerjaan/1", want: true},
+		{name: "DELETE ditolak secara default", method: http.MethodDelete, path: "/api/me/pekerjaan/1", want: true},
+		{name: "allow destructive", destructive: "true", method: http.MethodDelete, path: "/api/me/pekerjaan/1", want: false},
+		{name: "kredensial tetap ditolak", destructive: "true", method: http.MethodPut, path: "/api/me/password", want: true},
+		{name: "kredensial huruf besar", destructive: "true", method: http.MethodPost, path: "/API/Me/2FA/disable", want: true},
+		{name: "impersonate bertingkat", destructive: "true", method: http.MethodPost, path: "/api/users/1/impersonate/", want: true},
+		{name: "allowlist cocok", writes: "PUT /api/me/alumni, post /api/me/pekerjaan", method: http.MethodPost, path: "/api/me/pekerjaan", want: false},
+		{name: "allowlist sub-path", writes: "PUT /api/me/pekerjaan", method: http.MethodPut, path: "/api/me/pekerjaan/1", want: false},
+		{name: "allowlist method lain", writes: "PUT /api/me/pekerjaan", method: http.MethodDelete, path: "/api/me/pekerjaan/1", want: true},
+		{name: "allowlist bukan prefix segmen", writes: "PUT /api/me/alumni", method: http.MethodPut, path: "/api/me/alumnix", want: true},
+		{name: "allowlist tidak membuka kredensial", writes: "PUT /api/me", method: http.MethodPut, path: "/api/me/password", want: true},
+		{name: "ekspor data pribadi ditolak", method: http.MethodGet, path: "/api/me/export", want: true},
+		{name: "ekspor data pribadi huruf besar", destructive: "true", method: http.MethodGet, path: "/api/Me/Export/", want: true},
+		{name: "daftar session ditolak", method: http.MethodGet, path: "/api/me/sessions", want: true},
+		{name: "logout diizinkan", method: http.MethodPost, path: "/api/logout", want: false},
+		{name: "logout huruf besar", method: http.MethodPost, path: "/api/Logout/", want: false},
+		{name: "logout method lain", method: http.MethodDelete, path: "/api/logout", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("IMPERSONATION_ALLOW_DESTRUCTIVE", tt.destructive)
+			t.Setenv("IMPERSONATION_ALLOWED_WRITES", tt.writes)
+			if _, err := app.Test(httptest.NewRequest(tt.method, tt.path, nil), -1); err != nil {
+				t.Fatal(err)
+			}
+			if blocked != tt.want {
+				t.Fatalf("%s %s: blocked = %v, want %v", tt.method, tt.path, blocked, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+// Package oidctest adalah identity provider OpenID Connect palsu untuk pengembangan lokal
+// dan pengujian login SSO. Setiap permintaan /authorize langsung disetujui sebagai user
+// yang dikonfigurasi (atau email dari parameter login_hint), tanpa halaman login.
+// Dipakai oleh cmd/mock-oidc dan oleh test lewat httptest.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const keyID = "mock-oidc"
+
+// Config adalah konfigurasi provider palsu.
+type Config struct {
+	Issuer        string
+	ClientID      string
+	Email         string // email default jika login_hint kosong
+	EmailVerified bool
+	Name          string // default: bagian lokal email
+}
+
+type authCode struct {
+	clientID      string
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+	email         string
+	expiresAt     time.Time
+}
+
+// Server menerbitkan ID token RS256 untuk authorization code flow dengan PKCE S256.
+type Server struct {
+	cfg Config
+	key *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]authCode
+}
+
+// NewServer membuat provider dengan kunci RSA baru.
+func NewServer(cfg Config) (*Server, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Issuer = strings.TrimRight(cfg.Issuer, "/")
+	return &Server{cfg: cfg, key: key, codes: map[string]authCode{}}, nil
+}
+
+// Handler mengembalikan endpoint discovery, authorize, token dan jwks.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/authorize", s.authorize)
+	mux.HandleFunc("/token", s.token)
+	mux.HandleFunc("/jwks", s.jwks)
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func oauthError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, map[string]string{"error": code, "error_description": description})
+}
+
+func (s *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	issuer := s.cfg.Issuer
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                issuer,
+		"authorization_endpoint":                issuer + "/authorize",
+		"token_endpoint":                        issuer + "/token",
+		"jwks_uri":                              issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirectURI := q.Get("redirect_uri")
+	if q.Get("client_id") != s.cfg.ClientID || redirectURI == "" {
+		oauthError(w, http.StatusBadRequest, "invalid_request", "client_id atau redirect_uri tidak valid")
+		return
+	}
+	if q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		oauthError(w, http.StatusBadRequest, "invalid_request", "hanya response_type=code dengan PKCE S256 yang didukung")
+		return
+	}
+
+	email := q.Get("login_hint")
+	if email == "" {
+		email = s.cfg.Email
+	}
+
+	code := randomString()
+	s.mu.Lock()
+	s.codes[code] = authCode{
+		clientID:      s.cfg.ClientID,
+		redirectURI:   redirectURI,
+		codeChallenge: q.Get("code_challenge"),
+		nonce:         q.Get("nonce"),
+		email:         email,
+		expiresAt:     time.Now().Add(time.Minute),
+	}
+	s.mu.Unlock()
+
+	target, err := url.Parse(redirectURI)
+	if err != nil {
+		oauthError(w, http.StatusBadRequest, "invalid_request", "redirect_uri tidak valid")
+		return
+	}
+	params := target.Query()
+	params.Set("code", code)
+	params.Set("state", q.Get("state"))
+	target.RawQuery = params.Encode()
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		oauthError(w, http.StatusMethodNotAllowed, "invalid_request", "gunakan POST")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		oauthError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	clientID := r.PostForm.Get("client_id")
+	if user, _, ok := r.BasicAuth(); ok {
+		clientID, _ = url.QueryUnescape(user)
+	}
+
+	code := r.PostForm.Get("code")
+	s.mu.Lock()
+	grant, ok := s.codes[code]
+	delete(s.codes, code)
+	s.mu.Unlock()
+
+	switch {
+	case r.PostForm.Get("grant_type") != "authorization_code":
+		oauthError(w, http.StatusBadRequest, "unsupported_grant_type", "hanya authorization_code")
+		return
+	case !ok || time.Now().After(grant.expiresAt):
+		oauthError(w, http.StatusBadRequest, "invalid_grant", "code tidak valid atau expired")
+		return
+	case clientID != grant.clientID || r.PostForm.Get("redirect_uri") != grant.redirectURI:
+		oauthError(w, http.StatusBadRequest, "invalid_grant", "client_id atau redirect_uri tidak cocok")
+		return
+	}
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != grant.codeChallenge {
+		oauthError(w, http.StatusBadRequest, "invalid_grant", "code_verifier tidak cocok")
+		return
+	}
+
+	now := time.Now()
+	username := strings.SplitN(grant.email, "@", 2)[0]
+	name := s.cfg.Name
+	if name == "" {
+		name = username
+	}
+	claims := jwt.MapClaims{
+		"iss":                s.cfg.Issuer,
+		"sub":                "mock|" + grant.email,
+		"aud":                s.cfg.ClientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(5 * time.Minute).Unix(),
+		"nonce":              grant.nonce,
+		"email":              grant.email,
+		"email_verified":     s.cfg.EmailVerified,
+		"name":               name,
+		"preferred_username": username,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+	idToken, err := token.SignedString(s.key)
+	if err != nil {
+		oauthError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func (s *Server) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := s.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": keyID,
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func randomString() string {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		panic(fmt.Sprintf("crypto/rand: %v", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,32 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// randomString menghasilkan string acak base64url dari n byte.
+func randomString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// NewCodeVerifier membuat code_verifier PKCE (RFC 7636), 43 karakter base64url.
+func NewCodeVerifier() (string, error) {
+	return randomString(32)
+}
+
+// NewState membuat nilai acak untuk parameter state / nonce.
+func NewState() (string, error) {
+	return randomString(24)
+}
+
+// CodeChallengeS256 menghitung code_challenge dengan metode S256.
+func CodeChallengeS256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
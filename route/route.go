@@ -1,20 +1,62 @@
 package route
 
 import (
+	"gofiber-mongo/app/model"
 	"gofiber-mongo/app/repository"
 	"gofiber-mongo/app/service"
+	"gofiber-mongo/mailer"
 	"gofiber-mongo/middleware"
 
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// RegisterAuthRoutes mendaftarkan endpoint publik (tanpa token akses).
+// Harus dipanggil sebelum middleware AuthRequired dipasang pada /api.
+func RegisterAuthRoutes(app *fiber.App, db *mongo.Database) {
+	mail := mailer.NewFromEnv()
+	authService := service.NewAuthService(db, mail)
+
+	app.Get("/.well-known/jwks.json", authService.JWKS)
+
+	api := app.Group("/api")
+	api.Post("/register", authService.Register)
+	api.Post("/login", authService.Login)
+	api.Post("/login/2fa", authService.LoginTwoFactor)
+	api.Post("/token/refresh", authService.Refresh)
+	api.Post("/forgot-password", authService.ForgotPassword)
+	api.Post("/reset-password", authService.ResetPassword)
+	api.Post("/verify-email", authService.VerifyEmail)
+	api.Post("/verify-email/resend", authService.ResendVerification)
+
+	// Undangan pembuatan akun alumni
+	invitationService := service.NewInvitationService(repository.NewInvitationRepository(db),
+		repository.NewAlumniRepository(db), repository.NewUserRepository(db), mail)
+	api.Get("/invitations/preview", invitationService.Preview)
+	api.Post("/invitations/accept", invitationService.Accept)
+
+	// Login SSO (OpenID Connect)
+	oidcService := service.NewOIDCService(db, authService)
+	api.Get("/oidc/login", oidcService.Login)
+	api.Get("/oidc/callback", oidcService.Callback)
+}
+
+// RegisterRoutes mendaftarkan endpoint yang dilindungi. Semua route di bawah /api
+// sudah melewati middleware.AuthRequired yang dipasang di main.
 func RegisterRoutes(app *fiber.App, db *mongo.Database) {
+	tokenRepo := repository.NewTokenRepository(db)
+
 	alumniRepo := repository.NewAlumniRepository(db)
 	alumniService := service.NewAlumniService(alumniRepo)
 
 	userRepo := repository.NewUserRepository(db)
-	userService := service.NewUserService(userRepo)
+	roleRepo := repository.NewRoleRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	eventRepo := repository.NewSecurityEventRepository(db)
+	userService := service.NewUserService(userRepo, tokenRepo, roleRepo, sessionRepo, alumniRepo, eventRepo)
+
+	mail := mailer.NewFromEnv()
+	authService := service.NewAuthService(db, mail)
 
 	pekerjaanRepo := repository.NewPekerjaanRepository(db)
 	pekerjaanService := service.NewPekerjaanService(pekerjaanRepo, db)
@@ -24,43 +66,156 @@ func RegisterRoutes(app *fiber.App, db *mongo.Database) {
 
 	api := app.Group("/api")
 
+	// Logout (cabut access token + refresh token)
+	api.Post("/logout", authService.Logout)
+
+	// Two-factor authentication (TOTP)
+	twoFactorService := service.NewTwoFactorService(userRepo, eventRepo)
+	api.Get("/me/2fa", twoFactorService.Status)
+	api.Post("/me/2fa/setup", twoFactorService.Setup)
+	api.Post("/me/2fa/enable", twoFactorService.Enable)
+	api.Post("/me/2fa/disable", twoFactorService.Disable)
+	api.Post("/me/2fa/recovery-codes", twoFactorService.RegenerateRecoveryCodes)
+
+	// Profil sendiri (alumni di-resolve lewat Alumni.UserID)
+	meService := service.NewMeService(userRepo, alumniRepo, pekerjaanService)
+	api.Get("/me", meService.Get)
+	api.Get("/me/alumni", meService.GetAlumni)
+	api.Put("/me/alumni", meService.UpdateAlumni)
+	api.Get("/me/pekerjaan", meService.GetPekerjaan)
+	api.Get("/me/pekerjaan/:id", meService.GetPekerjaanByID)
+	api.Post("/me/pekerjaan", meService.CreatePekerjaan)
+	api.Put("/me/pekerjaan/:id", meService.UpdatePekerjaan)
+	api.Delete("/me/pekerjaan/:id", meService.DeletePekerjaan)
+
+	// Ganti password sendiri
+	api.Put("/me/password", authService.ChangePassword)
+
+	// Session login milik sendiri
+	sessionService := service.NewSessionService(sessionRepo, tokenRepo, eventRepo)
+	api.Get("/me/sessions", sessionService.GetMine)
+	api.Delete("/me/sessions", sessionService.RevokeAllMine)
+	api.Delete("/me/sessions/:id", sessionService.RevokeMine)
+
+	// Riwayat keamanan akun (login, password, 2FA, aksi admin)
+	securityEventService := service.NewSecurityEventService(eventRepo)
+	api.Get("/me/security-events", securityEventService.GetMine)
+	api.Get("/security-events", middleware.RequirePermission(model.PermAuditRead), securityEventService.GetAll)
+
+	// Klaim profil alumni (menautkan akun ke data alumni)
+	claimService := service.NewAlumniClaimService(repository.NewAlumniClaimRepository(db), alumniRepo, userRepo,
+		repository.NewLoginThrottleRepository(db), mail)
+	api.Post("/me/alumni/claim", claimService.Create)
+	api.Post("/me/alumni/claim/:id/verify", claimService.Verify)
+	api.Get("/me/alumni/claims", claimService.GetMine)
+	api.Delete("/me/alumni/link", claimService.UnlinkMine)
+	claims := api.Group("/alumni-claims", middleware.RequirePermission(model.PermAlumniClaims))
+	claims.Get("/", claimService.GetAll)
+	claims.Put("/:id/approve", claimService.Approve)
+	claims.Put("/:id/reject", claimService.Reject)
+	api.Delete("/alumni/:id/link", middleware.RequirePermission(model.PermAlumniClaims), claimService.Unlink)
+
+	// Ekspor dan penghapusan data pribadi (UU PDP)
+	privacyService := service.NewPrivacyService(db, fileRepo, "./uploads")
+	api.Get("/me/export", privacyService.ExportMine)
+	api.Get("/me/erasure", privacyService.GetMyErasure)
+	api.Post("/me/erasure", privacyService.CreateErasure)
+	api.Delete("/me/erasure", privacyService.CancelErasure)
+	api.Get("/users/:id/export", middleware.RequirePermission(model.PermPrivacyManage), privacyService.ExportUser)
+	erasure := api.Group("/erasure-requests", middleware.RequirePermission(model.PermPrivacyManage))
+	erasure.Get("/", privacyService.GetErasureRequests)
+	erasure.Put("/:id/approve", privacyService.ApproveErasure)
+	erasure.Put("/:id/reject", privacyService.RejectErasure)
+
+	// Undangan alumni (admin)
+	invitationService := service.NewInvitationService(repository.NewInvitationRepository(db), alumniRepo, userRepo, mail)
+	invitations := api.Group("/invitations", middleware.RequirePermission(model.PermInvitationsManage))
+	invitations.Get("/", invitationService.GetAll)
+	invitations.Post("/", invitationService.Create)
+	invitations.Post("/bulk", invitationService.CreateBulk)
+	invitations.Delete("/:id", invitationService.Revoke)
+
 	// Restore pekerjaan dari trash
-	api.Put("/trash/pekerjaan/:id/restore", middleware.AuthRequired(), pekerjaanService.Restore)
+	api.Put("/trash/pekerjaan/:id/restore", pekerjaanService.Restore)
 
 	// Hard delete pekerjaan
-	api.Delete("/trash/pekerjaan/:id/permanent", middleware.AuthRequired(), pekerjaanService.HardDelete)
+	api.Delete("/trash/pekerjaan/:id/permanent", pekerjaanService.HardDelete)
 
 	// Trash pekerjaan
-	api.Get("/trash/pekerjaan", middleware.AuthRequired(), pekerjaanService.GetTrashed)
+	api.Get("/trash/pekerjaan", pekerjaanService.GetTrashed)
+
+	// User administration
+	api.Get("/users", middleware.RequirePermission(model.PermUsersRead), userService.GetAll)
+	api.Get("/users/:id", middleware.RequirePermission(model.PermUsersRead), userService.GetByID)
+	api.Put("/users/:id/role", middleware.RequirePermission(model.PermUsersWrite, model.PermRolesManage), userService.UpdateRole)
+	api.Put("/users/:id/password", middleware.RequirePermission(model.PermUsersWrite), userService.ResetPassword)
+	api.Put("/users/:id/restore", middleware.RequirePermission(model.PermUsersWrite), userService.Restore)
+	api.Delete("/users/:id/permanent", middleware.RequirePermission(model.PermUsersDelete), userService.HardDelete)
+
+	// Impersonation (token berumur pendek, setiap request dicatat di audit log)
+	impersonationService := service.NewImpersonationService(userRepo, roleRepo, repository.NewAuditLogRepository(db), eventRepo)
+	api.Post("/users/:id/impersonate", middleware.RequirePermission(model.PermUsersImpersonate), impersonationService.Start)
+	api.Get("/audit-logs", middleware.RequirePermission(model.PermAuditRead), impersonationService.GetAuditLogs)
+
+	api.Get("/users/:id/sessions", middleware.RequirePermission(model.PermUsersRead), sessionService.GetByUser)
+	api.Delete("/users/:id/sessions", middleware.RequirePermission(model.PermUsersWrite), sessionService.RevokeAllForUser)
 
-	// user soft delete (admin only)
-	api.Delete("/users/:id", middleware.AdminOnly(), userService.SoftDelete)
+	// admin buka kunci akun setelah terlalu banyak login gagal
+	api.Put("/users/:id/unlock", middleware.RequirePermission(model.PermUsersWrite), userService.Unlock)
 
-	// alumni soft delete (admin only)
-	api.Delete("/alumni/:id", middleware.AdminOnly(), alumniService.SoftDelete)
+	// admin override verifikasi email
+	api.Put("/users/:id/verify-email", middleware.RequirePermission(model.PermUsersWrite), userService.VerifyEmail)
+
+	// user soft delete
+	api.Delete("/users/:id", middleware.RequirePermission(model.PermUsersDelete), userService.SoftDelete)
+
+	// alumni soft delete
+	api.Delete("/alumni/:id", middleware.RequirePermission(model.PermAlumniDelete), alumniService.SoftDelete)
 
 	// pekerjaan soft delete
 	api.Delete("/pekerjaan/:id", pekerjaanService.SoftDelete)
 
 	// endpoint alumni tanpa pekerjaan
-	api.Get("/alumni/tanpa-pekerjaan", alumniService.GetWithoutPekerjaan)
+	api.Get("/alumni/tanpa-pekerjaan", middleware.RequirePermission(model.PermAlumniRead), alumniService.GetWithoutPekerjaan)
 
 	// Alumni (protected)
-	alumni := api.Group("/alumni", middleware.AuthRequired())
-	alumni.Get("/", alumniService.GetAll)                 // admin + user
-	alumni.Get("/:id", alumniService.GetByID)             // admin + user
-	alumni.Post("/", middleware.AdminOnly(), alumniService.Create)
-	alumni.Put("/:id", middleware.AdminOnly(), alumniService.Update)
-	alumni.Delete("/:id", middleware.AdminOnly(), alumniService.Delete)
+	alumni := api.Group("/alumni")
+	alumni.Get("/", middleware.RequirePermission(model.PermAlumniRead), alumniService.GetAll)
+	alumni.Get("/export", middleware.RequirePermission(model.PermAlumniExport), alumniService.Export)
+	alumni.Get("/:id", middleware.RequirePermission(model.PermAlumniRead), alumniService.GetByID)
+	alumni.Post("/", middleware.RequirePermission(model.PermAlumniWrite), alumniService.Create)
+	alumni.Post("/import", middleware.RequirePermission(model.PermAlumniImport), alumniService.Import)
+	alumni.Put("/:id", middleware.RequirePermission(model.PermAlumniWrite), alumniService.Update)
+	alumni.Patch("/:id", middleware.RequirePermission(model.PermAlumniWrite), alumniService.Patch)
+	alumni.Delete("/:id", middleware.RequirePermission(model.PermAlumniHardDelete), alumniService.Delete)
 
 	// Pekerjaan (protected)
-	pekerjaan := api.Group("/pekerjaan", middleware.AuthRequired())
-	pekerjaan.Get("/", pekerjaanService.GetAll)                    // admin + user
-	pekerjaan.Get("/:id", pekerjaanService.GetByID)                // admin + user
-	pekerjaan.Get("/alumni/:alumni_id", middleware.AdminOnly(), pekerjaanService.GetByAlumniID)
-	pekerjaan.Post("/", middleware.AdminOnly(), pekerjaanService.Create)
-	pekerjaan.Put("/:id", middleware.AdminOnly(), pekerjaanService.Update)
-	pekerjaan.Delete("/:id", middleware.AdminOnly(), pekerjaanService.Delete)
+	pekerjaan := api.Group("/pekerjaan")
+	pekerjaan.Get("/", middleware.RequirePermission(model.PermPekerjaanRead), pekerjaanService.GetAll)
+	pekerjaan.Get("/export", middleware.RequirePermission(model.PermAlumniExport), pekerjaanService.Export)
+	pekerjaan.Get("/:id", middleware.RequirePermission(model.PermPekerjaanRead), pekerjaanService.GetByID)
+	pekerjaan.Get("/alumni/:alumni_id", middleware.RequirePermission(model.PermPekerjaanReadAny), pekerjaanService.GetByAlumniID)
+	pekerjaan.Post("/", middleware.RequirePermission(model.PermPekerjaanWrite), pekerjaanService.Create)
+	pekerjaan.Put("/:id", middleware.RequirePermission(model.PermPekerjaanWrite), pekerjaanService.Update)
+	pekerjaan.Patch("/:id", middleware.RequirePermission(model.PermPekerjaanWrite), pekerjaanService.Patch)
+	pekerjaan.Delete("/:id", middleware.RequirePermission(model.PermPekerjaanHardDelete), pekerjaanService.Delete)
+
+	// Roles & permissions
+	roleService := service.NewRoleService(roleRepo)
+	api.Get("/permissions", middleware.RequirePermission(model.PermRolesManage), roleService.GetPermissions)
+	roles := api.Group("/roles", middleware.RequirePermission(model.PermRolesManage))
+	roles.Get("/", roleService.GetAll)
+	roles.Get("/:id", roleService.GetByID)
+	roles.Post("/", roleService.Create)
+	roles.Put("/:id", roleService.Update)
+	roles.Delete("/:id", roleService.Delete)
+
+	// API keys untuk akses mesin-ke-mesin
+	apiKeyService := service.NewAPIKeyService(repository.NewAPIKeyRepository(db))
+	apiKeys := api.Group("/api-keys", middleware.RequirePermission(model.PermAPIKeysManage))
+	apiKeys.Get("/", apiKeyService.GetAll)
+	apiKeys.Post("/", apiKeyService.Create)
+	apiKeys.Delete("/:id", apiKeyService.Revoke)
 
 	RegisterFileRoutes(app, fileService)
 }
@@ -68,8 +223,8 @@ func RegisterRoutes(app *fiber.App, db *mongo.Database) {
 func RegisterFileRoutes(app *fiber.App, fileService service.IFileService) {
 	api := app.Group("/api")
 
-	// File upload routes - require authentication
-	files := api.Group("/files", middleware.AuthRequired())
+	// File upload routes - require authentication (AuthRequired dipasang di main)
+	files := api.Group("/files")
 
 	// Photo routes
 	files.Post("/photo/upload", fileService.UploadPhoto)
@@ -80,4 +235,7 @@ func RegisterFileRoutes(app *fiber.App, fileService service.IFileService) {
 	files.Post("/certificate/upload", fileService.UploadCertificate)
 	files.Get("/certificate/:alumni_id", fileService.GetCertificateByAlumniID)
 	files.Delete("/certificate/:id", fileService.DeleteCertificate)
+
+	// File milik alumni sendiri
+	api.Get("/me/files", fileService.GetMine)
 }
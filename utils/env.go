@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DurationFromEnv membaca durasi (format time.ParseDuration) dari environment variable.
+func DurationFromEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Peringatan: %s tidak valid (%q). Menggunakan default: %s", key, value, fallback)
+		return fallback
+	}
+	return d
+}
+
+// AppURL menggabungkan APP_URL (default http://localhost:APP_PORT) dengan path,
+// dipakai untuk link di email.
+func AppURL(path string) string {
+	base := os.Getenv("APP_URL")
+	if base == "" {
+		port := os.Getenv("APP_PORT")
+		if port == "" {
+			port = "3000"
+		}
+		base = "http://localhost:" + port
+	}
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(path, "/")
+}
+
+// FrontendURL menggabungkan FRONTEND_URL dengan path, dipakai untuk link di email yang harus
+// dibuka di aplikasi web (halaman form), bukan di API. Jika kosong, APP_URL yang dipakai.
+func FrontendURL(path string) string {
+	base := os.Getenv("FRONTEND_URL")
+	if base == "" {
+		return AppURL(path)
+	}
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(path, "/")
+}
+
+// BoolFromEnv membaca nilai boolean ("true"/"false", "1"/"0") dari environment variable.
+func BoolFromEnv(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Peringatan: %s tidak valid (%q). Menggunakan default: %t", key, value, fallback)
+		return fallback
+	}
+	return b
+}
+
+// IntFromEnv membaca bilangan bulat positif dari environment variable.
+func IntFromEnv(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Printf("Peringatan: %s tidak valid (%q). Menggunakan default: %d", key, value, fallback)
+		return fallback
+	}
+	return n
+}
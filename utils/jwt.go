@@ -1,40 +1,220 @@
 package utils
 
 import (
-	"gofiber-mongo/app/model"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"gofiber-mongo/app/model"
+	"math/big"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-var jwtSecret = []byte("supersecretkey-min32chars")
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 7 * 24 * time.Hour
+
+	defaultImpersonationTokenTTL = 10 * time.Minute
+
+	// invitationAudience membedakan token undangan dari access token yang ditandatangani kunci yang sama
+	invitationAudience = "alumni-invitation"
+)
+
+// AccessTokenTTL membaca JWT_ACCESS_TTL (format time.ParseDuration, mis. "15m").
+func AccessTokenTTL() time.Duration {
+	return DurationFromEnv("JWT_ACCESS_TTL", defaultAccessTokenTTL)
+}
+
+// RefreshTokenTTL membaca JWT_REFRESH_TTL (format time.ParseDuration, mis. "168h").
+func RefreshTokenTTL() time.Duration {
+	return DurationFromEnv("JWT_REFRESH_TTL", defaultRefreshTokenTTL)
+}
+
+// GenerateToken membuat access token untuk user. sessionID dibawa sebagai claim "sid"
+// supaya token bisa dicabut bersama session-nya.
+func GenerateToken(user *model.User, sessionID string) (string, error) {
+	km, err := Keys()
+	if err != nil {
+		return "", err
+	}
 
-func GenerateToken(user *model.User) (string, error) {
+	now := time.Now()
 	claims := model.JWTClaims{
-		UserID:   user.ID,
-		Username: user.Username,
-		Role:     user.Role,
+		UserID:    user.ID,
+		Username:  user.Username,
+		Role:      user.Role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			// Token berlaku 24 jam
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL())),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	return km.Sign(claims)
+}
+
+// ImpersonationTokenTTL membaca IMPERSONATION_TTL (format time.ParseDuration, mis. "10m").
+func ImpersonationTokenTTL() time.Duration {
+	return DurationFromEnv("IMPERSONATION_TTL", defaultImpersonationTokenTTL)
+}
+
+// ImpersonationAllowDestructive membaca IMPERSONATION_ALLOW_DESTRUCTIVE (default false).
+// Jika false, selama impersonation hanya GET, HEAD, OPTIONS dan request yang cocok dengan
+// ImpersonationAllowedWrites yang diizinkan.
+func ImpersonationAllowDestructive() bool {
+	return BoolFromEnv("IMPERSONATION_ALLOW_DESTRUCTIVE", false)
+}
+
+// ImpersonationWrite adalah request yang mengubah data tetapi tetap diizinkan selama impersonation.
+type ImpersonationWrite struct {
+	Method string
+	Path   string
+}
+
+// ImpersonationAllowedWrites membaca IMPERSONATION_ALLOWED_WRITES, daftar "METHOD /path" yang
+// dipisah koma (mis. "PUT /api/me/alumni,POST /api/me/pekerjaan"). Path berlaku juga untuk
+// sub-path-nya. Entry yang formatnya salah diabaikan.
+func ImpersonationAllowedWrites() []ImpersonationWrite {
+	var writes []ImpersonationWrite
+	for _, entry := range strings.Split(os.Getenv("IMPERSONATION_ALLOWED_WRITES"), ",") {
+		method, path, ok := strings.Cut(strings.TrimSpace(entry), " ")
+		path = strings.TrimRight(strings.ToLower(strings.TrimSpace(path)), "/")
+		if !ok || !strings.HasPrefix(path, "/") {
+			continue
+		}
+		writes = append(writes, ImpersonationWrite{Method: strings.ToUpper(method), Path: path})
+	}
+	return writes
+}
+
+// GenerateImpersonationToken membuat access token berumur pendek atas nama target, dengan
+// admin yang bertindak di claim "act". Token tidak punya session dan refresh token sendiri.
+func GenerateImpersonationToken(target, actor *model.User, actorSessionID string) (string, error) {
+	km, err := Keys()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := model.JWTClaims{
+		UserID:   target.ID,
+		Username: target.Username,
+		Role:     target.Role,
+		Act: &model.ActorClaim{
+			UserID:    actor.ID,
+			Username:  actor.Username,
+			SessionID: actorSessionID,
+		},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ImpersonationTokenTTL())),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	return km.Sign(claims)
 }
 
 func ValidateToken(tokenStr string) (*model.JWTClaims, error) {
+	km, err := Keys()
+	if err != nil {
+		return nil, err
+	}
+
 	claims := &model.JWTClaims{}
 
-	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenStr, claims, km.Keyfunc,
+		jwt.WithValidMethods([]string{"HS256", "RS256", "EdDSA"}))
 
 	if err != nil || !token.Valid {
 		return nil, errors.New("token tidak valid")
 	}
+	// Access token tidak punya audience; token lain (mis. undangan) tidak boleh dipakai login
+	if len(claims.Audience) > 0 {
+		return nil, errors.New("token tidak valid")
+	}
 
 	return claims, nil
 }
+
+// GenerateInvitationToken membuat token undangan yang ditandatangani kunci JWT dan berlaku sampai
+// expiresAt. ID undangan dibawa di claim jti.
+func GenerateInvitationToken(invitationID string, expiresAt time.Time) (string, error) {
+	km, err := Keys()
+	if err != nil {
+		return "", err
+	}
+	return km.Sign(jwt.RegisteredClaims{
+		ID:        invitationID,
+		Audience:  jwt.ClaimStrings{invitationAudience},
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+	})
+}
+
+// ValidateInvitationToken memverifikasi tanda tangan, audience dan masa berlaku token undangan,
+// lalu mengembalikan ID undangan. Status undangan (dibatalkan / sudah dipakai) dicek di database.
+func ValidateInvitationToken(tokenStr string) (string, error) {
+	km, err := Keys()
+	if err != nil {
+		return "", err
+	}
+
+	claims := &jwt.RegisteredClaims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, km.Keyfunc,
+		jwt.WithValidMethods([]string{"HS256", "RS256", "EdDSA"}),
+		jwt.WithAudience(invitationAudience),
+		jwt.WithExpirationRequired())
+	if err != nil || !token.Valid || claims.ID == "" {
+		return "", errors.New("token undangan tidak valid")
+	}
+	return claims.ID, nil
+}
+
+// GenerateOpaqueToken membuat token acak (base64url) beserta hash SHA-256 untuk disimpan di database.
+func GenerateOpaqueToken() (token string, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashToken(token), nil
+}
+
+// HashToken menghasilkan hash SHA-256 (hex) dari token opaque.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateNumericCode membuat kode angka acak sepanjang digits (mis. kode verifikasi 6 digit via email).
+func GenerateNumericCode(digits int) (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", digits, n), nil
+}
+
+// GenerateAPIKey membuat API key berformat <label>_<prefix>_<secret>. Prefix (8 hex) dipakai
+// untuk lookup dan ditampilkan di daftar key; hanya hash dari key lengkap yang disimpan.
+func GenerateAPIKey(label string) (key, prefix, hash string, err error) {
+	prefixBytes := make([]byte, 4)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", "", "", err
+	}
+	secret, _, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", "", "", err
+	}
+	prefix = hex.EncodeToString(prefixBytes)
+	key = label + "_" + prefix + "_" + secret
+	return key, prefix, HashToken(key), nil
+}
@@ -0,0 +1,336 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SigningKey adalah satu kunci JWT yang dikenal oleh KeyManager.
+// Kunci tanpa private key / secret hanya dipakai untuk verifikasi.
+type SigningKey struct {
+	KID       string
+	Alg       string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// CanSign menandakan apakah kunci ini punya materi untuk menandatangani token.
+func (k *SigningKey) CanSign() bool {
+	return k.signKey != nil
+}
+
+// KeyConfig adalah satu entri kunci di file JWT_KEYS_FILE.
+type KeyConfig struct {
+	KID            string `json:"kid"`
+	Alg            string `json:"alg"`
+	Secret         string `json:"secret,omitempty"`
+	SecretEnv      string `json:"secret_env,omitempty"`
+	SecretFile     string `json:"secret_file,omitempty"`
+	PrivateKeyFile string `json:"private_key_file,omitempty"`
+	PublicKeyFile  string `json:"public_key_file,omitempty"`
+	Disabled       bool   `json:"disabled,omitempty"`
+}
+
+// KeysConfig adalah isi file JWT_KEYS_FILE.
+//
+//	{
+//	  "primary": "2025-10",
+//	  "keys": [
+//	    {"kid": "2025-10", "alg": "EdDSA", "private_key_file": "keys/ed25519.pem"},
+//	    {"kid": "2025-01", "alg": "RS256", "public_key_file": "keys/rsa_old.pub.pem"},
+//	    {"kid": "legacy", "alg": "HS256", "secret_env": "JWT_SECRET"}
+//	  ]
+//	}
+type KeysConfig struct {
+	Primary string      `json:"primary"`
+	Keys    []KeyConfig `json:"keys"`
+}
+
+// KeyManager menyimpan kunci aktif. Token ditandatangani dengan kunci primary,
+// dan diverifikasi dengan kunci mana pun yang kid-nya cocok dan tidak disabled.
+type KeyManager struct {
+	mu      sync.RWMutex
+	keys    map[string]*SigningKey
+	primary string
+}
+
+var (
+	keyManager     *KeyManager
+	keyManagerOnce sync.Once
+	keyManagerErr  error
+)
+
+// InitKeys memuat kunci JWT dari konfigurasi. Dipanggil sekali saat startup
+// supaya kesalahan konfigurasi langsung terlihat.
+func InitKeys() error {
+	keyManagerOnce.Do(func() {
+		keyManager, keyManagerErr = LoadKeyManager()
+	})
+	return keyManagerErr
+}
+
+// Keys mengembalikan KeyManager global, memuatnya jika belum pernah dimuat.
+func Keys() (*KeyManager, error) {
+	if err := InitKeys(); err != nil {
+		return nil, err
+	}
+	return keyManager, nil
+}
+
+// placeholderJWTSecret adalah contoh nilai JWT_SECRET yang pernah ada di .env repo. Nilai ini
+// publik, jadi ditolak supaya token tidak ditandatangani dengan kunci yang diketahui semua orang.
+const placeholderJWTSecret = "change-me-to-a-random-secret-of-at-least-32-chars"
+
+// LoadKeyManager membaca JWT_KEYS_FILE. Jika tidak disetel, dipakai satu kunci
+// HS256 dari JWT_SECRET (kid dari JWT_KEY_ID, default "default").
+func LoadKeyManager() (*KeyManager, error) {
+	var cfg KeysConfig
+
+	if path := os.Getenv("JWT_KEYS_FILE"); path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("gagal membaca JWT_KEYS_FILE: %w", err)
+		}
+		if err := json.Unmarshal(raw, &cfg); err != nil {
+			return nil, fmt.Errorf("JWT_KEYS_FILE tidak valid: %w", err)
+		}
+	} else {
+		kid := os.Getenv("JWT_KEY_ID")
+		if kid == "" {
+			kid = "default"
+		}
+		secret := os.Getenv("JWT_SECRET")
+		if secret == placeholderJWTSecret {
+			return nil, errors.New("JWT_SECRET masih berisi contoh dari .env, ganti dengan secret acak")
+		}
+		if secret == "" {
+			buf := make([]byte, 32)
+			if _, err := rand.Read(buf); err != nil {
+				return nil, err
+			}
+			secret = base64.RawURLEncoding.EncodeToString(buf)
+			log.Println("Peringatan: JWT_SECRET tidak disetel. Menggunakan secret acak; token tidak berlaku setelah restart")
+		}
+		cfg = KeysConfig{
+			Primary: kid,
+			Keys:    []KeyConfig{{KID: kid, Alg: "HS256", Secret: secret}},
+		}
+	}
+
+	if kid := os.Getenv("JWT_SIGNING_KID"); kid != "" {
+		cfg.Primary = kid
+	}
+
+	return NewKeyManager(cfg)
+}
+
+// NewKeyManager membangun KeyManager dari konfigurasi yang sudah di-parse.
+func NewKeyManager(cfg KeysConfig) (*KeyManager, error) {
+	km := &KeyManager{keys: map[string]*SigningKey{}}
+
+	for _, kc := range cfg.Keys {
+		if kc.Disabled {
+			continue
+		}
+		if kc.KID == "" {
+			return nil, errors.New("setiap kunci JWT harus punya kid")
+		}
+		if _, exists := km.keys[kc.KID]; exists {
+			return nil, fmt.Errorf("kid %q duplikat", kc.KID)
+		}
+		key, err := loadSigningKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("kunci %q: %w", kc.KID, err)
+		}
+		km.keys[kc.KID] = key
+	}
+
+	primary, ok := km.keys[cfg.Primary]
+	if !ok {
+		return nil, fmt.Errorf("kunci primary %q tidak ditemukan atau disabled", cfg.Primary)
+	}
+	if !primary.CanSign() {
+		return nil, fmt.Errorf("kunci primary %q tidak punya private key/secret", cfg.Primary)
+	}
+	km.primary = cfg.Primary
+
+	return km, nil
+}
+
+func loadSigningKey(kc KeyConfig) (*SigningKey, error) {
+	key := &SigningKey{KID: kc.KID, Alg: kc.Alg}
+
+	switch kc.Alg {
+	case "HS256":
+		secret := kc.Secret
+		if kc.SecretEnv != "" {
+			secret = os.Getenv(kc.SecretEnv)
+		}
+		if kc.SecretFile != "" {
+			raw, err := os.ReadFile(kc.SecretFile)
+			if err != nil {
+				return nil, err
+			}
+			secret = string(raw)
+		}
+		if len(secret) < 32 {
+			return nil, errors.New("secret HS256 minimal 32 karakter")
+		}
+		key.method = jwt.SigningMethodHS256
+		key.signKey = []byte(secret)
+		key.verifyKey = []byte(secret)
+
+	case "RS256":
+		key.method = jwt.SigningMethodRS256
+		if kc.PrivateKeyFile != "" {
+			raw, err := os.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			priv, err := jwt.ParseRSAPrivateKeyFromPEM(raw)
+			if err != nil {
+				return nil, err
+			}
+			key.signKey = priv
+			key.verifyKey = &priv.PublicKey
+		} else if kc.PublicKeyFile != "" {
+			raw, err := os.ReadFile(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			pub, err := jwt.ParseRSAPublicKeyFromPEM(raw)
+			if err != nil {
+				return nil, err
+			}
+			key.verifyKey = pub
+		} else {
+			return nil, errors.New("RS256 butuh private_key_file atau public_key_file")
+		}
+
+	case "EdDSA":
+		key.method = jwt.SigningMethodEdDSA
+		if kc.PrivateKeyFile != "" {
+			raw, err := os.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			priv, err := jwt.ParseEdPrivateKeyFromPEM(raw)
+			if err != nil {
+				return nil, err
+			}
+			key.signKey = priv
+			key.verifyKey = priv.(crypto.Signer).Public()
+		} else if kc.PublicKeyFile != "" {
+			raw, err := os.ReadFile(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			pub, err := jwt.ParseEdPublicKeyFromPEM(raw)
+			if err != nil {
+				return nil, err
+			}
+			key.verifyKey = pub
+		} else {
+			return nil, errors.New("EdDSA butuh private_key_file atau public_key_file")
+		}
+
+	default:
+		return nil, fmt.Errorf("algoritma %q tidak didukung (HS256, RS256, EdDSA)", kc.Alg)
+	}
+
+	return key, nil
+}
+
+// Sign menandatangani claims dengan kunci primary dan menambahkan header kid.
+func (km *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	km.mu.RLock()
+	key := km.keys[km.primary]
+	km.mu.RUnlock()
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.KID
+	return token.SignedString(key.signKey)
+}
+
+// Keyfunc dipakai jwt.Parse untuk memilih kunci verifikasi berdasarkan kid.
+// Algoritma token harus sama dengan algoritma kunci untuk mencegah alg confusion.
+func (km *KeyManager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		// Token lama tanpa kid hanya diterima oleh kunci primary
+		kid = km.primary
+	}
+
+	key, ok := km.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("kid %q tidak dikenal", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("algoritma token tidak cocok dengan kunci")
+	}
+	return key.verifyKey, nil
+}
+
+// JWK adalah representasi public key sesuai RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet adalah respons endpoint JWKS.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS mengembalikan public key semua kunci asimetris. Kunci HS256 tidak pernah dipublikasikan.
+func (km *KeyManager) JWKS() JWKSet {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range km.keys {
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA",
+				Use: "sig",
+				Alg: key.Alg,
+				Kid: key.KID,
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP",
+				Use: "sig",
+				Alg: key.Alg,
+				Kid: key.KID,
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	return set
+}
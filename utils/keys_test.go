@@ -0,0 +1,314 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testHSSecret = "secret-hs256-minimal-32-karakter!!"
+
+type testKeyFiles struct {
+	rsaPrivate, rsaPublic string
+	edPrivate, edPublic   string
+	rsaKey                *rsa.PrivateKey
+	edKey                 ed25519.PrivateKey
+}
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newTestKeyFiles(t *testing.T) testKeyFiles {
+	t.Helper()
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPub, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPriv, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPub, err := x509.MarshalPKIXPublicKey(edKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return testKeyFiles{
+		rsaPrivate: writePEM(t, dir, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)),
+		rsaPublic:  writePEM(t, dir, "rsa.pub.pem", "PUBLIC KEY", rsaPub),
+		edPrivate:  writePEM(t, dir, "ed25519.pem", "PRIVATE KEY", edPriv),
+		edPublic:   writePEM(t, dir, "ed25519.pub.pem", "PUBLIC KEY", edPub),
+		rsaKey:     rsaKey,
+		edKey:      edKey,
+	}
+}
+
+func testClaims() jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{Subject: "user", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))}
+}
+
+func verify(km *KeyManager, token string) error {
+	_, err := jwt.ParseWithClaims(token, &jwt.RegisteredClaims{}, km.Keyfunc,
+		jwt.WithValidMethods([]string{"HS256", "RS256", "EdDSA"}))
+	return err
+}
+
+func TestNewKeyManagerConfig(t *testing.T) {
+	files := newTestKeyFiles(t)
+
+	tests := []struct {
+		name    string
+		cfg     KeysConfig
+		wantErr string
+	}{
+		{
+			name: "hs256",
+			cfg:  KeysConfig{Primary: "a", Keys: []KeyConfig{{KID: "a", Alg: "HS256", Secret: testHSSecret}}},
+		},
+		{
+			name: "eddsa primary dengan rs256 lama hanya public key",
+			cfg: KeysConfig{Primary: "new", Keys: []KeyConfig{
+				{KID: "new", Alg: "EdDSA", PrivateKeyFile: files.edPrivate},
+				{KID: "old", Alg: "RS256", PublicKeyFile: files.rsaPublic},
+			}},
+		},
+		{
+			name:    "kid kosong",
+			cfg:     KeysConfig{Primary: "a", Keys: []KeyConfig{{Alg: "HS256", Secret: testHSSecret}}},
+			wantErr: "harus punya kid",
+		},
+		{
+			name: "kid duplikat",
+			cfg: KeysConfig{Primary: "a", Keys: []KeyConfig{
+				{KID: "a", Alg: "HS256", Secret: testHSSecret},
+				{KID: "a", Alg: "RS256", PublicKeyFile: files.rsaPublic},
+			}},
+			wantErr: "duplikat",
+		},
+		{
+			name:    "secret terlalu pendek",
+			cfg:     KeysConfig{Primary: "a", Keys: []KeyConfig{{KID: "a", Alg: "HS256", Secret: "pendek"}}},
+			wantErr: "minimal 32",
+		},
+		{
+			name:    "algoritma tidak didukung",
+			cfg:     KeysConfig{Primary: "a", Keys: []KeyConfig{{KID: "a", Alg: "none"}}},
+			wantErr: "tidak didukung",
+		},
+		{
+			name:    "primary tidak ada",
+			cfg:     KeysConfig{Primary: "b", Keys: []KeyConfig{{KID: "a", Alg: "HS256", Secret: testHSSecret}}},
+			wantErr: "tidak ditemukan",
+		},
+		{
+			name:    "primary disabled",
+			cfg:     KeysConfig{Primary: "a", Keys: []KeyConfig{{KID: "a", Alg: "HS256", Secret: testHSSecret, Disabled: true}}},
+			wantErr: "tidak ditemukan",
+		},
+		{
+			name:    "primary hanya public key",
+			cfg:     KeysConfig{Primary: "a", Keys: []KeyConfig{{KID: "a", Alg: "EdDSA", PublicKeyFile: files.edPublic}}},
+			wantErr: "tidak punya private key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeyManager(tt.cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	files := newTestKeyFiles(t)
+
+	// Sebelum rotasi: RS256 sebagai primary
+	before, err := NewKeyManager(KeysConfig{Primary: "2025-01", Keys: []KeyConfig{
+		{KID: "2025-01", Alg: "RS256", PrivateKeyFile: files.rsaPrivate},
+		{KID: "legacy", Alg: "HS256", Secret: testHSSecret},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken, err := before.Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sesudah rotasi: EdDSA primary, RS256 tinggal public key, HS256 dimatikan
+	after, err := NewKeyManager(KeysConfig{Primary: "2025-10", Keys: []KeyConfig{
+		{KID: "2025-10", Alg: "EdDSA", PrivateKeyFile: files.edPrivate},
+		{KID: "2025-01", Alg: "RS256", PublicKeyFile: files.rsaPublic},
+		{KID: "legacy", Alg: "HS256", Secret: testHSSecret, Disabled: true},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	newToken, err := after.Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	legacy := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+	legacy.Header["kid"] = "legacy"
+	legacyToken, _ := legacy.SignedString([]byte(testHSSecret))
+
+	// Alg confusion: token HS256 yang ditandatangani dengan public key RSA sebagai secret
+	pubDER, _ := x509.MarshalPKIXPublicKey(&files.rsaKey.PublicKey)
+	confused := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+	confused.Header["kid"] = "2025-01"
+	confusedToken, _ := confused.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}))
+
+	unknown := jwt.NewWithClaims(jwt.SigningMethodEdDSA, testClaims())
+	unknown.Header["kid"] = "tidak-ada"
+	unknownToken, _ := unknown.SignedString(files.edKey)
+
+	noKid := jwt.NewWithClaims(jwt.SigningMethodEdDSA, testClaims())
+	noKidToken, _ := noKid.SignedString(files.edKey)
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"token baru dengan kunci primary", newToken, true},
+		{"token lama tetap valid lewat public key", oldToken, true},
+		{"token tanpa kid memakai kunci primary", noKidToken, true},
+		{"kunci disabled ditolak", legacyToken, false},
+		{"kid tidak dikenal ditolak", unknownToken, false},
+		{"algoritma tidak cocok ditolak", confusedToken, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verify(after, tt.token)
+			if tt.valid && err != nil {
+				t.Fatalf("token ditolak: %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Fatal("token diterima, seharusnya ditolak")
+			}
+		})
+	}
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &jwt.RegisteredClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Header["kid"] != "2025-10" || parsed.Method.Alg() != "EdDSA" {
+		t.Fatalf("header token baru = %v, want kid 2025-10 / EdDSA", parsed.Header)
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	files := newTestKeyFiles(t)
+	km, err := NewKeyManager(KeysConfig{Primary: "ed", Keys: []KeyConfig{
+		{KID: "ed", Alg: "EdDSA", PrivateKeyFile: files.edPrivate},
+		{KID: "rsa", Alg: "RS256", PublicKeyFile: files.rsaPublic},
+		{KID: "hs", Alg: "HS256", Secret: testHSSecret},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := map[string]JWK{}
+	for _, k := range km.JWKS().Keys {
+		keys[k.Kid] = k
+	}
+	if len(keys) != 2 {
+		t.Fatalf("JWKS berisi %d kunci, want 2 (HS256 tidak boleh dipublikasikan)", len(keys))
+	}
+	if _, ok := keys["hs"]; ok {
+		t.Fatal("kunci HS256 dipublikasikan di JWKS")
+	}
+
+	ed := keys["ed"]
+	x, _ := base64.RawURLEncoding.DecodeString(ed.X)
+	if ed.Kty != "OKP" || ed.Crv != "Ed25519" || ed.Use != "sig" || !files.edKey.Public().(ed25519.PublicKey).Equal(ed25519.PublicKey(x)) {
+		t.Fatalf("JWK Ed25519 tidak sesuai: %+v", ed)
+	}
+
+	rsaJWK := keys["rsa"]
+	n, _ := base64.RawURLEncoding.DecodeString(rsaJWK.N)
+	e, _ := base64.RawURLEncoding.DecodeString(rsaJWK.E)
+	pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	if rsaJWK.Kty != "RSA" || rsaJWK.Alg != "RS256" || !files.rsaKey.PublicKey.Equal(pub) {
+		t.Fatalf("JWK RSA tidak sesuai: %+v", rsaJWK)
+	}
+}
+
+func TestInvitationToken(t *testing.T) {
+	t.Setenv("JWT_KEYS_FILE", "")
+	t.Setenv("JWT_SECRET", testHSSecret)
+	km, err := Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := GenerateInvitationToken("inv-1", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := ValidateInvitationToken(token); err != nil || id != "inv-1" {
+		t.Fatalf("ValidateInvitationToken = (%q, %v), want inv-1", id, err)
+	}
+	if _, err := ValidateToken(token); err == nil {
+		t.Fatal("token undangan diterima sebagai access token")
+	}
+
+	expired, _ := GenerateInvitationToken("inv-2", time.Now().Add(-time.Minute))
+	access, _ := km.Sign(testClaims())
+	tampered := token[:len(token)-2] + "xx"
+	for name, tok := range map[string]string{"expired": expired, "access token": access, "tanda tangan diubah": tampered} {
+		if _, err := ValidateInvitationToken(tok); err == nil {
+			t.Errorf("%s diterima sebagai token undangan", name)
+		}
+	}
+}
+
+func TestLoadKeyManagerRejectsPlaceholderSecret(t *testing.T) {
+	t.Setenv("JWT_KEYS_FILE", "")
+	t.Setenv("JWT_SECRET", placeholderJWTSecret)
+	if _, err := LoadKeyManager(); err == nil {
+		t.Fatal("JWT_SECRET contoh dari .env diterima")
+	}
+
+	t.Setenv("JWT_SECRET", testHSSecret)
+	if _, err := LoadKeyManager(); err != nil {
+		t.Fatal(err)
+	}
+}
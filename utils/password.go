@@ -1,13 +1,175 @@
 package utils
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	PasswordAlgorithmArgon2id = "argon2id"
+	PasswordAlgorithmBcrypt   = "bcrypt"
+)
+
+// Argon2Params adalah parameter argon2id. Memory dalam KiB.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// PasswordHasher membuat hash password dengan algoritma dan parameter saat ini, dan tetap bisa
+// memverifikasi hash lama (bcrypt atau argon2id dengan parameter berbeda).
+//
+// Format argon2id mengikuti PHC string format:
+//
+//	$argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+type PasswordHasher struct {
+	Algorithm  string
+	Argon2     Argon2Params
+	BcryptCost int
+}
+
+var (
+	defaultHasher     *PasswordHasher
+	defaultHasherOnce sync.Once
+
+	dummyHash     string
+	dummyHashOnce sync.Once
+)
+
+// DefaultPasswordHasher membaca PASSWORD_HASH_ALGORITHM (argon2id | bcrypt, default argon2id),
+// ARGON2_MEMORY (KiB), ARGON2_ITERATIONS, ARGON2_PARALLELISM, ARGON2_SALT_LENGTH,
+// ARGON2_KEY_LENGTH dan BCRYPT_COST sekali saat pertama dipakai.
+func DefaultPasswordHasher() *PasswordHasher {
+	defaultHasherOnce.Do(func() {
+		algorithm := strings.ToLower(os.Getenv("PASSWORD_HASH_ALGORITHM"))
+		if algorithm != PasswordAlgorithmBcrypt {
+			algorithm = PasswordAlgorithmArgon2id
+		}
+		defaultHasher = &PasswordHasher{
+			Algorithm: algorithm,
+			Argon2: Argon2Params{
+				Memory:      uint32(IntFromEnv("ARGON2_MEMORY", 64*1024)),
+				Iterations:  uint32(IntFromEnv("ARGON2_ITERATIONS", 3)),
+				Parallelism: uint8(IntFromEnv("ARGON2_PARALLELISM", 2)),
+				SaltLength:  uint32(IntFromEnv("ARGON2_SALT_LENGTH", 16)),
+				KeyLength:   uint32(IntFromEnv("ARGON2_KEY_LENGTH", 32)),
+			},
+			BcryptCost: IntFromEnv("BCRYPT_COST", bcrypt.DefaultCost),
+		}
+	})
+	return defaultHasher
+}
+
+// Hash membuat hash password dengan algoritma dan parameter hasher.
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	if h.Algorithm == PasswordAlgorithmBcrypt {
+		bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.BcryptCost)
+		return string(bytes), err
+	}
+
+	p := h.Argon2
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify mencocokkan password dengan hash. needsRehash bernilai true jika password cocok tetapi
+// hash dibuat dengan algoritma atau parameter yang berbeda dari konfigurasi saat ini.
+func (h *PasswordHasher) Verify(password, hash string) (ok bool, needsRehash bool) {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		params, salt, key, err := decodeArgon2Hash(hash)
+		if err != nil {
+			return false, false
+		}
+		actual := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(actual, key) != 1 {
+			return false, false
+		}
+		params.SaltLength = uint32(len(salt))
+		return true, h.Algorithm != PasswordAlgorithmArgon2id || params != h.Argon2
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return false, false
+	}
+	if h.Algorithm != PasswordAlgorithmBcrypt {
+		return true, true
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	return true, err != nil || cost != h.BcryptCost
+}
+
+func decodeArgon2Hash(hash string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, errors.New("format hash argon2id tidak valid")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, err
+	}
+	if version != argon2.Version {
+		return params, nil, nil, errors.New("versi argon2 tidak didukung")
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, err
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, err
+	}
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
 
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes), err
+	return DefaultPasswordHasher().Hash(password)
 }
 
 func CheckPassword(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+	ok, _ := DefaultPasswordHasher().Verify(password, hash)
+	return ok
+}
+
+// VerifyPassword seperti CheckPassword, tetapi juga melaporkan apakah hash perlu dibuat ulang
+// dengan parameter terbaru (dipakai saat login untuk upgrade hash secara transparan).
+func VerifyPassword(password, hash string) (ok bool, needsRehash bool) {
+	return DefaultPasswordHasher().Verify(password, hash)
+}
+
+// CheckPasswordDummy menjalankan perbandingan hash palsu dengan biaya yang sama seperti
+// CheckPassword. Dipakai saat user tidak ditemukan agar waktu respons login seragam.
+func CheckPasswordDummy(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = HashPassword("dummy-password-for-timing")
+	})
+	_, _ = VerifyPassword(password, dummyHash)
 }
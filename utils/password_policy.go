@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"gofiber-mongo/app/model"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// PasswordPolicy adalah aturan password untuk register, reset dan ganti password.
+//
+// BreachedDir berisi daftar hash SHA-1 password yang pernah bocor dalam format range
+// k-anonymity (seperti Pwned Passwords): satu file per 5 karakter pertama hash, mis.
+// "21BD1" atau "21BD1.txt", berisi baris "SISA_35_KARAKTER:JUMLAH". Hanya file dengan
+// prefix yang sama yang dibaca saat pengecekan.
+type PasswordPolicy struct {
+	MinLength        int
+	MaxLength        int
+	RequireUpper     bool
+	RequireLower     bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	BreachedDir      string
+	BreachedMinCount int
+}
+
+var (
+	defaultPolicy     *PasswordPolicy
+	defaultPolicyOnce sync.Once
+)
+
+// DefaultPasswordPolicy membaca PASSWORD_MIN_LENGTH, PASSWORD_MAX_LENGTH, PASSWORD_REQUIRE_UPPER,
+// PASSWORD_REQUIRE_LOWER, PASSWORD_REQUIRE_DIGIT, PASSWORD_REQUIRE_SYMBOL, PASSWORD_BREACHED_DIR
+// dan PASSWORD_BREACHED_MIN_COUNT sekali saat pertama dipakai.
+func DefaultPasswordPolicy() *PasswordPolicy {
+	defaultPolicyOnce.Do(func() {
+		defaultPolicy = &PasswordPolicy{
+			MinLength:        IntFromEnv("PASSWORD_MIN_LENGTH", 8),
+			MaxLength:        IntFromEnv("PASSWORD_MAX_LENGTH", 128),
+			RequireUpper:     BoolFromEnv("PASSWORD_REQUIRE_UPPER", true),
+			RequireLower:     BoolFromEnv("PASSWORD_REQUIRE_LOWER", true),
+			RequireDigit:     BoolFromEnv("PASSWORD_REQUIRE_DIGIT", true),
+			RequireSymbol:    BoolFromEnv("PASSWORD_REQUIRE_SYMBOL", false),
+			BreachedDir:      os.Getenv("PASSWORD_BREACHED_DIR"),
+			BreachedMinCount: IntFromEnv("PASSWORD_BREACHED_MIN_COUNT", 1),
+		}
+	})
+	return defaultPolicy
+}
+
+// Validate mengembalikan semua pelanggaran kebijakan untuk password pada field tertentu.
+// personal berisi nilai yang tidak boleh dipakai sebagai password (username, NIM, email).
+func (p *PasswordPolicy) Validate(field, password string, personal ...string) []model.FieldError {
+	errs := []model.FieldError{}
+	add := func(code, message string) {
+		errs = append(errs, model.FieldError{Field: field, Code: code, Message: message})
+	}
+
+	length := len([]rune(password))
+	if length < p.MinLength {
+		add("too_short", fmt.Sprintf("Password minimal %d karakter", p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		add("too_long", fmt.Sprintf("Password maksimal %d karakter", p.MaxLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireUpper && !hasUpper {
+		add("missing_uppercase", "Password harus mengandung huruf besar")
+	}
+	if p.RequireLower && !hasLower {
+		add("missing_lowercase", "Password harus mengandung huruf kecil")
+	}
+	if p.RequireDigit && !hasDigit {
+		add("missing_digit", "Password harus mengandung angka")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		add("missing_symbol", "Password harus mengandung simbol")
+	}
+
+	lower := strings.ToLower(password)
+	for _, value := range personal {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if len(value) >= 3 && strings.Contains(lower, value) {
+			add("contains_personal_info", "Password tidak boleh mengandung username, NIM atau email")
+			break
+		}
+	}
+
+	if p.isBreached(password) {
+		add("breached", "Password ini pernah bocor di kebocoran data publik. Gunakan password lain")
+	}
+	return errs
+}
+
+func (p *PasswordPolicy) isBreached(password string) bool {
+	if p.BreachedDir == "" {
+		return false
+	}
+
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	file, err := os.Open(filepath.Join(p.BreachedDir, prefix))
+	if os.IsNotExist(err) {
+		file, err = os.Open(filepath.Join(p.BreachedDir, prefix+".txt"))
+	}
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Gagal membaca daftar password bocor: %v", err)
+		}
+		return false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		candidate, countStr, _ := strings.Cut(line, ":")
+		if !strings.EqualFold(candidate, suffix) {
+			continue
+		}
+		count, err := strconv.Atoi(strings.TrimSpace(countStr))
+		if err != nil {
+			count = 1
+		}
+		return count >= p.BreachedMinCount
+	}
+	return false
+}
+
+// ValidatePassword memvalidasi password dengan kebijakan default.
+func ValidatePassword(field, password string, personal ...string) []model.FieldError {
+	return DefaultPasswordPolicy().Validate(field, password, personal...)
+}
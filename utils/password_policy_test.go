@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func errorCodes(t *testing.T, p *PasswordPolicy, password string, personal ...string) []string {
+	t.Helper()
+	codes := []string{}
+	for _, e := range p.Validate("password", password, personal...) {
+		if e.Field != "password" {
+			t.Fatalf("field = %q, want password", e.Field)
+		}
+		codes = append(codes, e.Code)
+	}
+	return codes
+}
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	policy := &PasswordPolicy{MinLength: 8, MaxLength: 16, RequireUpper: true, RequireLower: true, RequireDigit: true}
+	strict := &PasswordPolicy{MinLength: 8, RequireSymbol: true}
+
+	tests := []struct {
+		name     string
+		policy   *PasswordPolicy
+		password string
+		personal []string
+		want     []string
+	}{
+		{"memenuhi semua aturan", policy, "Rahasia123", nil, []string{}},
+		{"terlalu pendek", policy, "Ra1", nil, []string{"too_short"}},
+		{"terlalu panjang", policy, "Rahasia123Rahasia", nil, []string{"too_long"}},
+		{"panjang dihitung per karakter", policy, "Ráhásíá1", nil, []string{}},
+		{"tanpa huruf besar", policy, "rahasia123", nil, []string{"missing_uppercase"}},
+		{"tanpa huruf kecil", policy, "RAHASIA123", nil, []string{"missing_lowercase"}},
+		{"tanpa angka", policy, "RahasiaSekali", nil, []string{"missing_digit"}},
+		{"beberapa pelanggaran sekaligus", policy, "abc", nil, []string{"too_short", "missing_uppercase", "missing_digit"}},
+		{"tanpa simbol", strict, "rahasiasekali", nil, []string{"missing_symbol"}},
+		{"spasi dihitung simbol", strict, "rahasia sekali", nil, []string{}},
+		{"mengandung username", policy, "Budi2024xyz", []string{"budi"}, []string{"contains_personal_info"}},
+		{"mengandung NIM", policy, "Ab12345678", []string{"budi", "12345678"}, []string{"contains_personal_info"}},
+		{"email tidak terkandung", policy, "Rahasia123", []string{"budi@example.com"}, []string{}},
+		{"nilai pribadi pendek diabaikan", policy, "Rahasia123", []string{"ra", " "}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := errorCodes(t, tt.policy, tt.password, tt.personal...)
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("kode error = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyBreached(t *testing.T) {
+	dir := t.TempDir()
+	hashOf := func(password string) string {
+		sum := sha1.Sum([]byte(password))
+		return strings.ToUpper(hex.EncodeToString(sum[:]))
+	}
+	writeRange := func(name string, lines ...string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(lines, "\n")), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	common := hashOf("Password123")
+	rare := hashOf("Jarang12345")
+	writeRange(common[:5], "0000000000000000000000000000000000A:3", common[5:]+":52000")
+	// Prefix kedua memakai akhiran .txt dan huruf kecil
+	writeRange(rare[:5]+".txt", strings.ToLower(rare[5:])+":1")
+
+	tests := []struct {
+		name     string
+		minCount int
+		password string
+		breached bool
+	}{
+		{"ada di daftar", 1, "Password123", true},
+		{"file .txt dan huruf kecil", 1, "Jarang12345", true},
+		{"jumlah di bawah minimum", 10, "Jarang12345", false},
+		{"prefix tidak ada", 1, "TidakPernahBocor9", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &PasswordPolicy{BreachedDir: dir, BreachedMinCount: tt.minCount}
+			got := slices.Contains(errorCodes(t, p, tt.password), "breached")
+			if got != tt.breached {
+				t.Fatalf("breached = %v, want %v", got, tt.breached)
+			}
+		})
+	}
+
+	if slices.Contains(errorCodes(t, &PasswordPolicy{}, "Password123"), "breached") {
+		t.Fatal("pengecekan kebocoran jalan tanpa PASSWORD_BREACHED_DIR")
+	}
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Parameter argon2id kecil agar test tetap cepat.
+var testArgon2 = Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestPasswordHasherHashFormat(t *testing.T) {
+	h := &PasswordHasher{Algorithm: PasswordAlgorithmArgon2id, Argon2: testArgon2}
+	hash, err := h.Hash("Rahasia123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("hash = %s, want PHC string argon2id", hash)
+	}
+
+	other, _ := h.Hash("Rahasia123")
+	if other == hash {
+		t.Fatal("dua hash password yang sama identik, salt tidak acak")
+	}
+}
+
+func TestPasswordHasherVerify(t *testing.T) {
+	current := &PasswordHasher{Algorithm: PasswordAlgorithmArgon2id, Argon2: testArgon2, BcryptCost: bcrypt.MinCost}
+
+	mustHash := func(h *PasswordHasher) string {
+		t.Helper()
+		hash, err := h.Hash("Rahasia123")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+	withArgon2 := func(change func(*Argon2Params)) *PasswordHasher {
+		p := testArgon2
+		change(&p)
+		return &PasswordHasher{Algorithm: PasswordAlgorithmArgon2id, Argon2: p}
+	}
+
+	tests := []struct {
+		name       string
+		hasher     *PasswordHasher
+		hash       string
+		password   string
+		wantOK     bool
+		wantRehash bool
+	}{
+		{"argon2id parameter sama", current, mustHash(current), "Rahasia123", true, false},
+		{"password salah", current, mustHash(current), "Rahasia124", false, false},
+		{"memory berbeda", current, mustHash(withArgon2(func(p *Argon2Params) { p.Memory = 2048 })), "Rahasia123", true, true},
+		{"iterasi berbeda", current, mustHash(withArgon2(func(p *Argon2Params) { p.Iterations = 2 })), "Rahasia123", true, true},
+		{"panjang salt berbeda", current, mustHash(withArgon2(func(p *Argon2Params) { p.SaltLength = 8 })), "Rahasia123", true, true},
+		{"panjang key berbeda", current, mustHash(withArgon2(func(p *Argon2Params) { p.KeyLength = 16 })), "Rahasia123", true, true},
+		{
+			name:       "bcrypt lama di-upgrade ke argon2id",
+			hasher:     current,
+			hash:       mustHash(&PasswordHasher{Algorithm: PasswordAlgorithmBcrypt, BcryptCost: bcrypt.MinCost}),
+			password:   "Rahasia123",
+			wantOK:     true,
+			wantRehash: true,
+		},
+		{
+			name:     "bcrypt password salah",
+			hasher:   current,
+			hash:     mustHash(&PasswordHasher{Algorithm: PasswordAlgorithmBcrypt, BcryptCost: bcrypt.MinCost}),
+			password: "salah",
+		},
+		{
+			name:     "bcrypt cost sama",
+			hasher:   &PasswordHasher{Algorithm: PasswordAlgorithmBcrypt, BcryptCost: bcrypt.MinCost},
+			hash:     mustHash(&PasswordHasher{Algorithm: PasswordAlgorithmBcrypt, BcryptCost: bcrypt.MinCost}),
+			password: "Rahasia123",
+			wantOK:   true,
+		},
+		{
+			name:       "bcrypt cost berbeda",
+			hasher:     &PasswordHasher{Algorithm: PasswordAlgorithmBcrypt, BcryptCost: bcrypt.MinCost + 1},
+			hash:       mustHash(&PasswordHasher{Algorithm: PasswordAlgorithmBcrypt, BcryptCost: bcrypt.MinCost}),
+			password:   "Rahasia123",
+			wantOK:     true,
+			wantRehash: true,
+		},
+		{
+			name:       "argon2id saat konfigurasi bcrypt",
+			hasher:     &PasswordHasher{Algorithm: PasswordAlgorithmBcrypt, BcryptCost: bcrypt.MinCost},
+			hash:       mustHash(current),
+			password:   "Rahasia123",
+			wantOK:     true,
+			wantRehash: true,
+		},
+		{"versi argon2 tidak didukung", current, "$argon2id$v=16$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5", "Rahasia123", false, false},
+		{"hash rusak", current, "$argon2id$v=19$m=1024", "Rahasia123", false, false},
+		{"hash kosong", current, "", "Rahasia123", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, rehash := tt.hasher.Verify(tt.password, tt.hash)
+			if ok != tt.wantOK || rehash != tt.wantRehash {
+				t.Fatalf("Verify = (%v, %v), want (%v, %v)", ok, rehash, tt.wantOK, tt.wantRehash)
+			}
+		})
+	}
+}
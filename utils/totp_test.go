@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Secret ASCII "12345678901234567890" dari RFC 6238 Appendix B, dalam base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	key, err := base32NoPad.DecodeString(rfc6238Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Vektor SHA1 RFC 6238 (8 digit), diambil 6 digit terakhir
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		if got := totpCode(key, tt.unix/totpPeriod); got != tt.want {
+			t.Errorf("totpCode(T=%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := now.Unix() / totpPeriod
+	key, _ := base32NoPad.DecodeString(rfc6238Secret)
+	codeAt := func(offset int64) string { return totpCode(key, step+offset) }
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		wantOK   bool
+		wantStep int64
+	}{
+		{"periode saat ini", rfc6238Secret, codeAt(0), true, step},
+		{"satu periode sebelumnya", rfc6238Secret, codeAt(-1), true, step - 1},
+		{"satu periode sesudahnya", rfc6238Secret, codeAt(1), true, step + 1},
+		{"dua periode sebelumnya ditolak", rfc6238Secret, codeAt(-2), false, 0},
+		{"dua periode sesudahnya ditolak", rfc6238Secret, codeAt(2), false, 0},
+		{"spasi diabaikan", rfc6238Secret, codeAt(0)[:3] + " " + codeAt(0)[3:], true, step},
+		{"secret huruf kecil", strings.ToLower(rfc6238Secret), codeAt(0), true, step},
+		{"panjang kode salah", rfc6238Secret, codeAt(0)[:5], false, 0},
+		{"secret tidak valid", "bukan-base32!", codeAt(0), false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := VerifyTOTP(tt.secret, tt.code, now)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Fatalf("VerifyTOTP = (%d, %v), want (%d, %v)", gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPSecretVerifies(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := base32NoPad.DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Fatalf("secret %q bukan base32 160-bit", secret)
+	}
+	now := time.Now()
+	if _, ok := VerifyTOTP(secret, totpCode(key, now.Unix()/totpPeriod), now); !ok {
+		t.Fatal("kode dari secret baru ditolak")
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	t.Setenv("TOTP_ISSUER", "Alumni Kampus")
+	u, err := url.Parse(TOTPProvisioningURI("budi@example.com", rfc6238Secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Alumni Kampus:budi@example.com" {
+		t.Fatalf("URI = %s", u)
+	}
+	if q.Get("secret") != rfc6238Secret || q.Get("issuer") != "Alumni Kampus" || q.Get("digits") != "6" || q.Get("period") != "30" {
+		t.Fatalf("parameter URI = %v", q)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 10 || len(hashes) != 10 {
+		t.Fatalf("dapat %d kode / %d hash, want 10", len(codes), len(hashes))
+	}
+	seen := map[string]bool{}
+	for i, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("format kode %q, want xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("kode %q duplikat", code)
+		}
+		seen[code] = true
+		if HashRecoveryCode(code) != hashes[i] {
+			t.Errorf("hash kode %q tidak cocok", code)
+		}
+	}
+
+	// Input user dinormalkan sebelum dibandingkan
+	for _, input := range []string{"abcde-fghij", "ABCDE-FGHIJ", "abcdefghij", " abcde fghij "} {
+		if HashRecoveryCode(input) != HashRecoveryCode("abcde-fghij") {
+			t.Errorf("HashRecoveryCode(%q) berbeda dari bentuk normalnya", input)
+		}
+	}
+}
+
+func TestRequiresTwoFactor(t *testing.T) {
+	tests := []struct {
+		env  string
+		set  bool
+		role string
+		want bool
+	}{
+		{set: false, role: "admin", want: true},
+		{set: false, role: "user", want: false},
+		{env: "", set: true, role: "admin", want: false},
+		{env: "admin, operator", set: true, role: "operator", want: true},
+		{env: "admin,operator", set: true, role: "user", want: false},
+		{env: "admin,", set: true, role: "", want: false},
+	}
+	for _, tt := range tests {
+		if tt.set {
+			t.Setenv("REQUIRE_2FA_ROLES", tt.env)
+		} else {
+			t.Setenv("REQUIRE_2FA_ROLES", "")
+			unsetEnv(t, "REQUIRE_2FA_ROLES")
+		}
+		if got := RequiresTwoFactor(tt.role); got != tt.want {
+			t.Errorf("REQUIRE_2FA_ROLES=%q (set=%v), RequiresTwoFactor(%q) = %v, want %v", tt.env, tt.set, tt.role, got, tt.want)
+		}
+	}
+}
+
+// unsetEnv menghapus environment variable; nilainya dikembalikan t.Setenv setelah test selesai.
+func unsetEnv(t *testing.T, key string) {
+	t.Helper()
+	if err := os.Unsetenv(key); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"gofiber-mongo/app/model"
+	"log"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	dateLayout        = "2006-01-02"
+	defaultNIMPattern = `^[0-9]{6,20}$`
+)
+
+var (
+	validate     *validator.Validate
+	validateOnce sync.Once
+
+	phonePattern = regexp.MustCompile(`^\+?[0-9][0-9 \-]{6,19}$`)
+)
+
+// nimPattern membaca NIM_PATTERN (regex). Pola yang tidak valid diganti default.
+func nimPattern() *regexp.Regexp {
+	value := os.Getenv("NIM_PATTERN")
+	if value == "" {
+		return regexp.MustCompile(defaultNIMPattern)
+	}
+	re, err := regexp.Compile(value)
+	if err != nil {
+		log.Printf("Peringatan: NIM_PATTERN tidak valid (%q). Menggunakan default: %s", value, defaultNIMPattern)
+		return regexp.MustCompile(defaultNIMPattern)
+	}
+	return re
+}
+
+func validatorInstance() *validator.Validate {
+	validateOnce.Do(func() {
+		v := validator.New(validator.WithRequiredStructEnabled())
+
+		// Nama field di error mengikuti nama JSON, bukan nama field Go
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+
+		nim := nimPattern()
+		must(v.RegisterValidation("nim", func(fl validator.FieldLevel) bool {
+			return nim.MatchString(fl.Field().String())
+		}))
+		must(v.RegisterValidation("phone", func(fl validator.FieldLevel) bool {
+			return phonePattern.MatchString(fl.Field().String())
+		}))
+		// String kosong lolos (mis. tanggal_selesai_kerja "" berarti masih bekerja); pakai required jika wajib
+		must(v.RegisterValidation("date", func(fl validator.FieldLevel) bool {
+			if fl.Field().String() == "" {
+				return true
+			}
+			_, err := time.Parse(dateLayout, fl.Field().String())
+			return err == nil
+		}))
+		// date_gtefield=Field: tanggal tidak boleh sebelum tanggal di field lain (format YYYY-MM-DD)
+		must(v.RegisterValidation("date_gtefield", func(fl validator.FieldLevel) bool {
+			other := fl.Parent().FieldByName(fl.Param())
+			if other.Kind() == reflect.Pointer {
+				if other.IsNil() {
+					return true
+				}
+				other = other.Elem()
+			}
+			start, err := time.Parse(dateLayout, other.String())
+			if err != nil {
+				// Field pembanding divalidasi sendiri
+				return true
+			}
+			end, err := time.Parse(dateLayout, fl.Field().String())
+			return err != nil || !end.Before(start)
+		}))
+
+		validate = v
+	})
+	return validate
+}
+
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+// ValidateStruct memvalidasi request berdasarkan tag `validate` dan mengembalikan
+// semua field yang tidak valid (slice kosong jika valid).
+func ValidateStruct(s any) []model.FieldError {
+	errs := []model.FieldError{}
+
+	err := validatorInstance().Struct(s)
+	if err == nil {
+		return errs
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return append(errs, model.FieldError{Field: "", Code: "invalid", Message: err.Error()})
+	}
+	for _, fe := range fieldErrs {
+		errs = append(errs, toFieldError(fe))
+	}
+	return errs
+}
+
+// toFieldError menerjemahkan error validator menjadi kode stabil dan pesan berbahasa Indonesia.
+func toFieldError(fe validator.FieldError) model.FieldError {
+	field := fe.Field()
+	param := fe.Param()
+	isString := fe.Kind() == reflect.String
+
+	code, message := "invalid", field+" tidak valid"
+	switch fe.Tag() {
+	case "required":
+		code, message = "required", field+" wajib diisi"
+	case "email":
+		code, message = "invalid_email", "Format email tidak valid"
+	case "nim":
+		code, message = "invalid_nim", "Format NIM tidak valid"
+	case "phone":
+		code, message = "invalid_phone", "Nomor telepon hanya boleh berisi angka, spasi, tanda - dan diawali + (7-20 karakter)"
+	case "date":
+		code, message = "invalid_date", field+" harus berformat YYYY-MM-DD"
+	case "mongodb":
+		code, message = "invalid_id", field+" bukan ID yang valid"
+	case "oneof":
+		code, message = "invalid_choice", field+" harus salah satu dari: "+strings.ReplaceAll(param, " ", ", ")
+	case "min", "gte":
+		if isString {
+			code, message = "too_short", fmt.Sprintf("%s minimal %s karakter", field, param)
+		} else {
+			code, message = "too_small", fmt.Sprintf("%s minimal %s", field, param)
+		}
+	case "max", "lte":
+		if isString {
+			code, message = "too_long", fmt.Sprintf("%s maksimal %s karakter", field, param)
+		} else {
+			code, message = "too_large", fmt.Sprintf("%s maksimal %s", field, param)
+		}
+	case "gtefield", "date_gtefield":
+		code, message = "before_"+snakeCase(param), fmt.Sprintf("%s tidak boleh lebih kecil dari %s", field, snakeCase(param))
+	}
+	return model.FieldError{Field: field, Code: code, Message: message}
+}
+
+// snakeCase mengubah nama field Go (TanggalMulaiKerja) menjadi nama JSON (tanggal_mulai_kerja).
+func snakeCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}